@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"oracle_engine/internal/admin"
+	"oracle_engine/internal/config"
+	"oracle_engine/internal/rpcpool"
+	"oracle_engine/internal/signer"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+const adminUsage = `usage: oracle admin <show|diff|apply> [flags]
+
+  show    print the admin settings of every contract, on chain and in config.yaml
+  diff    print the txs that bring the contracts in line with config.yaml, exits 1 on drift
+  apply   send those txs with the admin signer after a confirmation
+
+flags:
+`
+
+// stdin is shared by every confirmation, a reader per prompt would buffer
+// away the answers piped in for the contracts after it
+var stdin = bufio.NewReader(os.Stdin)
+
+// runAdmin is the admin command, it returns the exit code
+func runAdmin(cfg *config.Config, args []string) int {
+	flags := flag.NewFlagSet("oracle admin", flag.ContinueOnError)
+	chainID := flags.String("chain", "", "only the contracts of this chain id")
+	contract := flags.String("contract", "", "only the contract at this address")
+	dryRun := flags.Bool("dry-run", false, "apply: preview the txs without sending them")
+	yes := flags.Bool("yes", false, "apply: send without asking")
+	timeout := flags.Duration("timeout", 5*time.Minute, "give up after this long")
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), adminUsage)
+		flags.PrintDefaults()
+	}
+	if len(args) == 0 {
+		flags.Usage()
+		return 2
+	}
+	command := args[0]
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if command != "show" && command != "diff" && command != "apply" {
+		flags.Usage()
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	pool := rpcpool.New(cfg.RPCPool, cfg.Contracts)
+	defer pool.Close()
+
+	drift, failed := false, false
+	for _, ctrct := range cfg.Contracts {
+		if *chainID != "" && ctrct.ChainID != *chainID {
+			continue
+		}
+		if *contract != "" && !strings.EqualFold(ctrct.Address, *contract) {
+			continue
+		}
+		fmt.Printf("\n%s (chain %s) %s\n", ctrct.ChainName, ctrct.ChainID, ctrct.Address)
+		drifted, err := adminContract(ctx, cfg, pool, ctrct, command, *dryRun, *yes)
+		if err != nil {
+			fmt.Printf("  error: %v\n", err)
+			failed = true
+		}
+		drift = drift || drifted
+	}
+
+	switch {
+	case failed:
+		return 1
+	case command == "diff" && drift:
+		return 1
+	}
+	return 0
+}
+
+// adminContract runs command against one contract and reports whether it
+// drifted from config.yaml
+func adminContract(
+	ctx context.Context,
+	cfg *config.Config,
+	pool *rpcpool.Pool,
+	ctrct config.ContractConfig,
+	command string,
+	dryRun, yes bool,
+) (bool, error) {
+	desired, err := admin.DesiredOf(ctrct.Admin)
+	if err != nil {
+		return false, err
+	}
+	client, err := pool.Chain(ctrct.ChainID)
+	if err != nil {
+		return false, err
+	}
+	contract := admin.NewContract(ctrct, client)
+	state, err := contract.Read(ctx, desired.Owner)
+	if err != nil {
+		return false, err
+	}
+
+	// show and diff work without a signer, every change is blocked then
+	var s signer.Signer
+	var from common.Address
+	if signerCfg := cfg.AdminSignerFor(ctrct); signerCfg.Type != "" || signerCfg.Key != "" {
+		if s, err = signer.New(signerCfg); err != nil {
+			return false, fmt.Errorf("admin signer: %w", err)
+		}
+		from = s.Address()
+	}
+	changes := admin.Plan(state, desired, from, time.Now())
+
+	if command == "show" {
+		printSettings(os.Stdout, state, desired)
+		return len(changes) > 0, nil
+	}
+	if len(changes) == 0 {
+		fmt.Println("  in line with config.yaml")
+		return false, nil
+	}
+	for i, change := range changes {
+		printChange(os.Stdout, i+1, change)
+		if command == "apply" && change.Blocked == "" {
+			preview, err := contract.Preview(ctx, from, change)
+			if err != nil {
+				return true, err
+			}
+			printPreview(os.Stdout, from, preview)
+		}
+	}
+	if command != "apply" || dryRun {
+		return true, nil
+	}
+
+	sendable := make([]admin.Change, 0, len(changes))
+	for _, change := range changes {
+		if change.Blocked == "" {
+			sendable = append(sendable, change)
+		}
+	}
+	if len(sendable) == 0 {
+		return true, fmt.Errorf("the admin signer %s can make none of the changes", from.Hex())
+	}
+	if !yes && !confirm(stdin, fmt.Sprintf("  send %d txs from %s? [y/N] ", len(sendable), from.Hex())) {
+		fmt.Println("  skipped")
+		return true, nil
+	}
+
+	chain, ok := new(big.Int).SetString(ctrct.ChainID, 10)
+	if !ok {
+		return true, fmt.Errorf("chain id %q is not a number", ctrct.ChainID)
+	}
+	opts := signer.TransactOpts(ctx, s, chain)
+	for _, change := range sendable {
+		receipt, err := contract.Apply(ctx, opts, change)
+		if err != nil {
+			return true, err
+		}
+		fmt.Printf("  %s: %s in block %d\n", change.Method, receipt.TxHash.Hex(), receipt.BlockNumber.Uint64())
+		if change.Note != "" {
+			fmt.Printf("  %s\n", change.Note)
+		}
+	}
+	return true, nil
+}
+
+func printSettings(w io.Writer, state admin.State, desired admin.Desired) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "  setting\ton chain\tconfig.yaml\t")
+	row := func(setting, onChain, want string) {
+		mark := ""
+		if want != "-" && !strings.EqualFold(onChain, want) {
+			mark = "drift"
+		}
+		fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\n", setting, onChain, want, mark)
+	}
+	row(admin.SettingOwner, state.Owner.Hex(), addressOrDash(desired.Owner))
+	row(admin.SettingRelayerNode, state.RelayerNode.Hex(), addressOrDash(desired.RelayerNode))
+	forwarder := "n/a"
+	if state.HasForwarder {
+		forwarder = state.Forwarder.Hex()
+	}
+	row(admin.SettingForwarder, forwarder, addressOrDash(desired.Forwarder))
+	workflow, want := "n/a", "-"
+	if state.HasWorkflow {
+		workflow = hexutil.Encode(state.WorkflowID[:])
+	}
+	if desired.WorkflowID != nil {
+		want = hexutil.Encode(desired.WorkflowID[:])
+	}
+	row(admin.SettingWorkflowID, workflow, want)
+	if !state.HandoverExpiresAt.IsZero() {
+		fmt.Fprintf(tw, "  handover to %s\texpires %s\t\t\n", addressOrDash(desired.Owner), state.HandoverExpiresAt.UTC().Format(time.RFC3339))
+	}
+	tw.Flush()
+}
+
+func addressOrDash(address *common.Address) string {
+	if address == nil {
+		return "-"
+	}
+	return address.Hex()
+}
+
+func printChange(w io.Writer, n int, change admin.Change) {
+	from := change.From
+	if from == "" {
+		from = "n/a"
+	}
+	fmt.Fprintf(w, "  %d. %s: %s -> %s via %s\n", n, change.Setting, from, change.To, change.Method)
+	if change.Blocked != "" {
+		fmt.Fprintf(w, "     blocked: %s\n", change.Blocked)
+	}
+	if change.Note != "" {
+		fmt.Fprintf(w, "     %s\n", change.Note)
+	}
+}
+
+func printPreview(w io.Writer, from common.Address, preview admin.Preview) {
+	fmt.Fprintf(w, "     from %s to %s\n     data %s\n", from.Hex(), preview.To.Hex(), hexutil.Encode(preview.Data))
+	if preview.EstimateErr != nil {
+		fmt.Fprintf(w, "     gas estimate failed, the tx would likely revert: %v\n", preview.EstimateErr)
+		return
+	}
+	fmt.Fprintf(w, "     gas %d\n", preview.Gas)
+}
+
+func confirm(in *bufio.Reader, prompt string) bool {
+	fmt.Print(prompt)
+	answer, _ := in.ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -5,10 +5,14 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"oracle_engine/internal/aggregator"
+	"oracle_engine/internal/balances"
 	"oracle_engine/internal/config"
 	"oracle_engine/internal/consensus"
+	"oracle_engine/internal/consensus/breaker"
+	"oracle_engine/internal/consensus/multinode"
 	"oracle_engine/internal/database/timescale"
 	"oracle_engine/internal/datastream"
 	"oracle_engine/internal/datastream/coingecko"
@@ -22,10 +26,18 @@ import (
 	"oracle_engine/internal/logging"
 	"oracle_engine/internal/models"
 	"oracle_engine/internal/pricepool"
+	"oracle_engine/internal/reconcile"
+	"oracle_engine/internal/registry"
 	"oracle_engine/internal/relayer"
+	"oracle_engine/internal/reputation"
+	"oracle_engine/internal/rpcpool"
 	"oracle_engine/internal/server"
+	"oracle_engine/internal/simchain"
 
 	_ "oracle_engine/docs"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"go.uber.org/zap"
 )
 
 func main() {
@@ -33,6 +45,10 @@ func main() {
 	logging.Init()
 
 	cfg := config.Load()
+	if len(os.Args) > 1 && os.Args[1] == "admin" {
+		// manage the contracts' admin settings instead of running the engine
+		os.Exit(runAdmin(cfg, os.Args[2:]))
+	}
 	logging.Logger.Info("Starting oracle")
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -41,6 +57,13 @@ func main() {
 	// DB
 	db, _ := timescale.NewTimescaleDB(cfg.DB_URL)
 
+	// Asset registry, reloaded on config changes and SIGHUP
+	assetRegistry, err := registry.New(cfg)
+	if err != nil {
+		logging.Logger.Fatal("Invalid asset config", zap.Error(err))
+	}
+	go assetRegistry.Watch(ctx, config.File(), 5*time.Second)
+
 	// Initialize Data Stream
 	priceCh := make(chan models.Price, 100)
 	ds := datastream.New(cfg, priceCh, db)
@@ -62,15 +85,83 @@ func main() {
 	pp := pricepool.New(cfg, priceCh)
 	go pp.Start(ctx)
 
+	// Source reputation
+	reputationTracker := reputation.New(cfg, db)
+	reputationTracker.UseRegistry(assetRegistry)
+	go reputationTracker.Start(ctx)
+
 	// Aggr
-	aggr := aggregator.New(ctx, cfg)
+	aggr := aggregator.New(ctx, cfg, assetRegistry, reputationTracker)
 	go aggr.Run(ctx, pp.OutChannel())
 
-	relayer := relayer.New(cfg, db)
-	consensus := consensus.New(relayer, db)
+	// Circuit breaker, halts left open on shutdown stay open
+	assetBreaker := breaker.New(assetRegistry.Setting, db)
+	if err := assetBreaker.Load(ctx); err != nil {
+		logging.Logger.Error("Failed to load asset halts", zap.Error(err))
+	}
+
+	// Long lived rpc clients, failing over between the endpoints of a chain
+	rpcPool := rpcpool.New(cfg.RPCPool, cfg.Contracts)
+	clients := relayer.PoolClients(rpcPool)
+	callers := reconcile.PoolCallers(rpcPool)
+	balanceClients := balances.PoolClients(rpcPool)
+	if cfg.RelayerMode == config.RelayerModeSimulated {
+		// publish to in process chains, nothing is sent and no gas is spent
+		sims, err := simchain.New(ctx, cfg)
+		if err != nil {
+			logging.Logger.Fatal("Failed to start simulated chains", zap.Error(err))
+		}
+		defer sims.Close()
+		go sims.Run(ctx, 2*time.Second)
+		clients = func(chainID string) (relayer.ChainClient, error) {
+			return sims.Client(chainID)
+		}
+		callers = func(chainID string) (bind.ContractCaller, error) {
+			return sims.Client(chainID)
+		}
+		balanceClients = func(chainID string) (balances.Client, error) {
+			return sims.Client(chainID)
+		}
+		logging.Logger.Warn("Relayer is publishing to simulated chains")
+	} else {
+		go rpcPool.Start(ctx)
+	}
+
+	relayer := relayer.New(cfg, db, clients)
+	if err := relayer.LoadKeys(); err != nil {
+		logging.Logger.Fatal("Failed to load relayer signers", zap.Error(err))
+	}
+	relayer.UseRegistry(assetRegistry)
+	consensus := consensus.New(assetRegistry, relayer, db, assetBreaker)
+	if cfg.MultiNode.Enabled {
+		var transport *multinode.HTTPTransport
+		node, err := multinode.NewFromConfig(cfg.MultiNode, func(self string) multinode.Transport {
+			transport = multinode.NewHTTPTransport(cfg.MultiNode, self)
+			return transport
+		})
+		if err != nil {
+			logging.Logger.Fatal("Failed to start multinode consensus", zap.Error(err))
+		}
+		go func() {
+			if err := transport.Start(ctx); err != nil {
+				logging.Logger.Error("Multinode peer endpoint stopped", zap.Error(err))
+			}
+		}()
+		go node.Run(ctx)
+		consensus.UseMultiNode(node)
+		logging.Logger.Info("Multinode consensus enabled", zap.String("node", node.Address().Hex()))
+	}
 	go consensus.Ambassador(ctx, aggr.AggrOutCh)
 
-	srv := server.New(cfg, consensus.IssuanceChan(), db)
+	// Reads the contracts back and alerts on feeds out of sync with the engine
+	reconciler := reconcile.New(cfg, assetRegistry, relayer.Carries, db, reconcile.NewChainReader(callers))
+	go reconciler.Start(ctx)
+
+	// Watches the relayer wallets and alerts before they run dry
+	wallets := balances.New(cfg, relayer.Wallets, balances.NewChainReader(cfg, balanceClients))
+	go wallets.Start(ctx)
+
+	srv := server.New(cfg, consensus.IssuanceChan(), db, assetBreaker, relayer, consensus.History(), assetRegistry, rpcPool, reconciler, wallets)
 	go srv.StartHTTPServer(ctx)
 
 	// Graceful shutdown
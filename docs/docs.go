@@ -23,66 +23,125 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/assets": {
+        "/admin/costs": {
             "get": {
-                "description": "Returns list of all available assets",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns what publishing cost per period, chain and asset, newest first. Every mined batch tx is split evenly between its assets, usd costs use the engine's own price of the chain's native token when the tx was mined",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "assets"
+                    "admin"
+                ],
+                "summary": "Get relay costs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Start in RFC3339 format (e.g., 2024-01-01T00:00:00Z)",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "End in RFC3339 format (e.g., 2024-02-01T00:00:00Z)",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Period of a row: hour, day (default), week or month",
+                        "name": "interval",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only this chain id",
+                        "name": "chain",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only this asset, by name or id",
+                        "name": "asset",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get available assets",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.AssetData"
+                                "$ref": "#/definitions/models.RelayCostSummary"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
                             }
                         }
                     }
                 }
             }
         },
-        "/dashboard/login": {
-            "post": {
-                "description": "Login with email and password",
-                "consumes": [
-                    "application/json"
+        "/admin/reconcile": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Returns the last check of every asset on every contract carrying it, drifted, stale, missing and unreadable feeds first",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "dashboard"
+                    "admin"
                 ],
-                "summary": "User Login",
+                "summary": "Get on chain reconciliation",
                 "parameters": [
                     {
-                        "description": "Login request",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.LoginRequest"
-                        }
+                        "type": "string",
+                        "description": "Only results with this status (ok, drift, stale, missing, error)",
+                        "name": "status",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.LoginResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ReconcileResult"
                             }
                         }
                     },
@@ -98,39 +157,47 @@ const docTemplate = `{
                 }
             }
         },
-        "/dashboard/signup": {
-            "post": {
-                "description": "Create a new company profile and user account",
-                "consumes": [
-                    "application/json"
+        "/admin/reviews": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Returns denied issuances no operator decided on yet, newest first, with the aggregate, its provenance, the band it was judged against and the last approved issuance",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "dashboard"
+                    "admin"
                 ],
-                "summary": "User Sign Up a company",
+                "summary": "List denied issuances awaiting review",
                 "parameters": [
                     {
-                        "description": "Sign up request",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.SignUpRequest"
-                        }
+                        "type": "string",
+                        "description": "Asset ID to filter by (optional)",
+                        "name": "asset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Max results (default 50)",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.SignUpResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ReviewItem"
+                            }
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -150,28 +217,39 @@ const docTemplate = `{
                 }
             }
         },
-        "/dashboard/{id}/api-keys": {
+        "/admin/reviews/log": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get all API keys for a profile",
+                "description": "Returns every operator decision on denied issuances, newest first",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "dashboard"
+                    "admin"
                 ],
-                "summary": "Get API Keys",
+                "summary": "Get the review audit log",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Profile ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Asset ID to filter by (optional)",
+                        "name": "asset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Operator to filter by (optional)",
+                        "name": "operator",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Max results (default 100)",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -180,16 +258,7 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.APIKey"
-                            }
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
+                                "$ref": "#/definitions/models.IssuanceReview"
                             }
                         }
                     },
@@ -202,15 +271,6 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "403": {
-                        "description": "Forbidden",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -221,14 +281,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/admin/reviews/{id}/approve": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Create a new API key for accessing the Oracle Engine API",
+                "description": "Overrides the denial of an issuance and relays it, refused once a newer issuance of the asset was approved or while the asset is halted",
                 "consumes": [
                     "application/json"
                 ],
@@ -236,32 +298,32 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "dashboard"
+                    "admin"
                 ],
-                "summary": "Create API Key",
+                "summary": "Approve and relay a denied issuance",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Profile ID",
+                        "description": "Issuance ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Create API key request",
+                        "description": "Reason for the decision",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.CreateAPIKeyRequest"
+                            "$ref": "#/definitions/models.ReviewRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.CreateAPIKeyResponse"
+                            "$ref": "#/definitions/models.IssuanceReview"
                         }
                     },
                     "400": {
@@ -273,17 +335,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    },
-                    "403": {
-                        "description": "Forbidden",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -291,8 +344,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -303,58 +356,51 @@ const docTemplate = `{
                 }
             }
         },
-        "/dashboard/{id}/api-keys/{key_id}": {
-            "delete": {
+        "/admin/reviews/{id}/reject": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Delete an API key",
+                "description": "Closes the review of a denied issuance, it is never relayed",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "dashboard"
+                    "admin"
                 ],
-                "summary": "Delete API Key",
+                "summary": "Reject a denied issuance",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Profile ID",
+                        "description": "Issuance ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "API Key ID",
-                        "name": "key_id",
-                        "in": "path",
-                        "required": true
+                        "description": "Reason for the decision",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ReviewRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    },
-                    "401": {
-                        "description": "Unauthorized",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.IssuanceReview"
                         }
                     },
-                    "403": {
-                        "description": "Forbidden",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -371,8 +417,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -383,57 +429,67 @@ const docTemplate = `{
                 }
             }
         },
-        "/dashboard/{id}/payment": {
-            "post": {
+        "/admin/rpc": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Create a new payment (placeholder implementation)",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Returns every rpc endpoint of every chain in the order the relayer tries them, with head, latency and failure counts",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "dashboard"
-                ],
-                "summary": "Create Payment",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Profile ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Create payment request",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.CreatePaymentRequest"
-                        }
-                    }
+                    "admin"
                 ],
+                "summary": "Get rpc endpoint health",
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Payment"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.RPCEndpointStatus"
+                            }
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
                                 "type": "string"
                             }
                         }
+                    }
+                }
+            }
+        },
+        "/admin/wallets": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns the native balance, spend and projected runway of every relayer wallet, wallets needing funds first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get relayer wallets",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.WalletBalance"
+                            }
+                        }
                     },
                     "401": {
                         "description": "Unauthorized",
@@ -443,13 +499,50 @@ const docTemplate = `{
                                 "type": "string"
                             }
                         }
-                    },
-                    "403": {
-                        "description": "Forbidden",
+                    }
+                }
+            }
+        },
+        "/assets": {
+            "get": {
+                "description": "Returns list of all available assets, halted ones are flagged",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "assets"
+                ],
+                "summary": "Get available assets",
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.AssetData"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/assets/halts": {
+            "get": {
+                "description": "Returns the circuit breaker state of every asset, halted assets are not published on chain",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "assets"
+                ],
+                "summary": "Get asset halts",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.AssetHalt"
                             }
                         }
                     },
@@ -465,47 +558,107 @@ const docTemplate = `{
                 }
             }
         },
-        "/dashboard/{id}/payment/history": {
+        "/assets/registry": {
             "get": {
+                "description": "Returns the version of the per asset settings in use, and the last reload that was refused as invalid",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "assets"
+                ],
+                "summary": "Get the asset registry version",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.RegistryStatus"
+                        }
+                    }
+                }
+            }
+        },
+        "/assets/{id}/resume": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get payment history for a profile",
+                "description": "Acknowledges the halt of an asset and resumes publishing, the operator is taken from the admin token",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "dashboard"
+                    "assets"
                 ],
-                "summary": "Get Payment History",
+                "summary": "Resume a halted asset",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Profile ID",
+                        "description": "Asset ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.AssetHalt"
+                        }
                     },
-                    {
-                        "type": "integer",
-                        "description": "Page number (default: 1)",
-                        "name": "page",
-                        "in": "query"
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
                     },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/dashboard/login": {
+            "post": {
+                "description": "Login with email and password",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "dashboard"
+                ],
+                "summary": "User Login",
+                "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Page size (default: 20, max: 100)",
-                        "name": "page_size",
-                        "in": "query"
+                        "description": "Login request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.LoginRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.PaymentHistoryResponse"
+                            "$ref": "#/definitions/models.LoginResponse"
                         }
                     },
                     "400": {
@@ -525,9 +678,43 @@ const docTemplate = `{
                                 "type": "string"
                             }
                         }
+                    }
+                }
+            }
+        },
+        "/dashboard/signup": {
+            "post": {
+                "description": "Create a new company profile and user account",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "dashboard"
+                ],
+                "summary": "User Sign Up a company",
+                "parameters": [
+                    {
+                        "description": "Sign up request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SignUpRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.SignUpResponse"
+                        }
                     },
-                    "403": {
-                        "description": "Forbidden",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -547,21 +734,21 @@ const docTemplate = `{
                 }
             }
         },
-        "/dashboard/{id}/profile": {
+        "/dashboard/{id}/api-keys": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get company profile information",
+                "description": "Get all API keys for a profile",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "dashboard"
                 ],
-                "summary": "Get User Profile",
+                "summary": "Get API Keys",
                 "parameters": [
                     {
                         "type": "string",
@@ -575,11 +762,14 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.CompanyProfile"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.APIKey"
+                            }
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -587,8 +777,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "403": {
-                        "description": "Forbidden",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -596,8 +786,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -616,13 +806,13 @@ const docTemplate = `{
                     }
                 }
             },
-            "put": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Update company profile information",
+                "description": "Create a new API key for accessing the Oracle Engine API",
                 "consumes": [
                     "application/json"
                 ],
@@ -632,7 +822,7 @@ const docTemplate = `{
                 "tags": [
                     "dashboard"
                 ],
-                "summary": "Update User Profile",
+                "summary": "Create API Key",
                 "parameters": [
                     {
                         "type": "string",
@@ -642,20 +832,20 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "Update profile request",
+                        "description": "Create API key request",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.UpdateProfileRequest"
+                            "$ref": "#/definitions/models.CreateAPIKeyRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/models.CompanyProfile"
+                            "$ref": "#/definitions/models.CreateAPIKeyResponse"
                         }
                     },
                     "400": {
@@ -697,24 +887,18 @@ const docTemplate = `{
                 }
             }
         },
-        "/dashboard/{id}/usage": {
-            "get": {
+        "/dashboard/{id}/api-keys/{key_id}": {
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Returns API usage statistics for the authenticated user",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
+                "description": "Delete an API key",
                 "tags": [
                     "dashboard"
                 ],
-                "summary": "Get API usage statistics",
+                "summary": "Delete API Key",
                 "parameters": [
                     {
                         "type": "string",
@@ -724,39 +908,73 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "type": "integer",
-                        "default": 1,
-                        "description": "Page number",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "default": 20,
-                        "description": "Items per page",
-                        "name": "limit",
-                        "in": "query"
+                        "type": "string",
+                        "description": "API Key ID",
+                        "name": "key_id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
-                            "type": "object"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "type": "object"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     }
                 }
             }
         },
-        "/issuances/{id}": {
-            "get": {
-                "description": "Returns details of a specific issuance",
+        "/dashboard/{id}/payment": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a new payment (placeholder implementation)",
                 "consumes": [
                     "application/json"
                 ],
@@ -764,72 +982,106 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "issuances"
+                    "dashboard"
                 ],
-                "summary": "Get issuance details",
+                "summary": "Create Payment",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Issuance ID",
+                        "description": "Profile ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Create payment request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreatePaymentRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/models.Issuance"
+                            "$ref": "#/definitions/models.Payment"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     }
                 }
             }
         },
-        "/prices/audit": {
+        "/dashboard/{id}/payment/history": {
             "get": {
-                "description": "Returns audit information for prices within a specified date range",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Get payment history for a profile",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "prices"
+                    "dashboard"
                 ],
-                "summary": "Get price audit by date range",
+                "summary": "Get Payment History",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Start date in RFC3339 format (e.g., 2024-01-01T00:00:00Z)",
-                        "name": "from",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "End date in RFC3339 format (e.g., 2024-01-02T00:00:00Z)",
-                        "name": "to",
-                        "in": "query",
+                        "description": "Profile ID",
+                        "name": "id",
+                        "in": "path",
                         "required": true
                     },
-                    {
-                        "type": "string",
-                        "description": "Asset ID to filter by (optional)",
-                        "name": "asset",
-                        "in": "query"
-                    },
                     {
                         "type": "integer",
-                        "description": "Maximum number of records to return (default: 100, max: 1000)",
-                        "name": "limit",
+                        "description": "Page number (default: 1)",
+                        "name": "page",
                         "in": "query"
                     },
                     {
                         "type": "integer",
-                        "description": "Number of records to skip (default: 0)",
-                        "name": "offset",
+                        "description": "Page size (default: 20, max: 100)",
+                        "name": "page_size",
                         "in": "query"
                     }
                 ],
@@ -837,7 +1089,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object"
+                            "$ref": "#/definitions/models.PaymentHistoryResponse"
                         }
                     },
                     "400": {
@@ -849,6 +1101,24 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -861,57 +1131,391 @@ const docTemplate = `{
                 }
             }
         },
-        "/prices/last": {
+        "/dashboard/{id}/profile": {
             "get": {
-                "description": "Returns the last known price for a specific asset or all assets",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Get company profile information",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "prices"
+                    "dashboard"
                 ],
-                "summary": "Get last price for an asset",
+                "summary": "Get User Profile",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Asset ID to get price for",
-                        "name": "asset",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Comma-separated list of price change periods (e.g. '7d,3d,24h'). Default is '7d'",
-                        "name": "changes",
-                        "in": "query"
+                        "description": "Profile ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.CompanyProfile"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
-                                "$ref": "#/definitions/models.UnifiedPrice"
+                                "type": "string"
                             }
                         }
-                    }
-                }
-            }
-        },
-        "/prices/stream": {
-            "get": {
-                "description": "Server-Sent Events stream of price updates, have a retry mechanism in place for break",
-                "produces": [
-                    "text/event-stream"
-                ],
-                "tags": [
-                    "prices"
-                ],
-                "summary": "Model Stream price updates",
-                "responses": {
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update company profile information",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "dashboard"
+                ],
+                "summary": "Update User Profile",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Profile ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Update profile request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateProfileRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.CompanyProfile"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/dashboard/{id}/usage": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns API usage statistics for the authenticated user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "dashboard"
+                ],
+                "summary": "Get API usage statistics",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Profile ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Items per page",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/health": {
+            "get": {
+                "description": "Returns the engine status with the relayer wallet runway of every chain, chains needing funds first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Health check",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.HealthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/issuances/{id}": {
+            "get": {
+                "description": "Returns details of a specific issuance, with its lifecycle state (queued, submitted, confirmed, failed, replaced) on every contract",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "issuances"
+                ],
+                "summary": "Get issuance details",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Issuance ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Issuance"
+                        }
+                    }
+                }
+            }
+        },
+        "/prices/audit": {
+            "get": {
+                "description": "Returns audit information for prices within a specified date range",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "prices"
+                ],
+                "summary": "Get price audit by date range",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Start date in RFC3339 format (e.g., 2024-01-01T00:00:00Z)",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date in RFC3339 format (e.g., 2024-01-02T00:00:00Z)",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Asset ID to filter by (optional)",
+                        "name": "asset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of records to return (default: 100, max: 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of records to skip (default: 0)",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/prices/last": {
+            "get": {
+                "description": "Returns the last known price for a specific asset or all assets",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "prices"
+                ],
+                "summary": "Get last price for an asset",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Asset ID to get price for",
+                        "name": "asset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated list of price change periods (e.g. '7d,3d,24h'). Default is '7d'",
+                        "name": "changes",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/models.UnifiedPrice"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/prices/stream": {
+            "get": {
+                "description": "Server-Sent Events stream of price updates, have a retry mechanism in place for break.\nNew issuances are sent as \"price\" events, later lifecycle changes (queued, submitted, confirmed, failed, replaced) as \"issuance_state\" events.\nAggregates of an asset halted by its circuit breaker carry \"halted\": true and are not published on chain.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "prices"
+                ],
+                "summary": "Model Stream price updates",
+                "responses": {
                     "200": {
                         "description": "SSE stream",
                         "schema": {
@@ -953,6 +1557,46 @@ const docTemplate = `{
                 }
             }
         },
+        "/sources/reputation": {
+            "get": {
+                "description": "Returns the adaptive reputation score of every source, used as its weight during aggregation",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sources"
+                ],
+                "summary": "Get source reputation scores",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Asset ID to filter by (optional)",
+                        "name": "asset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.SourceReputation"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
         "/subscription/plans": {
             "get": {
                 "description": "Returns available subscription plans with pricing and limits",
@@ -978,6 +1622,21 @@ const docTemplate = `{
         }
     },
     "definitions": {
+        "api.HealthResponse": {
+            "type": "object",
+            "properties": {
+                "chains": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ChainRunway"
+                    }
+                },
+                "status": {
+                    "description": "ok, or degraded while a wallet needs funds",
+                    "type": "string"
+                }
+            }
+        },
         "models.APIKey": {
             "type": "object",
             "properties": {
@@ -1012,6 +1671,40 @@ const docTemplate = `{
                 }
             }
         },
+        "models.AggregationProvenance": {
+            "type": "object",
+            "properties": {
+                "contributions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SourceContribution"
+                    }
+                },
+                "distinct_sources": {
+                    "type": "integer"
+                },
+                "exclusions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.PriceExclusion"
+                    }
+                },
+                "max": {
+                    "type": "number"
+                },
+                "min": {
+                    "type": "number"
+                },
+                "sample_count": {
+                    "description": "prices in the window, excluded ones too",
+                    "type": "integer"
+                },
+                "stddev": {
+                    "description": "over included prices",
+                    "type": "number"
+                }
+            }
+        },
         "models.AssetData": {
             "type": "object",
             "properties": {
@@ -1020,6 +1713,62 @@ const docTemplate = `{
                 },
                 "asset_id": {
                     "type": "string"
+                },
+                "halted": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.AssetHalt": {
+            "type": "object",
+            "properties": {
+                "asset_id": {
+                    "type": "string"
+                },
+                "detail": {
+                    "type": "string"
+                },
+                "halted": {
+                    "type": "boolean"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "resumed_at": {
+                    "type": "string"
+                },
+                "resumed_by": {
+                    "description": "operator, or \"auto\" after stability",
+                    "type": "string"
+                },
+                "tripped_at": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ChainRunway": {
+            "type": "object",
+            "properties": {
+                "chain_id": {
+                    "type": "string"
+                },
+                "min_runway_hours": {
+                    "description": "shortest runway of the chain's wallets",
+                    "type": "number"
+                },
+                "needing_funds": {
+                    "description": "wallets not ok",
+                    "type": "integer"
+                },
+                "status": {
+                    "description": "ok, or degraded while a wallet is not ok",
+                    "type": "string"
+                },
+                "wallets": {
+                    "type": "integer"
                 }
             }
         },
@@ -1054,11 +1803,38 @@ const docTemplate = `{
                     "description": "\"free\", \"developer\", \"professional\", \"enterprise\"",
                     "type": "string"
                 },
-                "updated_at": {
-                    "type": "string"
+                "updated_at": {
+                    "type": "string"
+                },
+                "website": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ConsensusReport": {
+            "type": "object",
+            "properties": {
+                "asset_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "leader": {
+                    "type": "string"
+                },
+                "observations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SignedObservation"
+                    }
+                },
+                "round_id": {
+                    "type": "integer"
                 },
-                "website": {
-                    "type": "string"
+                "value": {
+                    "description": "median of the agreeing observations, expo -18",
+                    "type": "number"
                 }
             }
         },
@@ -1130,12 +1906,58 @@ const docTemplate = `{
                 }
             }
         },
+        "models.DeviationBand": {
+            "type": "object",
+            "properties": {
+                "checked": {
+                    "description": "value compared to the band",
+                    "type": "number"
+                },
+                "lower": {
+                    "type": "number"
+                },
+                "mode": {
+                    "type": "string"
+                },
+                "reference": {
+                    "type": "number"
+                },
+                "samples": {
+                    "type": "integer"
+                },
+                "upper": {
+                    "type": "number"
+                },
+                "volatility": {
+                    "type": "number"
+                }
+            }
+        },
         "models.Issuance": {
             "type": "object",
             "properties": {
+                "band": {
+                    "description": "band the aggregate was judged against",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.DeviationBand"
+                        }
+                    ]
+                },
+                "contracts": {
+                    "description": "lifecycle per contract once the issuance reached the relayer",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.IssuanceContractState"
+                    }
+                },
                 "created_at": {
                     "type": "string"
                 },
+                "halted": {
+                    "description": "the asset's circuit breaker is open, the issuance is not published",
+                    "type": "boolean"
+                },
                 "issuance_id": {
                     "type": "string"
                 },
@@ -1162,6 +1984,14 @@ const docTemplate = `{
                     "description": "Normalized price value with 5 decimal places",
                     "type": "number"
                 },
+                "report": {
+                    "description": "signed multi node report backing the issuance, nil in single node mode",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ConsensusReport"
+                        }
+                    ]
+                },
                 "round_id": {
                     "type": "integer"
                 },
@@ -1170,191 +2000,507 @@ const docTemplate = `{
                 }
             }
         },
+        "models.IssuanceContractState": {
+            "type": "object",
+            "properties": {
+                "block_number": {
+                    "type": "integer"
+                },
+                "chain_id": {
+                    "type": "string"
+                },
+                "contract": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "gas_used": {
+                    "type": "integer"
+                },
+                "state": {
+                    "$ref": "#/definitions/models.IssuanceState"
+                },
+                "state_name": {
+                    "type": "string"
+                },
+                "tx_hash": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.IssuanceReview": {
+            "type": "object",
+            "properties": {
+                "asset_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "decision": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "issuance_id": {
+                    "type": "string"
+                },
+                "operator": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
         "models.IssuanceState": {
             "type": "integer",
             "enum": [
                 0,
                 1,
-                2
+                2,
+                3,
+                4,
+                5,
+                6
             ],
+            "x-enum-comments": {
+                "Failed": "submission or tx failed",
+                "Queued": "handed to the relayer for a contract",
+                "Replaced": "superseded by a newer issuance of the same asset",
+                "Submitted": "tx sent, waiting for a receipt"
+            },
             "x-enum-varnames": [
                 "Denied",
                 "Approved",
-                "Confirmed"
+                "Confirmed",
+                "Queued",
+                "Submitted",
+                "Failed",
+                "Replaced"
             ]
         },
-        "models.LoginRequest": {
+        "models.LoginRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.LoginResponse": {
+            "type": "object",
+            "properties": {
+                "access_token": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "expires_in": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Payment": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "payment_method": {
+                    "type": "string"
+                },
+                "profile_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "\"pending\", \"completed\", \"failed\", \"refunded\"",
+                    "type": "string"
+                },
+                "subscription_type": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.PaymentHistoryResponse": {
+            "type": "object",
+            "properties": {
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "payments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Payment"
+                    }
+                },
+                "total_count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Price": {
+            "type": "object",
+            "properties": {
+                "asset": {
+                    "type": "string"
+                },
+                "expo": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "internal_asset_identity": {
+                    "type": "string"
+                },
+                "req_url": {
+                    "type": "string"
+                },
+                "source": {
+                    "type": "string"
+                },
+                "timestamp": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.PriceAudit": {
+            "type": "object",
+            "properties": {
+                "aggregated_price": {
+                    "$ref": "#/definitions/models.UnifiedPrice"
+                },
+                "asset_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "price_id": {
+                    "type": "string"
+                },
+                "provenance": {
+                    "description": "nil for prices aggregated before provenance was recorded",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.AggregationProvenance"
+                        }
+                    ]
+                },
+                "raw_prices": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Price"
+                    }
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.PriceChange": {
+            "type": "object",
+            "properties": {
+                "change": {
+                    "description": "Absolute change",
+                    "type": "number"
+                },
+                "change_pct": {
+                    "description": "Percentage change",
+                    "type": "number"
+                },
+                "from_price": {
+                    "description": "Starting price",
+                    "type": "number"
+                },
+                "from_time": {
+                    "description": "Starting time",
+                    "type": "string"
+                },
+                "period": {
+                    "description": "e.g. \"7d\", \"3d\", \"24h\"",
+                    "type": "string"
+                },
+                "to_price": {
+                    "description": "Current price",
+                    "type": "number"
+                },
+                "to_time": {
+                    "description": "Current time",
+                    "type": "string"
+                }
+            }
+        },
+        "models.PriceExclusion": {
             "type": "object",
-            "required": [
-                "email",
-                "password"
-            ],
             "properties": {
-                "email": {
+                "price_id": {
                     "type": "string"
                 },
-                "password": {
+                "reason": {
+                    "type": "string"
+                },
+                "source": {
                     "type": "string"
+                },
+                "value": {
+                    "type": "number"
                 }
             }
         },
-        "models.LoginResponse": {
+        "models.QuorumStatus": {
             "type": "object",
             "properties": {
-                "access_token": {
-                    "type": "string"
+                "degraded": {
+                    "type": "boolean"
                 },
-                "email": {
-                    "type": "string"
+                "distinct_sources": {
+                    "type": "integer"
                 },
-                "expires_in": {
+                "met": {
+                    "type": "boolean"
+                },
+                "min_sources": {
                     "type": "integer"
                 },
-                "id": {
-                    "type": "string"
+                "missing_sources": {
+                    "description": "required sources absent from the window",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "name": {
-                    "type": "string"
+                "sources": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "withheld": {
+                    "description": "missed quorum on a withhold asset, not issued",
+                    "type": "boolean"
                 }
             }
         },
-        "models.Payment": {
+        "models.RPCEndpointStatus": {
             "type": "object",
             "properties": {
-                "amount": {
-                    "type": "number"
+                "blocks_behind": {
+                    "type": "integer"
                 },
-                "created_at": {
+                "chain_id": {
                     "type": "string"
                 },
-                "currency": {
-                    "type": "string"
+                "consecutive_failures": {
+                    "type": "integer"
                 },
-                "id": {
+                "cooldown_until": {
                     "type": "string"
                 },
-                "payment_method": {
+                "endpoint": {
+                    "description": "host only, urls carry api keys",
                     "type": "string"
                 },
-                "profile_id": {
-                    "type": "string"
+                "failures": {
+                    "type": "integer"
                 },
-                "status": {
-                    "description": "\"pending\", \"completed\", \"failed\", \"refunded\"",
-                    "type": "string"
+                "head": {
+                    "type": "integer"
                 },
-                "subscription_type": {
+                "healthy": {
+                    "type": "boolean"
+                },
+                "last_error": {
                     "type": "string"
                 },
-                "updated_at": {
+                "last_error_at": {
                     "type": "string"
-                }
-            }
-        },
-        "models.PaymentHistoryResponse": {
-            "type": "object",
-            "properties": {
-                "page": {
-                    "type": "integer"
                 },
-                "page_size": {
-                    "type": "integer"
+                "latency_ms": {
+                    "type": "number"
                 },
-                "payments": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.Payment"
-                    }
+                "rank": {
+                    "type": "integer"
                 },
-                "total_count": {
+                "requests": {
                     "type": "integer"
                 }
             }
         },
-        "models.Price": {
+        "models.ReconcileResult": {
             "type": "object",
             "properties": {
                 "asset": {
                     "type": "string"
                 },
-                "expo": {
-                    "type": "integer"
+                "asset_id": {
+                    "type": "string"
                 },
-                "id": {
+                "chain_id": {
                     "type": "string"
                 },
-                "internal_asset_identity": {
+                "checked_at": {
                     "type": "string"
                 },
-                "req_url": {
+                "contract": {
                     "type": "string"
                 },
-                "source": {
+                "detail": {
                     "type": "string"
                 },
-                "timestamp": {
+                "drift": {
+                    "type": "number"
+                },
+                "engine_issuance": {
                     "type": "string"
                 },
-                "value": {
+                "engine_value": {
+                    "type": "number"
+                },
+                "on_chain_updated_at": {
+                    "type": "string"
+                },
+                "on_chain_value": {
                     "type": "number"
+                },
+                "since": {
+                    "description": "when the asset entered its status",
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
                 }
             }
         },
-        "models.PriceAudit": {
+        "models.RegistryStatus": {
             "type": "object",
             "properties": {
-                "aggregated_price": {
-                    "$ref": "#/definitions/models.UnifiedPrice"
+                "active": {
+                    "$ref": "#/definitions/models.RegistryVersion"
                 },
-                "asset_id": {
+                "last_error": {
                     "type": "string"
                 },
-                "created_at": {
+                "last_error_at": {
                     "type": "string"
+                }
+            }
+        },
+        "models.RegistryVersion": {
+            "type": "object",
+            "properties": {
+                "assets": {
+                    "type": "integer"
                 },
-                "price_id": {
+                "id": {
+                    "description": "hash of the asset configs",
                     "type": "string"
                 },
-                "raw_prices": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.Price"
-                    }
+                "loaded_at": {
+                    "type": "string"
                 },
-                "updated_at": {
+                "source": {
+                    "description": "\"startup\", \"file\" or \"sighup\"",
                     "type": "string"
                 }
             }
         },
-        "models.PriceChange": {
+        "models.RelayCostSummary": {
             "type": "object",
             "properties": {
-                "change": {
-                    "description": "Absolute change",
+                "asset": {
+                    "type": "string"
+                },
+                "asset_id": {
+                    "type": "string"
+                },
+                "chain_id": {
+                    "type": "string"
+                },
+                "cost": {
+                    "description": "native token",
                     "type": "number"
                 },
-                "change_pct": {
-                    "description": "Percentage change",
+                "cost_per_update_usd": {
                     "type": "number"
                 },
-                "from_price": {
-                    "description": "Starting price",
+                "cost_usd": {
+                    "description": "priced with the engine's own price of the native token",
                     "type": "number"
                 },
-                "from_time": {
-                    "description": "Starting time",
+                "cost_wei": {
                     "type": "string"
                 },
+                "gas_used": {
+                    "type": "integer"
+                },
                 "period": {
-                    "description": "e.g. \"7d\", \"3d\", \"24h\"",
                     "type": "string"
                 },
-                "to_price": {
-                    "description": "Current price",
-                    "type": "number"
+                "txs": {
+                    "type": "integer"
                 },
-                "to_time": {
-                    "description": "Current time",
+                "updates": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ReviewItem": {
+            "type": "object",
+            "properties": {
+                "issuance": {
+                    "$ref": "#/definitions/models.Issuance"
+                },
+                "last_approved": {
+                    "$ref": "#/definitions/models.Issuance"
+                }
+            }
+        },
+        "models.ReviewRequest": {
+            "type": "object",
+            "required": [
+                "reason"
+            ],
+            "properties": {
+                "reason": {
                     "type": "string"
                 }
             }
@@ -1405,6 +2551,83 @@ const docTemplate = `{
                 }
             }
         },
+        "models.SignedObservation": {
+            "type": "object",
+            "properties": {
+                "asset_id": {
+                    "type": "string"
+                },
+                "node": {
+                    "description": "signer address",
+                    "type": "string"
+                },
+                "round_id": {
+                    "type": "integer"
+                },
+                "signature": {
+                    "description": "0x prefixed, 65 bytes",
+                    "type": "string"
+                },
+                "timestamp": {
+                    "type": "integer"
+                },
+                "value": {
+                    "description": "decimal integer, expo -18",
+                    "type": "string"
+                }
+            }
+        },
+        "models.SourceContribution": {
+            "type": "object",
+            "properties": {
+                "price_id": {
+                    "type": "string"
+                },
+                "source": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "number"
+                },
+                "weight": {
+                    "description": "share of the aggregate, weights of one aggregate sum to 1",
+                    "type": "number"
+                }
+            }
+        },
+        "models.SourceReputation": {
+            "type": "object",
+            "properties": {
+                "asset_id": {
+                    "type": "string"
+                },
+                "deviation": {
+                    "description": "mean relative deviation from the final aggregate",
+                    "type": "number"
+                },
+                "samples": {
+                    "type": "integer"
+                },
+                "score": {
+                    "description": "0..1, used as aggregation weight",
+                    "type": "number"
+                },
+                "source": {
+                    "type": "string"
+                },
+                "staleness_seconds": {
+                    "description": "mean sample age at aggregation time",
+                    "type": "number"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uptime": {
+                    "description": "share of aggregation windows the source showed up in",
+                    "type": "number"
+                }
+            }
+        },
         "models.UnifiedPrice": {
             "type": "object",
             "properties": {
@@ -1434,6 +2657,22 @@ const docTemplate = `{
                         "$ref": "#/definitions/models.PriceChange"
                     }
                 },
+                "provenance": {
+                    "description": "how the aggregate was built, only set on aggregated prices",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.AggregationProvenance"
+                        }
+                    ]
+                },
+                "quorum": {
+                    "description": "source quorum of the aggregation window, only set on aggregated prices",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.QuorumStatus"
+                        }
+                    ]
+                },
                 "req_hash": {
                     "type": "string"
                 },
@@ -1475,6 +2714,44 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "models.WalletBalance": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "balance": {
+                    "description": "native token",
+                    "type": "number"
+                },
+                "balance_wei": {
+                    "type": "string"
+                },
+                "chain_id": {
+                    "type": "string"
+                },
+                "checked_at": {
+                    "type": "string"
+                },
+                "detail": {
+                    "type": "string"
+                },
+                "runway_hours": {
+                    "type": "number"
+                },
+                "since": {
+                    "description": "when the wallet entered its status",
+                    "type": "string"
+                },
+                "spend_per_hour": {
+                    "description": "native token, over the runway window",
+                    "type": "number"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
         }
     },
     "securityDefinitions": {
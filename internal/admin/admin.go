@@ -0,0 +1,293 @@
+package admin
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"oracle_engine/internal/config"
+	importVerifier "oracle_engine/pkg/abi"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+/*
+Admin:
+Keeps the admin settings of the oracle contracts in line with the admin
+block of every contract in config.yaml. It reads the owner, relayer node,
+CRE forwarder and workflow id off the contract, plans the txs that move
+them to the configured values and previews or sends them with the admin
+signer. Settings the config leaves empty are never touched.
+
+The owner changes in two steps. The new owner requests the handover, and
+the current owner completes it within 48 hours. Apply runs once with the
+new owner's signer and once more with the current owner's. Every other
+setting needs the current owner's signer, so those changes go out before
+the handover completes.
+*/
+
+var oracleAdminABI, _ = abi.JSON(strings.NewReader(importVerifier.OracleAdminABI))
+
+// Settings names
+const (
+	SettingOwner       = "owner"
+	SettingRelayerNode = "relayer_node"
+	SettingForwarder   = "forwarder_address"
+	SettingWorkflowID  = "expected_workflow_id"
+)
+
+// Backend is what reading and sending admin txs needs from a chain
+type Backend interface {
+	bind.ContractBackend
+	bind.DeployBackend
+}
+
+// State is the admin settings a contract holds on chain
+type State struct {
+	Owner        common.Address
+	RelayerNode  common.Address
+	Forwarder    common.Address
+	HasForwarder bool // false on contracts without CRE
+	WorkflowID   [32]byte
+	HasWorkflow  bool
+	// end of the handover requested by the configured owner, zero without one
+	HandoverExpiresAt time.Time
+}
+
+// Desired is the admin settings config.yaml sets for a contract, nil
+// where it leaves one alone
+type Desired struct {
+	Owner       *common.Address
+	RelayerNode *common.Address
+	Forwarder   *common.Address
+	WorkflowID  *[32]byte
+}
+
+// DesiredOf parses the admin block of a contract
+func DesiredOf(cfg config.ContractAdminConfig) (Desired, error) {
+	var d Desired
+	var err error
+	if d.Owner, err = parseAddress(SettingOwner, cfg.Owner); err != nil {
+		return d, err
+	}
+	if d.RelayerNode, err = parseAddress(SettingRelayerNode, cfg.RelayerNode); err != nil {
+		return d, err
+	}
+	if d.Forwarder, err = parseAddress(SettingForwarder, cfg.ForwarderAddress); err != nil {
+		return d, err
+	}
+	if id := strings.TrimPrefix(strings.TrimSpace(cfg.ExpectedWorkflowID), "0x"); id != "" {
+		raw, err := hex.DecodeString(id)
+		if err != nil || len(raw) != 32 {
+			return d, fmt.Errorf("%s %q is not 32 bytes of hex", SettingWorkflowID, cfg.ExpectedWorkflowID)
+		}
+		var workflowID [32]byte
+		copy(workflowID[:], raw)
+		d.WorkflowID = &workflowID
+	}
+	return d, nil
+}
+
+func parseAddress(setting, value string) (*common.Address, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	if !common.IsHexAddress(value) {
+		return nil, fmt.Errorf("%s %q is not an address", setting, value)
+	}
+	address := common.HexToAddress(value)
+	return &address, nil
+}
+
+// Change is one admin tx that moves a setting to its configured value
+type Change struct {
+	Setting string
+	From    string
+	To      string
+	Method  string
+	Args    []interface{}
+	// why the signer cannot send it, empty when it can
+	Blocked string
+	// what is left to do after it, like completing a handover
+	Note string
+}
+
+// Plan lists the changes that bring state in line with desired when
+// signed by signer, the owner change last
+func Plan(state State, desired Desired, signer common.Address, now time.Time) []Change {
+	var changes []Change
+	byOwner := func(c Change) Change {
+		if signer != state.Owner {
+			c.Blocked = fmt.Sprintf("needs the owner %s, the admin signer is %s", state.Owner.Hex(), signerName(signer))
+		}
+		return c
+	}
+
+	if d := desired.RelayerNode; d != nil && *d != state.RelayerNode {
+		changes = append(changes, byOwner(Change{
+			Setting: SettingRelayerNode, From: state.RelayerNode.Hex(), To: d.Hex(),
+			Method: "setRelayerNode", Args: []interface{}{*d},
+		}))
+	}
+	if d := desired.Forwarder; d != nil && (!state.HasForwarder || *d != state.Forwarder) {
+		c := byOwner(Change{
+			Setting: SettingForwarder, From: state.Forwarder.Hex(), To: d.Hex(),
+			Method: "setForwarderAddress", Args: []interface{}{*d},
+		})
+		if !state.HasForwarder {
+			c.From, c.Blocked = "", "the contract has no CRE forwarder"
+		}
+		changes = append(changes, c)
+	}
+	if d := desired.WorkflowID; d != nil && (!state.HasWorkflow || *d != state.WorkflowID) {
+		c := byOwner(Change{
+			Setting: SettingWorkflowID, From: hexID(state.WorkflowID), To: hexID(*d),
+			Method: "setExpectedWorkflowId", Args: []interface{}{*d},
+		})
+		if !state.HasWorkflow {
+			c.From, c.Blocked = "", "the contract has no CRE workflow id"
+		}
+		changes = append(changes, c)
+	}
+
+	if d := desired.Owner; d != nil && *d != state.Owner {
+		c := Change{Setting: SettingOwner, From: state.Owner.Hex(), To: d.Hex()}
+		if state.HandoverExpiresAt.After(now) {
+			c.Method, c.Args = "completeOwnershipHandover", []interface{}{*d}
+			if signer != state.Owner {
+				c.Blocked = fmt.Sprintf("handover requested until %s, complete it with the owner %s",
+					state.HandoverExpiresAt.UTC().Format(time.RFC3339), state.Owner.Hex())
+			}
+		} else {
+			c.Method = "requestOwnershipHandover"
+			if signer != *d {
+				c.Blocked = fmt.Sprintf("the new owner %s has to request the handover, the admin signer is %s", d.Hex(), signerName(signer))
+			} else {
+				c.Note = fmt.Sprintf("then apply with the owner %s within 48 hours", state.Owner.Hex())
+			}
+		}
+		changes = append(changes, c)
+	}
+	return changes
+}
+
+func signerName(signer common.Address) string {
+	if signer == (common.Address{}) {
+		return "not configured"
+	}
+	return signer.Hex()
+}
+
+func hexID(id [32]byte) string {
+	return "0x" + hex.EncodeToString(id[:])
+}
+
+// Contract reads and sends the admin txs of one oracle contract
+type Contract struct {
+	Config   config.ContractConfig
+	address  common.Address
+	backend  Backend
+	contract *bind.BoundContract
+}
+
+func NewContract(ctrct config.ContractConfig, backend Backend) *Contract {
+	address := common.HexToAddress(ctrct.Address)
+	return &Contract{
+		Config:   ctrct,
+		address:  address,
+		backend:  backend,
+		contract: bind.NewBoundContract(address, oracleAdminABI, backend, backend, backend),
+	}
+}
+
+// Read fetches the settings of the contract, and the handover pending
+// for owner when it is set
+func (c *Contract) Read(ctx context.Context, owner *common.Address) (State, error) {
+	opts := &bind.CallOpts{Context: ctx}
+	var state State
+	var err error
+	if state.Owner, err = c.readAddress(opts, "owner"); err != nil {
+		return state, fmt.Errorf("reading owner: %w", err)
+	}
+	if state.RelayerNode, err = c.readAddress(opts, "relayerNode"); err != nil {
+		return state, fmt.Errorf("reading relayer node: %w", err)
+	}
+	// plain verifiers revert on the CRE settings
+	if state.Forwarder, err = c.readAddress(opts, "getForwarderAddress"); err == nil {
+		state.HasForwarder = true
+	}
+	var out []interface{}
+	if err := c.contract.Call(opts, &out, "getExpectedWorkflowId"); err == nil && len(out) == 1 {
+		state.WorkflowID, state.HasWorkflow = out[0].([32]byte)
+	}
+	if owner != nil && *owner != state.Owner {
+		out = nil
+		if err := c.contract.Call(opts, &out, "ownershipHandoverExpiresAt", *owner); err != nil {
+			return state, fmt.Errorf("reading ownership handover: %w", err)
+		}
+		if expires, ok := out[0].(*big.Int); ok && expires.Sign() > 0 {
+			state.HandoverExpiresAt = time.Unix(expires.Int64(), 0)
+		}
+	}
+	return state, nil
+}
+
+func (c *Contract) readAddress(opts *bind.CallOpts, method string) (common.Address, error) {
+	var out []interface{}
+	if err := c.contract.Call(opts, &out, method); err != nil {
+		return common.Address{}, err
+	}
+	address, ok := out[0].(common.Address)
+	if !ok {
+		return common.Address{}, errors.New("unexpected answer")
+	}
+	return address, nil
+}
+
+// Preview is the tx a change sends
+type Preview struct {
+	To   common.Address
+	Data []byte
+	Gas  uint64
+	// the estimate failed, most likely the call reverts
+	EstimateErr error
+}
+
+// Preview packs a change and estimates its gas as sent from from
+func (c *Contract) Preview(ctx context.Context, from common.Address, change Change) (Preview, error) {
+	data, err := oracleAdminABI.Pack(change.Method, change.Args...)
+	if err != nil {
+		return Preview{}, fmt.Errorf("packing %s: %w", change.Method, err)
+	}
+	preview := Preview{To: c.address, Data: data}
+	preview.Gas, preview.EstimateErr = c.backend.EstimateGas(ctx, ethereum.CallMsg{From: from, To: &c.address, Data: data})
+	return preview, nil
+}
+
+// Apply sends a change and waits for it to be mined
+func (c *Contract) Apply(ctx context.Context, opts *bind.TransactOpts, change Change) (*types.Receipt, error) {
+	if change.Blocked != "" {
+		return nil, fmt.Errorf("%s: %s", change.Setting, change.Blocked)
+	}
+	tx, err := c.contract.Transact(opts, change.Method, change.Args...)
+	if err != nil {
+		return nil, fmt.Errorf("sending %s: %w", change.Method, err)
+	}
+	receipt, err := bind.WaitMined(ctx, c.backend, tx)
+	if err != nil {
+		return nil, fmt.Errorf("waiting for %s: %w", tx.Hash().Hex(), err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return receipt, fmt.Errorf("%s reverted in %s", change.Method, tx.Hash().Hex())
+	}
+	return receipt, nil
+}
@@ -0,0 +1,72 @@
+package admin
+
+import (
+	"testing"
+	"time"
+
+	"oracle_engine/internal/config"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestPlanMovesSettingsBeforeHandingOverOwnership(t *testing.T) {
+	owner := common.HexToAddress("0x01")
+	newOwner := common.HexToAddress("0x02")
+	relayer := common.HexToAddress("0x03")
+	now := time.Unix(1_750_000_000, 0)
+
+	desired, err := DesiredOf(config.ContractAdminConfig{
+		Owner:              newOwner.Hex(),
+		RelayerNode:        relayer.Hex(),
+		ForwarderAddress:   "0x0000000000000000000000000000000000000004",
+		ExpectedWorkflowID: "0x00000000000000000000000000000000000000000000000000000000000000aa",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := State{Owner: owner, HasWorkflow: true}
+
+	// the owner can set what its contract has, not the forwarder of a
+	// plain verifier, and waits for the new owner to request the handover
+	changes := Plan(state, desired, owner, now)
+	if len(changes) != 4 {
+		t.Fatalf("planned %d changes, want 4", len(changes))
+	}
+	blocked := map[string]bool{}
+	for _, c := range changes {
+		blocked[c.Setting] = c.Blocked != ""
+	}
+	if blocked[SettingRelayerNode] || blocked[SettingWorkflowID] || !blocked[SettingForwarder] || !blocked[SettingOwner] {
+		t.Fatalf("blocked %v", blocked)
+	}
+	if last := changes[3]; last.Setting != SettingOwner || last.Method != "requestOwnershipHandover" {
+		t.Fatalf("last change %+v, want the handover", last)
+	}
+
+	// the new owner requests it, the owner then completes it
+	if c := Plan(state, desired, newOwner, now)[3]; c.Blocked != "" || c.Note == "" {
+		t.Fatalf("new owner request %+v", c)
+	}
+	state.HandoverExpiresAt = now.Add(time.Hour)
+	if c := Plan(state, desired, owner, now)[3]; c.Method != "completeOwnershipHandover" || c.Blocked != "" || c.Args[0] != newOwner {
+		t.Fatalf("owner completion %+v", c)
+	}
+
+	// in line, nothing to do
+	in := State{Owner: newOwner, RelayerNode: relayer, HasForwarder: true, Forwarder: *desired.Forwarder, HasWorkflow: true, WorkflowID: *desired.WorkflowID}
+	if changes := Plan(in, desired, newOwner, now); len(changes) != 0 {
+		t.Fatalf("planned %d changes for a contract in line", len(changes))
+	}
+}
+
+func TestDesiredOfRejectsMalformedSettings(t *testing.T) {
+	if _, err := DesiredOf(config.ContractAdminConfig{RelayerNode: "0x12"}); err == nil {
+		t.Fatal("took a short address")
+	}
+	if _, err := DesiredOf(config.ContractAdminConfig{ExpectedWorkflowID: "0xaa"}); err == nil {
+		t.Fatal("took a short workflow id")
+	}
+	if d, err := DesiredOf(config.ContractAdminConfig{}); err != nil || d.Owner != nil || d.WorkflowID != nil {
+		t.Fatalf("empty block %+v %v", d, err)
+	}
+}
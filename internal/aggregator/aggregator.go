@@ -2,10 +2,13 @@ package aggregator
 
 import (
 	"context"
+	"sync"
+	"time"
 
 	"oracle_engine/internal/config"
 	"oracle_engine/internal/logging"
 	"oracle_engine/internal/models"
+	"oracle_engine/internal/registry"
 	"oracle_engine/internal/utils"
 
 	"go.uber.org/zap"
@@ -16,25 +19,61 @@ Aggegator:
 Aggr unit based on each asset on the system.
 Each unit can be changed dynamically to spin up multiple threads.
 Each unit should handle whatever is thrown at it
+Units for configured assets live as long as the aggregator,
+units for assets first seen at runtime are created on demand
+and torn down once they go idle.
 */
 
 type AggrUnitCh chan models.UnifiedPrice
 
+// SourceWeigher scores sources for weighting inside an aggregation window
+// and learns from every window that got aggregated
+type SourceWeigher interface {
+	Weight(assetID, source string) float64
+	Observe(assetID string, aggregate float64, at time.Time, window []models.UnifiedPrice)
+}
+
+// a saturated unit is warned about on its first dropped price and then
+// every this many
+const dropWarnEvery = 100
+
 type Aggregator struct {
 	InitialAggregatorUnitCount uint8
-	AggegatorChannelsMap       map[string]AggrUnitCh
+	MaxAggregatorUnitCount     uint8
 	AggrOutCh                  AggrUnitCh
+	weigher                    SourceWeigher
+	cfg                        *config.Config
+	registry                   *registry.Registry
+	ctx                        context.Context
+	units                      map[string]*AggregatorUnit
+	removed                    map[string]struct{} // assets a reload removed, their prices are dropped
+	mu                         sync.RWMutex
 }
 
-func New(ctx context.Context, cfg *config.Config) *Aggregator {
+// New creates the aggregator, weigher may be nil for equally weighted sources
+func New(ctx context.Context, cfg *config.Config, registry *registry.Registry, weigher SourceWeigher) *Aggregator {
+	initial := cfg.Aggregator.InitialWorkers
+	if initial <= 0 {
+		initial = 1
+	}
+	max := cfg.AggregatorNodes
+	if max < initial {
+		max = initial
+	}
+
 	aggr := &Aggregator{
-		// Use env vars later
-		InitialAggregatorUnitCount: 1,
+		InitialAggregatorUnitCount: uint8(initial),
+		MaxAggregatorUnitCount:     uint8(max),
 		AggrOutCh:                  make(AggrUnitCh, 20), // 20 at time
-		AggegatorChannelsMap:       map[string]AggrUnitCh{},
+		weigher:                    weigher,
+		cfg:                        cfg,
+		registry:                   registry,
+		ctx:                        ctx,
+		units:                      map[string]*AggregatorUnit{},
+		removed:                    map[string]struct{}{},
 	}
 
-	go aggr.Start(ctx, cfg)
+	go aggr.Start(ctx)
 	return aggr
 }
 
@@ -42,30 +81,156 @@ func (ag *Aggregator) OutCh() *AggrUnitCh {
 	return &ag.AggrOutCh
 }
 
-func (ag *Aggregator) Start(ctx context.Context, cfg *config.Config) {
-	// spin up units based on the assets available
-	for _, asset := range cfg.Assets {
-		// TODO: calculate asset ID using identity string to hash
-		assetID := utils.GenerateIDForAsset(asset.InternalAssetIdentity)
-		logging.Logger.Debug("asset aggr", zap.String("key", assetID))
-
-		// create channel for the unit
-		aggrUnitCh := make(chan models.UnifiedPrice, 10)
-		ag.AggegatorChannelsMap[assetID] = aggrUnitCh
-
-		assetAggregatorUnit := NewAggregatorUnit(
-			aggrUnitCh,
-			&ag.AggrOutCh,
-			cfg.AggrDevPerc,
-			ag.InitialAggregatorUnitCount,
-			assetID,
-		)
-		go assetAggregatorUnit.RunAggregatorThreadUnit(ctx)
+func (ag *Aggregator) Start(ctx context.Context) {
+	// spin up units based on the assets available, and follow reloads
+	ag.registry.Subscribe(ag.applyChange)
+	for _, asset := range ag.registry.Assets() {
+		ag.AddAsset(asset)
 	}
+
+	ag.reapIdleUnits(ctx)
 }
 
-func (ag *Aggregator) Run(ctx context.Context, priceChan AggrUnitCh) {
+// AddAsset spins up a long lived unit for an asset, or refreshes
+// the settings of the unit when it already runs
+func (ag *Aggregator) AddAsset(asset config.AssetConfig) {
+	assetID := utils.GenerateIDForAsset(asset.InternalAssetIdentity)
+	logging.Logger.Debug("asset aggr", zap.String("key", assetID))
+
+	ag.mu.Lock()
+	defer ag.mu.Unlock()
+
+	delete(ag.removed, assetID)
+	if unit, ok := ag.units[assetID]; ok {
+		unit.SetSetting(asset.Settings.WithDefaults())
+		unit.pinned = true
+		return
+	}
+	ag.units[assetID] = ag.spawnUnit(assetID, asset.Settings.WithDefaults(), true)
+}
+
+// applyChange brings the units in line with a reloaded registry
+func (ag *Aggregator) applyChange(change registry.Change) {
+	for _, asset := range append(change.Added, change.Updated...) {
+		ag.AddAsset(asset)
+	}
+	for _, assetID := range change.Removed {
+		ag.RemoveAsset(assetID)
+	}
+}
+
+// RemoveAsset tears down the unit of an asset, prices still coming in for
+// it are dropped instead of spinning up an unknown asset unit
+func (ag *Aggregator) RemoveAsset(assetID string) {
+	ag.mu.Lock()
+	unit, ok := ag.units[assetID]
+	delete(ag.units, assetID)
+	ag.removed[assetID] = struct{}{}
+	ag.mu.Unlock()
+
+	if ok {
+		unit.Stop()
+		logging.Logger.Info("Aggregator unit removed", zap.String("asset", assetID))
+	}
+}
+
+// UnitCount returns the number of running units
+func (ag *Aggregator) UnitCount() int {
+	ag.mu.RLock()
+	defer ag.mu.RUnlock()
+	return len(ag.units)
+}
+
+// spawnUnit must be called with the lock held
+func (ag *Aggregator) spawnUnit(assetID string, setting config.AssetSetting, pinned bool) *AggregatorUnit {
+	buffer := ag.cfg.Aggregator.UnitBuffer
+	if buffer <= 0 {
+		buffer = BUFFER_MAX_SIZE
+	}
+
+	unit := NewAggregatorUnit(
+		make(AggrUnitCh, buffer),
+		&ag.AggrOutCh,
+		ag.cfg.AggrDevPerc,
+		ag.InitialAggregatorUnitCount,
+		ag.MaxAggregatorUnitCount,
+		assetID,
+		setting,
+		ag.weigher,
+	)
+	unit.pinned = pinned
+	go unit.RunAggregatorThreadUnit(ag.ctx)
+	return unit
+}
+
+// unitFor returns the unit of an asset, creating one on demand
+// for assets missing from the config while under the cap. Removed
+// assets get none.
+func (ag *Aggregator) unitFor(assetID string) (unit *AggregatorUnit, removed bool) {
+	ag.mu.RLock()
+	unit, ok := ag.units[assetID]
+	ag.mu.RUnlock()
+	if ok {
+		return unit, false
+	}
 
+	ag.mu.Lock()
+	defer ag.mu.Unlock()
+	if unit, ok := ag.units[assetID]; ok {
+		return unit, false
+	}
+	if _, ok := ag.removed[assetID]; ok {
+		return nil, true
+	}
+
+	unknown := 0
+	for _, u := range ag.units {
+		if !u.pinned {
+			unknown++
+		}
+	}
+	maxUnknown := ag.cfg.Aggregator.MaxUnknownUnits
+	if unknown >= maxUnknown {
+		return nil, false
+	}
+
+	logging.Logger.Warn("Spinning up aggregator unit for unknown asset", zap.String("asset", assetID))
+	unit = ag.spawnUnit(assetID, config.DefaultAssetSetting, false)
+	ag.units[assetID] = unit
+	return unit, false
+}
+
+// reapIdleUnits tears down on demand units that stopped receiving prices
+func (ag *Aggregator) reapIdleUnits(ctx context.Context) {
+	idleTimeout := time.Duration(ag.cfg.Aggregator.IdleUnitTimeoutSeconds) * time.Second
+	if idleTimeout <= 0 {
+		idleTimeout = 15 * time.Minute
+	}
+	ticker := time.NewTicker(idleTimeout / 4)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			ag.mu.RLock()
+			idle := make([]string, 0)
+			for assetID, unit := range ag.units {
+				if !unit.pinned && unit.IdleFor() > idleTimeout {
+					idle = append(idle, assetID)
+				}
+			}
+			ag.mu.RUnlock()
+
+			for _, assetID := range idle {
+				ag.RemoveAsset(assetID)
+			}
+		}
+	}
+}
+
+func (ag *Aggregator) Run(ctx context.Context, priceChan AggrUnitCh) {
 	for {
 		select {
 		case <-ctx.Done():
@@ -73,8 +238,36 @@ func (ag *Aggregator) Run(ctx context.Context, priceChan AggrUnitCh) {
 		case price := <-priceChan:
 			// retrieve the id of the price
 			assetID := price.AssetID
-			// throw to the channel for that id
-			ag.AggegatorChannelsMap[assetID] <- price
+			if assetID == "" {
+				logging.Logger.Warn("Dropping price without asset id", zap.String("source", price.Source))
+				continue
+			}
+			unit, removed := ag.unitFor(assetID)
+			if removed {
+				logging.Logger.Debug("Dropping price for a removed asset", zap.String("asset", assetID))
+				continue
+			}
+			if unit == nil {
+				logging.Logger.Warn("Dropping price for unknown asset, unit cap reached", zap.String("asset", assetID))
+				continue
+			}
+
+			// throw to the channel for that id, never block the
+			// dispatcher on one slow unit
+			accepted, stopped := unit.Offer(price)
+			switch {
+			case accepted:
+			case stopped:
+				logging.Logger.Debug("Dropping price for a removed aggregator unit", zap.String("asset", assetID))
+			default:
+				if dropped := unit.dropped.Load(); dropped%dropWarnEvery == 1 {
+					logging.Logger.Warn("Aggregator unit saturated, dropping price",
+						zap.String("asset", assetID),
+						zap.String("source", price.Source),
+						zap.Int64("dropped", dropped),
+					)
+				}
+			}
 		}
 	}
 }
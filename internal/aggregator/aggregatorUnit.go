@@ -2,10 +2,11 @@ package aggregator
 
 import (
 	"context"
-	"math"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"oracle_engine/internal/config"
 	"oracle_engine/internal/logging"
 	"oracle_engine/internal/models"
 	"oracle_engine/internal/utils"
@@ -16,13 +17,27 @@ import (
 
 const BUFFER_MAX_SIZE = 10
 
+// how long a worker above the initial count waits for a batch before exiting
+const workerIdleTimeout = 30 * time.Second
+
 type AggregatorUnit struct {
-	ActiveThreads uint8
-	AggrDevPerc   float32
-	AssetID       string
-	ch            AggrUnitCh
-	outCh         *AggrUnitCh
-	wg            sync.WaitGroup
+	activeThreads  int32
+	InitialThreads uint8
+	MaxThreads     uint8
+	AggrDevPerc    float32
+	AssetID        string
+	setting        config.AssetSetting
+	weigher        SourceWeigher
+	ch             AggrUnitCh
+	batchCh        chan []models.UnifiedPrice
+	outCh          *AggrUnitCh
+	pinned         bool
+	lastSeen       atomic.Int64
+	dropped        atomic.Int64 // prices turned away while saturated
+	settingMu      sync.RWMutex
+	done           chan struct{}
+	stopOnce       sync.Once
+	wg             sync.WaitGroup
 }
 
 func NewAggregatorUnit(
@@ -30,74 +45,234 @@ func NewAggregatorUnit(
 	outCh *AggrUnitCh,
 	aggrDevPerc float32,
 	initialThreadCount uint8,
+	maxThreadCount uint8,
 	assetID string,
+	setting config.AssetSetting,
+	weigher SourceWeigher,
 ) *AggregatorUnit {
-	return &AggregatorUnit{
-		ch:            ch,
-		ActiveThreads: initialThreadCount,
-		AssetID:       assetID,
-		outCh:         outCh,
-		AggrDevPerc:   aggrDevPerc,
+	if initialThreadCount == 0 {
+		initialThreadCount = 1
+	}
+	if maxThreadCount < initialThreadCount {
+		maxThreadCount = initialThreadCount
+	}
+	au := &AggregatorUnit{
+		ch:             ch,
+		batchCh:        make(chan []models.UnifiedPrice, maxThreadCount),
+		InitialThreads: initialThreadCount,
+		MaxThreads:     maxThreadCount,
+		AssetID:        assetID,
+		setting:        setting,
+		weigher:        weigher,
+		outCh:          outCh,
+		AggrDevPerc:    aggrDevPerc,
+		done:           make(chan struct{}),
+	}
+	au.lastSeen.Store(time.Now().UnixNano())
+	return au
+}
+
+// ActiveThreads returns the number of compute workers currently running
+func (au *AggregatorUnit) ActiveThreads() int {
+	return int(atomic.LoadInt32(&au.activeThreads))
+}
+
+// Setting returns the asset settings the unit aggregates with
+func (au *AggregatorUnit) Setting() config.AssetSetting {
+	au.settingMu.RLock()
+	defer au.settingMu.RUnlock()
+	return au.setting
+}
+
+// SetSetting swaps the asset settings, picked up by the next batch
+func (au *AggregatorUnit) SetSetting(setting config.AssetSetting) {
+	au.settingMu.Lock()
+	defer au.settingMu.Unlock()
+	au.setting = setting
+}
+
+// IdleFor is the time since the unit last received a price
+func (au *AggregatorUnit) IdleFor() time.Duration {
+	return time.Since(time.Unix(0, au.lastSeen.Load()))
+}
+
+// Stop tears the unit down, pending batches are still computed
+func (au *AggregatorUnit) Stop() {
+	au.stopOnce.Do(func() { close(au.done) })
+}
+
+// Offer hands a price to the unit without blocking, a price that is not
+// accepted was dropped because the unit is saturated or, when stopped,
+// torn down
+func (au *AggregatorUnit) Offer(price models.UnifiedPrice) (accepted, stopped bool) {
+	select {
+	case <-au.done:
+		return false, true
+	default:
+	}
+	select {
+	case au.ch <- price:
+		return true, false
+	case <-au.done:
+		return false, true
+	default:
+		au.dropped.Add(1)
+		return false, false
 	}
 }
 
 func (au *AggregatorUnit) RunAggregatorThreadUnit(ctx context.Context) {
-	priceBuf := make([]models.UnifiedPrice, 10)
-	// lock threads
+	for i := uint8(0); i < au.InitialThreads; i++ {
+		au.spawnWorker(true)
+	}
+
+	priceBuf := make([]models.UnifiedPrice, 0, BUFFER_MAX_SIZE)
 	for {
 		select {
 		case <-ctx.Done():
-			au.wg.Wait() // case killed, at least throw
+			au.drain()
+			return
+		case <-au.done:
+			au.drain()
 			return
 		case price := <-au.ch:
-			logging.Logger.Warn("---yy-- ret", zap.Any("k", au.AssetID))
+			au.lastSeen.Store(time.Now().UnixNano())
 			priceBuf = append(priceBuf, price)
-			if len(priceBuf) >= BUFFER_MAX_SIZE {
-				// Batch up and price out
-				au.wg.Add(1)
-
-				copiedPrices := make([]models.UnifiedPrice, 10)
-				copy(copiedPrices, priceBuf)
-				go func(cp []models.UnifiedPrice) {
-					defer au.wg.Done()
-
-					threadUnitCalculateBatchAverage(
-						cp, au.outCh, au.AggrDevPerc,
-					)
-				}(copiedPrices)
-				// reset price buf
-				priceBuf = []models.UnifiedPrice{}
+			if len(priceBuf) < BUFFER_MAX_SIZE {
+				continue
+			}
+
+			// Batch up and price out
+			copiedPrices := make([]models.UnifiedPrice, len(priceBuf))
+			copy(copiedPrices, priceBuf)
+			select {
+			case au.batchCh <- copiedPrices:
+			default:
+				// workers are backlogged
+				au.scaleUp()
+				select {
+				case au.batchCh <- copiedPrices:
+				case <-ctx.Done():
+				case <-au.done:
+				}
 			}
+			// reset price buf
+			priceBuf = priceBuf[:0]
 		}
 	}
 }
 
+func (au *AggregatorUnit) drain() {
+	close(au.batchCh)
+	au.wg.Wait() // case killed, at least throw
+}
+
+// scaleUp adds a compute worker when under the max thread count,
+// only called from the collector so it never races with drain
+func (au *AggregatorUnit) scaleUp() {
+	au.spawnWorker(false)
+}
+
+func (au *AggregatorUnit) spawnWorker(permanent bool) {
+	for {
+		active := atomic.LoadInt32(&au.activeThreads)
+		if !permanent && active >= int32(au.MaxThreads) {
+			return
+		}
+		if atomic.CompareAndSwapInt32(&au.activeThreads, active, active+1) {
+			break
+		}
+	}
+	if !permanent {
+		logging.Logger.Debug("Scaling up aggregator unit",
+			zap.String("asset", au.AssetID),
+			zap.Int("threads", au.ActiveThreads()),
+		)
+	}
+
+	au.wg.Add(1)
+	go func() {
+		defer au.wg.Done()
+		defer atomic.AddInt32(&au.activeThreads, -1)
+
+		idle := time.NewTimer(workerIdleTimeout)
+		defer idle.Stop()
+		for {
+			select {
+			case batch, ok := <-au.batchCh:
+				if !ok {
+					return
+				}
+				threadUnitCalculateBatchAverage(
+					batch, au.outCh, au.AggrDevPerc, au.Setting(), au.weigher,
+				)
+				if !idle.Stop() {
+					select {
+					case <-idle.C:
+					default:
+					}
+				}
+				idle.Reset(workerIdleTimeout)
+			case <-idle.C:
+				// scale back down to the initial count
+				if permanent {
+					idle.Reset(workerIdleTimeout)
+					continue
+				}
+				return
+			}
+		}
+	}()
+}
+
 func threadUnitCalculateBatchAverage(
 	batch []models.UnifiedPrice,
 	outgoingCh *AggrUnitCh,
 	aggr_dev_perc float32,
+	setting config.AssetSetting,
+	weigher SourceWeigher,
 ) {
 	firstPrice := batch[0]
-	avg := (firstPrice.Value + batch[len(batch)-1].Value) / 2
+	now := time.Now()
+	included, exclusions := classifyBatch(batch, now, aggr_dev_perc, setting)
 
 	sum := 0.0
-	connectedPriceIDs := make([]string, 0)
-	for _, p := range batch {
-		// TODO: check here for empty ids
-		if p.ID == "" {
-			continue
-		}
-		pn := p.Value
-		devPerc := math.Abs(pn-avg) / avg
-		if devPerc > float64(aggr_dev_perc) {
-			continue
-		}
-		sum += pn
+	weightSum := 0.0
+	weights := make([]float64, 0, len(included))
+	connectedPriceIDs := make([]string, 0, len(included))
+	for _, p := range included {
+		weight := sourceWeight(weigher, p)
+		sum += p.Value * weight
+		weightSum += weight
+		weights = append(weights, weight)
 		connectedPriceIDs = append(connectedPriceIDs, p.ID)
 	}
-	avg = sum / float64(len(batch))
+	if len(included) == 0 || weightSum == 0 {
+		logging.Logger.Warn("No usable prices in batch",
+			zap.String("asset", firstPrice.AssetID),
+			zap.Int("excluded", len(exclusions)),
+		)
+		return
+	}
+	avg := sum / weightSum
+	if weigher != nil {
+		weigher.Observe(firstPrice.AssetID, avg, now, batch)
+	}
+
+	quorum := evaluateQuorum(included, setting)
+	if !quorum.Met {
+		logging.Logger.Warn("Aggregate missed source quorum",
+			zap.String("asset", firstPrice.AssetID),
+			zap.Int("distinctSources", quorum.DistinctSources),
+			zap.Int("minSources", quorum.MinSources),
+			zap.Strings("missing", quorum.MissingSources),
+			zap.String("action", setting.QuorumAction),
+		)
+		// a withheld aggregate only counts towards the quorum breaker
+		quorum.Withheld = withholdOnMissedQuorum(setting)
+		quorum.Degraded = !quorum.Withheld
+	}
 
-	logging.Logger.Warn("---compute babe-- ret", zap.Any("k", avg))
 	// some other calc
 	// source aggr and hash gen
 	// ------ Sys aggregated price
@@ -106,12 +281,22 @@ func threadUnitCalculateBatchAverage(
 		Value:             avg,
 		AssetID:           firstPrice.AssetID,
 		Expo:              firstPrice.Expo, // still -18
-		Timestamp:         time.Now(),
+		Timestamp:         now,
 		Source:            "ifa_labs",
 		ReqHash:           utils.HashWithSource("ifa_labs"),
 		IsAggr:            true,
 		ConnectedPriceIDs: connectedPriceIDs,
+		Quorum:            &quorum,
+		Provenance:        buildProvenance(batch, included, weights, exclusions, quorum),
 	}
 
 	*outgoingCh <- avgPrice
 }
+
+// sourceWeight is the weight of a price inside its window, 1 without a weigher
+func sourceWeight(weigher SourceWeigher, p models.UnifiedPrice) float64 {
+	if weigher == nil {
+		return 1
+	}
+	return weigher.Weight(p.AssetID, p.Source)
+}
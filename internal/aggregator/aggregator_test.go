@@ -0,0 +1,58 @@
+package aggregator
+
+import (
+	"context"
+	"testing"
+
+	"oracle_engine/internal/config"
+	"oracle_engine/internal/logging"
+	"oracle_engine/internal/models"
+	"oracle_engine/internal/utils"
+
+	"go.uber.org/zap"
+)
+
+func TestOfferNeverBlocksOnAFullOrStoppedUnit(t *testing.T) {
+	unit := NewAggregatorUnit(make(AggrUnitCh, 1), nil, 0, 1, 1, "usdt", config.DefaultAssetSetting, nil)
+
+	if accepted, stopped := unit.Offer(models.UnifiedPrice{}); !accepted || stopped {
+		t.Fatal("a unit with room refused a price")
+	}
+	if accepted, stopped := unit.Offer(models.UnifiedPrice{}); accepted || stopped || unit.dropped.Load() != 1 {
+		t.Fatal("a saturated unit took a price or did not count the drop")
+	}
+
+	<-unit.ch
+	unit.Stop()
+	if accepted, stopped := unit.Offer(models.UnifiedPrice{}); accepted || !stopped {
+		t.Fatal("a stopped unit took a price")
+	}
+}
+
+func TestRemovedAssetsAreNotRespawnedAsUnknown(t *testing.T) {
+	logging.Logger = zap.NewNop()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ag := &Aggregator{
+		InitialAggregatorUnitCount: 1,
+		MaxAggregatorUnitCount:     1,
+		cfg:                        &config.Config{Aggregator: config.AggregatorConfig{MaxUnknownUnits: 5}},
+		ctx:                        ctx,
+		units:                      map[string]*AggregatorUnit{},
+		removed:                    map[string]struct{}{},
+	}
+	asset := config.AssetConfig{Name: "USDT/USD", InternalAssetIdentity: "0xUSDT"}
+	assetID := utils.GenerateIDForAsset(asset.InternalAssetIdentity)
+
+	ag.AddAsset(asset)
+	ag.RemoveAsset(assetID)
+	if unit, removed := ag.unitFor(assetID); unit != nil || !removed {
+		t.Fatal("a late price spun the removed asset up again")
+	}
+
+	// added back, it runs with its own settings again
+	ag.AddAsset(asset)
+	if unit, removed := ag.unitFor(assetID); unit == nil || removed || !unit.pinned {
+		t.Fatal("a re-added asset has no pinned unit")
+	}
+}
@@ -0,0 +1,128 @@
+package aggregator
+
+import (
+	"math"
+	"time"
+
+	"oracle_engine/internal/config"
+	"oracle_engine/internal/models"
+	"oracle_engine/internal/pricepool/outlier"
+)
+
+// classifyBatch splits a window into the prices that count and the ones
+// that get dropped, checked in order stale, outlier, deviation so every
+// dropped price carries the first reason it failed
+func classifyBatch(
+	batch []models.UnifiedPrice,
+	now time.Time,
+	aggrDevPerc float32,
+	setting config.AssetSetting,
+) ([]models.UnifiedPrice, []models.PriceExclusion) {
+	maxAge := time.Duration(setting.MaxPriceAge) * time.Second
+
+	fresh := make([]models.UnifiedPrice, 0, len(batch))
+	exclusions := make([]models.PriceExclusion, 0)
+	for _, p := range batch {
+		// TODO: check here for empty ids
+		if p.ID == "" {
+			continue
+		}
+		if maxAge > 0 && now.Sub(p.Timestamp) > maxAge {
+			exclusions = append(exclusions, exclusion(p, models.ExclusionStale))
+			continue
+		}
+		fresh = append(fresh, p)
+	}
+	if len(fresh) == 0 {
+		return fresh, exclusions
+	}
+
+	median := 0.0
+	if len(fresh) >= outlier.MinSamples {
+		values := make([]float64, len(fresh))
+		for i, p := range fresh {
+			values[i] = p.Value
+		}
+		median = outlier.Median(values)
+	}
+
+	// reference of the window, mean of its first and last price
+	reference := (fresh[0].Value + fresh[len(fresh)-1].Value) / 2
+
+	included := make([]models.UnifiedPrice, 0, len(fresh))
+	for _, p := range fresh {
+		if median != 0 && outlier.IsOutlier(p.Value, median) {
+			exclusions = append(exclusions, exclusion(p, models.ExclusionOutlier))
+			continue
+		}
+		if math.Abs(p.Value-reference)/reference > float64(aggrDevPerc) {
+			exclusions = append(exclusions, exclusion(p, models.ExclusionDeviation))
+			continue
+		}
+		included = append(included, p)
+	}
+	return included, exclusions
+}
+
+func exclusion(p models.UnifiedPrice, reason string) models.PriceExclusion {
+	return models.PriceExclusion{
+		PriceID: p.ID,
+		Source:  p.Source,
+		Value:   p.Value,
+		Reason:  reason,
+	}
+}
+
+// buildProvenance summarises a window, weights are the raw source
+// weights of the included prices and get normalised to shares
+func buildProvenance(
+	batch []models.UnifiedPrice,
+	included []models.UnifiedPrice,
+	weights []float64,
+	exclusions []models.PriceExclusion,
+	quorum models.QuorumStatus,
+) *models.AggregationProvenance {
+	weightSum := 0.0
+	for _, w := range weights {
+		weightSum += w
+	}
+
+	prov := &models.AggregationProvenance{
+		SampleCount:     len(batch),
+		DistinctSources: quorum.DistinctSources,
+		Contributions:   make([]models.SourceContribution, 0, len(included)),
+		Exclusions:      exclusions,
+	}
+
+	sum := 0.0
+	for i, p := range included {
+		share := 0.0
+		if weightSum > 0 {
+			share = weights[i] / weightSum
+		}
+		prov.Contributions = append(prov.Contributions, models.SourceContribution{
+			PriceID: p.ID,
+			Source:  p.Source,
+			Value:   p.Value,
+			Weight:  share,
+		})
+
+		sum += p.Value
+		if i == 0 || p.Value < prov.Min {
+			prov.Min = p.Value
+		}
+		if i == 0 || p.Value > prov.Max {
+			prov.Max = p.Value
+		}
+	}
+
+	if len(included) > 0 {
+		mean := sum / float64(len(included))
+		variance := 0.0
+		for _, p := range included {
+			variance += (p.Value - mean) * (p.Value - mean)
+		}
+		prov.StdDev = math.Sqrt(variance / float64(len(included)))
+	}
+	return prov
+}
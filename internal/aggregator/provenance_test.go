@@ -0,0 +1,41 @@
+package aggregator
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"oracle_engine/internal/config"
+	"oracle_engine/internal/datastream/currencylayer"
+	"oracle_engine/internal/models"
+)
+
+// a currencylayer /convert answer, the timestamp is its last hourly update
+const currencyLayerBRL = `{"success":true,"terms":"https://currencylayer.com/terms","privacy":"https://currencylayer.com/privacy",
+"query":{"from":"BRL","to":"USD","amount":1},"info":{"timestamp":1750003204,"quote":0.180425},"result":0.180425}`
+
+func TestProviderTimestampsAreNotStaleByDefault(t *testing.T) {
+	var res currencylayer.CurrencyLayerResponse
+	if err := json.Unmarshal([]byte(currencyLayerBRL), &res); err != nil {
+		t.Fatal(err)
+	}
+	stamped := time.Unix(res.Info.Timestamp, 0)
+	// fetched 50 minutes into the hour, next to a fresh pyth price
+	now := stamped.Add(50 * time.Minute)
+	batch := []models.UnifiedPrice{
+		{ID: "1", Source: "currencylayer", Value: res.Result, Timestamp: stamped},
+		{ID: "2", Source: "pyth", Value: 0.1805, Timestamp: now},
+	}
+
+	included, exclusions := classifyBatch(batch, now, 0.04, config.AssetSetting{}.WithDefaults())
+	if len(included) != 2 || len(exclusions) != 0 {
+		t.Fatalf("default settings kept %d prices and excluded %v", len(included), exclusions)
+	}
+
+	// an asset opting into an age limit drops it
+	setting := config.AssetSetting{MaxPriceAge: 300}.WithDefaults()
+	included, exclusions = classifyBatch(batch, now, 0.04, setting)
+	if len(included) != 1 || len(exclusions) != 1 || exclusions[0].Reason != models.ExclusionStale {
+		t.Fatalf("max_price_age 300 kept %d prices and excluded %v", len(included), exclusions)
+	}
+}
@@ -0,0 +1,53 @@
+package aggregator
+
+import (
+	"sort"
+	"strings"
+
+	"oracle_engine/internal/config"
+	"oracle_engine/internal/models"
+)
+
+// evaluateQuorum checks the prices that made it into an aggregate
+// against the asset's source quorum settings.
+func evaluateQuorum(included []models.UnifiedPrice, setting config.AssetSetting) models.QuorumStatus {
+	seen := make(map[string]struct{})
+	sources := make([]string, 0, len(included))
+	for _, p := range included {
+		source := strings.ToLower(p.Source)
+		if _, ok := seen[source]; ok || source == "" {
+			continue
+		}
+		seen[source] = struct{}{}
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	minSources := setting.MinSources
+	if minSources <= 0 {
+		minSources = 1
+	}
+
+	missing := make([]string, 0)
+	for _, required := range setting.RequiredSources {
+		if _, ok := seen[strings.ToLower(required)]; !ok {
+			missing = append(missing, required)
+		}
+	}
+
+	met := len(sources) >= minSources && len(missing) == 0
+	return models.QuorumStatus{
+		Met:             met,
+		Degraded:        !met,
+		DistinctSources: len(sources),
+		MinSources:      minSources,
+		Sources:         sources,
+		MissingSources:  missing,
+	}
+}
+
+// withholdOnMissedQuorum reports whether an aggregate that missed
+// quorum should be withheld from issuance instead of published as degraded
+func withholdOnMissedQuorum(setting config.AssetSetting) bool {
+	return strings.EqualFold(setting.QuorumAction, config.QuorumActionWithhold)
+}
@@ -0,0 +1,39 @@
+package aggregator
+
+import (
+	"testing"
+	"time"
+
+	"oracle_engine/internal/config"
+	"oracle_engine/internal/logging"
+	"oracle_engine/internal/models"
+
+	"go.uber.org/zap"
+)
+
+func TestMissedQuorumStillReachesConsensusFlagged(t *testing.T) {
+	logging.Logger = zap.NewNop()
+	batch := []models.UnifiedPrice{{ID: "1", AssetID: "usdt", Source: "pyth", Value: 1, Timestamp: time.Now()}}
+
+	for _, c := range []struct {
+		action             string
+		degraded, withheld bool
+	}{
+		{config.QuorumActionDegrade, true, false},
+		{config.QuorumActionWithhold, false, true},
+	} {
+		out := make(AggrUnitCh, 1)
+		setting := config.AssetSetting{MinSources: 2, QuorumAction: c.action}.WithDefaults()
+		threadUnitCalculateBatchAverage(batch, &out, 0.04, setting, nil)
+
+		select {
+		case price := <-out:
+			if price.Quorum.Met || price.Quorum.Degraded != c.degraded || price.Quorum.Withheld != c.withheld {
+				t.Fatalf("%s: quorum %+v", c.action, *price.Quorum)
+			}
+		default:
+			// the quorum breaker has to see it either way
+			t.Fatalf("%s: aggregate never left the unit", c.action)
+		}
+	}
+}
@@ -0,0 +1,319 @@
+package balances
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"oracle_engine/internal/config"
+	"oracle_engine/internal/logging"
+	"oracle_engine/internal/models"
+
+	"github.com/ethereum/go-ethereum/common"
+	"go.uber.org/zap"
+)
+
+/*
+Balances:
+Reads the native balance of every relayer wallet on every chain the
+engine publishes to, through the chain's BalanceReader in one call when
+one is configured and eth_getBalance per wallet otherwise. What a wallet
+spends is what its balance dropped by within the runway window, top ups
+do not count against it, and the runway is the balance over that spend.
+A wallet below the chain's min balance or running dry within the min
+runway is low. Every status change is logged and posted to the alert
+webhook. The health api reports a runway summary per chain, the
+latest check of every wallet is served to admins.
+*/
+
+// Wallets lists the relayer wallets of a chain
+type Wallets func(chainID string) ([]common.Address, error)
+
+// Reader reads the native balances of wallets on a chain
+type Reader interface {
+	NativeBalances(ctx context.Context, chainID string, addresses []common.Address) ([]*big.Int, error)
+}
+
+type Monitor struct {
+	cfg     config.BalanceMonitorConfig
+	chains  []string
+	wallets Wallets
+	reader  Reader
+	http    *http.Client
+	now     func() time.Time
+
+	mu      sync.RWMutex
+	results map[string]models.WalletBalance
+	samples map[string][]sample // balances within the runway window, oldest first
+}
+
+type sample struct {
+	at  time.Time
+	wei *big.Int
+}
+
+func New(cfg *config.Config, wallets Wallets, reader Reader) *Monitor {
+	bc := cfg.BalanceMonitor
+	if bc.IntervalSeconds <= 0 {
+		bc.IntervalSeconds = 300
+	}
+	if bc.RunwayWindowSeconds <= 0 {
+		bc.RunwayWindowSeconds = 6 * 3600
+	}
+
+	var chains []string
+	seen := make(map[string]struct{})
+	for _, ctrct := range cfg.Contracts {
+		if _, ok := seen[ctrct.ChainID]; ok {
+			continue
+		}
+		seen[ctrct.ChainID] = struct{}{}
+		chains = append(chains, ctrct.ChainID)
+	}
+
+	return &Monitor{
+		cfg:     bc,
+		chains:  chains,
+		wallets: wallets,
+		reader:  reader,
+		http:    &http.Client{Timeout: 5 * time.Second},
+		now:     time.Now,
+		results: make(map[string]models.WalletBalance),
+		samples: make(map[string][]sample),
+	}
+}
+
+// Start checks every wallet each interval until ctx is done
+func (m *Monitor) Start(ctx context.Context) {
+	if !m.cfg.Enabled {
+		return
+	}
+	ticker := time.NewTicker(time.Duration(m.cfg.IntervalSeconds) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		m.Check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check reads every relayer wallet on every chain once
+func (m *Monitor) Check(ctx context.Context) {
+	for _, chainID := range m.chains {
+		addresses, err := m.wallets(chainID)
+		if err != nil {
+			logging.Logger.Warn("No relayer wallets to watch", zap.String("chainID", chainID), zap.Error(err))
+			continue
+		}
+		if len(addresses) == 0 {
+			continue
+		}
+		balances, err := m.reader.NativeBalances(ctx, chainID, addresses)
+		if err == nil && len(balances) != len(addresses) {
+			err = fmt.Errorf("read %d balances for %d wallets", len(balances), len(addresses))
+		}
+		for i, address := range addresses {
+			if err != nil {
+				m.record(ctx, models.WalletBalance{
+					ChainID:   chainID,
+					Address:   address.Hex(),
+					Status:    models.WalletError,
+					Detail:    err.Error(),
+					CheckedAt: m.now(),
+				})
+				continue
+			}
+			m.record(ctx, m.check(chainID, address, balances[i]))
+		}
+	}
+}
+
+// check judges one balance against the chain's thresholds
+func (m *Monitor) check(chainID string, address common.Address, wei *big.Int) models.WalletBalance {
+	now := m.now()
+	thresholds := m.cfg.ForChain(chainID)
+	result := models.WalletBalance{
+		ChainID:    chainID,
+		Address:    address.Hex(),
+		Status:     models.WalletOK,
+		BalanceWei: wei.String(),
+		Balance:    weiToEther(wei),
+		CheckedAt:  now,
+	}
+
+	spent, over := m.spend(chainID+"|"+address.Hex(), now, wei)
+	if over > 0 {
+		result.SpendPerHour = weiToEther(spent) / over.Hours()
+	}
+	if result.SpendPerHour > 0 {
+		runway := result.Balance / result.SpendPerHour
+		result.RunwayHours = &runway
+	}
+
+	switch {
+	case thresholds.MinBalance > 0 && result.Balance < thresholds.MinBalance:
+		result.Status = models.WalletLowBalance
+		result.Detail = fmt.Sprintf("%.6f left, %.6f wanted", result.Balance, thresholds.MinBalance)
+	case thresholds.MinRunwayHours > 0 && result.RunwayHours != nil && *result.RunwayHours < thresholds.MinRunwayHours:
+		result.Status = models.WalletLowRunway
+		result.Detail = fmt.Sprintf("runs dry in %.1fh at %.6f an hour, %.0fh wanted",
+			*result.RunwayHours, result.SpendPerHour, thresholds.MinRunwayHours)
+	}
+	return result
+}
+
+// spend adds a balance to the wallet's window and sums its drops, over
+// the time the window covers
+func (m *Monitor) spend(k string, now time.Time, wei *big.Int) (*big.Int, time.Duration) {
+	cutoff := now.Add(-time.Duration(m.cfg.RunwayWindowSeconds) * time.Second)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	samples := m.samples[k]
+	for len(samples) > 0 && samples[0].at.Before(cutoff) {
+		samples = samples[1:]
+	}
+	samples = append(samples, sample{at: now, wei: new(big.Int).Set(wei)})
+	m.samples[k] = samples
+
+	spent := new(big.Int)
+	for i := 1; i < len(samples); i++ {
+		if drop := new(big.Int).Sub(samples[i-1].wei, samples[i].wei); drop.Sign() > 0 {
+			spent.Add(spent, drop)
+		}
+	}
+	return spent, now.Sub(samples[0].at)
+}
+
+// record keeps the result and alerts when the status changed
+func (m *Monitor) record(ctx context.Context, result models.WalletBalance) {
+	k := result.ChainID + "|" + result.Address
+
+	m.mu.Lock()
+	prev, seen := m.results[k]
+	changed := !seen || prev.Status != result.Status
+	if changed {
+		result.Since = result.CheckedAt
+	} else {
+		result.Since = prev.Since
+	}
+	m.results[k] = result
+	m.mu.Unlock()
+
+	// a first healthy check is not worth an alert
+	if !changed || (!seen && result.Status == models.WalletOK) {
+		return
+	}
+	m.alert(ctx, prev, result)
+}
+
+func (m *Monitor) alert(ctx context.Context, prev, result models.WalletBalance) {
+	fields := []zap.Field{
+		zap.String("chainID", result.ChainID),
+		zap.String("address", result.Address),
+		zap.String("status", result.Status),
+		zap.String("previous", prev.Status),
+		zap.String("detail", result.Detail),
+		zap.Float64("balance", result.Balance),
+		zap.Float64("spendPerHour", result.SpendPerHour),
+	}
+	if result.Status == models.WalletOK {
+		logging.Logger.Info("Relayer wallet funded", fields...)
+	} else {
+		logging.Logger.Error("Relayer wallet needs funds", fields...)
+	}
+
+	if m.cfg.AlertWebhook == "" {
+		return
+	}
+	body, err := json.Marshal(result)
+	if err != nil {
+		return
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.cfg.AlertWebhook, bytes.NewReader(body))
+	if err != nil {
+		logging.Logger.Warn("Invalid balance alert webhook", zap.Error(err))
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := m.http.Do(req)
+	if err != nil {
+		logging.Logger.Warn("Failed to post balance alert", zap.Error(err))
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		logging.Logger.Warn("Balance alert webhook refused the alert", zap.Int("status", resp.StatusCode))
+	}
+}
+
+// Results lists the latest check of every wallet, the ones needing funds
+// first
+func (m *Monitor) Results() []models.WalletBalance {
+	m.mu.RLock()
+	results := make([]models.WalletBalance, 0, len(m.results))
+	for _, result := range m.results {
+		results = append(results, result)
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if (a.Status == models.WalletOK) != (b.Status == models.WalletOK) {
+			return b.Status == models.WalletOK
+		}
+		if a.ChainID != b.ChainID {
+			return a.ChainID < b.ChainID
+		}
+		return a.Address < b.Address
+	})
+	return results
+}
+
+// Runways sums up the latest checks per chain, the chains needing funds
+// first
+func (m *Monitor) Runways() []models.ChainRunway {
+	byChain := make(map[string]*models.ChainRunway)
+	runways := make([]*models.ChainRunway, 0)
+	for _, result := range m.Results() {
+		runway, ok := byChain[result.ChainID]
+		if !ok {
+			runway = &models.ChainRunway{ChainID: result.ChainID, Status: models.WalletOK}
+			byChain[result.ChainID] = runway
+			runways = append(runways, runway)
+		}
+		runway.Wallets++
+		if result.Status != models.WalletOK {
+			runway.Status = "degraded"
+			runway.NeedingFunds++
+		}
+		if result.RunwayHours != nil && (runway.MinRunwayHours == nil || *result.RunwayHours < *runway.MinRunwayHours) {
+			hours := *result.RunwayHours
+			runway.MinRunwayHours = &hours
+		}
+	}
+
+	summaries := make([]models.ChainRunway, 0, len(runways))
+	for _, runway := range runways {
+		summaries = append(summaries, *runway)
+	}
+	sort.SliceStable(summaries, func(i, j int) bool {
+		return summaries[i].NeedingFunds > 0 && summaries[j].NeedingFunds == 0
+	})
+	return summaries
+}
+
+func weiToEther(wei *big.Int) float64 {
+	ether, _ := new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(1e18)).Float64()
+	return ether
+}
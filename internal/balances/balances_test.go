@@ -0,0 +1,135 @@
+package balances
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"oracle_engine/internal/config"
+	"oracle_engine/internal/logging"
+	"oracle_engine/internal/models"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"go.uber.org/zap"
+)
+
+var wallet = common.HexToAddress("0x00000000000000000000000000000000000000aa")
+
+type staticBalances struct{ wei *big.Int }
+
+func (s *staticBalances) NativeBalances(ctx context.Context, chainID string, addresses []common.Address) ([]*big.Int, error) {
+	return []*big.Int{s.wei}, nil
+}
+
+func ether(milli int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(milli), big.NewInt(1e15))
+}
+
+func TestProjectsRunwayFromTheBalanceDrops(t *testing.T) {
+	logging.Logger = zap.NewNop()
+	cfg := &config.Config{
+		Contracts: []config.ContractConfig{{ChainID: "84532", Address: "0x01"}, {ChainID: "84532", Address: "0x02"}},
+		BalanceMonitor: config.BalanceMonitorConfig{
+			Enabled:             true,
+			RunwayWindowSeconds: 6 * 3600,
+			MinBalance:          0.01,
+			MinRunwayHours:      72,
+		},
+	}
+	reader := &staticBalances{wei: ether(1000)}
+	m := New(cfg, func(chainID string) ([]common.Address, error) {
+		return []common.Address{wallet}, nil
+	}, reader)
+	now := time.Unix(1_750_000_000, 0)
+	m.now = func() time.Time { return now }
+
+	m.Check(context.Background())
+	results := m.Results()
+	if len(results) != 1 || results[0].Status != models.WalletOK || results[0].RunwayHours != nil {
+		t.Fatalf("first check %+v, want one funded wallet without a runway yet", results)
+	}
+
+	// 0.02 over three hours, the top up in between is no negative spend
+	steps := []int64{990, 1980, 1970}
+	for _, milli := range steps {
+		now = now.Add(time.Hour)
+		reader.wei = ether(milli)
+		m.Check(context.Background())
+	}
+	result := m.Results()[0]
+	if result.SpendPerHour < 0.0066 || result.SpendPerHour > 0.0067 {
+		t.Fatalf("spend %f an hour, want 0.02/3", result.SpendPerHour)
+	}
+	if result.RunwayHours == nil || *result.RunwayHours < 295 || *result.RunwayHours > 296 || result.Status != models.WalletOK {
+		t.Fatalf("runway %v, status %s, want 295.5h and ok", result.RunwayHours, result.Status)
+	}
+
+	now = now.Add(time.Hour)
+	reader.wei = ether(500)
+	m.Check(context.Background())
+	if result := m.Results()[0]; result.Status != models.WalletLowRunway || result.Since != now {
+		t.Fatalf("after a large spend %+v, want a low runway since now", result)
+	}
+
+	now = now.Add(time.Hour)
+	reader.wei = ether(5)
+	m.Check(context.Background())
+	if result := m.Results()[0]; result.Status != models.WalletLowBalance {
+		t.Fatalf("with 0.005 left %+v, want a low balance", result)
+	}
+	runways := m.Runways()
+	if len(runways) != 1 || runways[0].Status != "degraded" || runways[0].Wallets != 1 || runways[0].NeedingFunds != 1 {
+		t.Fatalf("runways %+v, want the chain degraded by its one wallet", runways)
+	}
+}
+
+// balanceReaderChain answers getNativeBalances with a balance per wallet
+type balanceReaderChain struct {
+	t      *testing.T
+	reader common.Address
+}
+
+func (c *balanceReaderChain) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	return []byte{0x1}, nil
+}
+
+func (c *balanceReaderChain) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	if *call.To != c.reader {
+		c.t.Fatalf("called %s, want the balance reader", call.To.Hex())
+	}
+	args, err := balanceReaderABI.Methods["getNativeBalances"].Inputs.Unpack(call.Data[4:])
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	addresses := args[0].([]common.Address)
+	balances := make([]*big.Int, len(addresses))
+	for i := range addresses {
+		balances[i] = ether(int64(i + 1))
+	}
+	return balanceReaderABI.Methods["getNativeBalances"].Outputs.Pack(balances)
+}
+
+func (c *balanceReaderChain) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	c.t.Fatal("read a balance one by one on a chain with a balance reader")
+	return nil, nil
+}
+
+func TestReadsEveryWalletThroughTheBalanceReader(t *testing.T) {
+	reader := common.HexToAddress("0xba1a")
+	cfg := &config.Config{BalanceMonitor: config.BalanceMonitorConfig{
+		Chains: map[string]config.ChainBalanceConfig{"8453": {BalanceReader: reader.Hex()}},
+	}}
+	r := NewChainReader(cfg, func(chainID string) (Client, error) {
+		return &balanceReaderChain{t: t, reader: reader}, nil
+	})
+
+	balances, err := r.NativeBalances(context.Background(), "8453", []common.Address{wallet, common.HexToAddress("0xbb")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(balances) != 2 || balances[0].Cmp(ether(1)) != 0 || balances[1].Cmp(ether(2)) != 0 {
+		t.Fatalf("read %v", balances)
+	}
+}
@@ -0,0 +1,80 @@
+package balances
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"oracle_engine/internal/config"
+	"oracle_engine/internal/rpcpool"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+
+	importVerifier "oracle_engine/pkg/abi"
+)
+
+var balanceReaderABI, _ = abi.JSON(strings.NewReader(importVerifier.BalanceReaderABI))
+
+// Client reads contracts and balances of a chain
+type Client interface {
+	bind.ContractCaller
+	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+}
+
+// Clients resolves the client of a chain
+type Clients func(chainID string) (Client, error)
+
+// PoolClients reads over the rpc endpoints of pool
+func PoolClients(pool *rpcpool.Pool) Clients {
+	return func(chainID string) (Client, error) {
+		chain, err := pool.Chain(chainID)
+		if err != nil {
+			return nil, err
+		}
+		return chain, nil
+	}
+}
+
+// chainReader reads balances through the chain's BalanceReader, or one
+// by one without it
+type chainReader struct {
+	cfg     config.BalanceMonitorConfig
+	clients Clients
+}
+
+// NewChainReader reads balances through clients
+func NewChainReader(cfg *config.Config, clients Clients) Reader {
+	return &chainReader{cfg: cfg.BalanceMonitor, clients: clients}
+}
+
+func (r *chainReader) NativeBalances(ctx context.Context, chainID string, addresses []common.Address) ([]*big.Int, error) {
+	client, err := r.clients(chainID)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := r.cfg.ForChain(chainID).BalanceReader
+	if reader == "" {
+		balances := make([]*big.Int, len(addresses))
+		for i, address := range addresses {
+			if balances[i], err = client.BalanceAt(ctx, address, nil); err != nil {
+				return nil, fmt.Errorf("reading balance of %s: %w", address.Hex(), err)
+			}
+		}
+		return balances, nil
+	}
+
+	contract := bind.NewBoundContract(common.HexToAddress(reader), balanceReaderABI, client, nil, nil)
+	var out []interface{}
+	if err := contract.Call(&bind.CallOpts{Context: ctx}, &out, "getNativeBalances", addresses); err != nil {
+		return nil, fmt.Errorf("reading balances through %s: %w", reader, err)
+	}
+	balances, ok := out[0].([]*big.Int)
+	if !ok {
+		return nil, fmt.Errorf("balance reader %s returned %T", reader, out[0])
+	}
+	return balances, nil
+}
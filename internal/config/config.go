@@ -1,8 +1,12 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"log"
+	"math"
 	"os"
+	"strings"
 
 	"github.com/joho/godotenv"
 	"github.com/spf13/viper"
@@ -15,11 +19,138 @@ type FeedConfig struct {
 }
 
 type ContractConfig struct {
-	Address   string `mapstructure:"address"`
-	RPC       string `mapstructure:"rpc"`
-	ABI       string `mapstructure:"abi"`
-	ChainID   string `mapstructure:"chainID"`
-	ChainName string `mapstructure:"chainName"`
+	Address   string    `mapstructure:"address"`
+	RPC       string    `mapstructure:"rpc"`
+	RPCs      []string  `mapstructure:"rpcs"` // more endpoints of the chain to fail over to
+	ABI       string    `mapstructure:"abi"`
+	ChainID   string    `mapstructure:"chainID"`
+	ChainName string    `mapstructure:"chainName"`
+	Fees      FeeConfig `mapstructure:"fees"`
+	// asset names, internal identities or asset ids the contract carries, empty carries every asset
+	Assets []string `mapstructure:"assets"`
+	// publish an asset here on a move this large since it was last published here,
+	// 0 without a heartbeat publishes every issuance
+	DeviationThreshold float64 `mapstructure:"deviation_threshold"`
+	// seconds, publish an unmoved asset here at least this often
+	Heartbeat int `mapstructure:"heartbeat"`
+	// decimals of the prices on chain, 0 keeps the exponent of the aggregate
+	Decimals int `mapstructure:"decimals"`
+	// overrides relayer_batch for this contract
+	Batch RelayerBatchConfig `mapstructure:"batch"`
+	// what `oracle admin` keeps on chain
+	Admin ContractAdminConfig `mapstructure:"admin"`
+}
+
+// ContractAdminConfig is the admin settings a contract should hold on
+// chain, an empty setting is left as it is
+type ContractAdminConfig struct {
+	Owner              string       `mapstructure:"owner"`
+	RelayerNode        string       `mapstructure:"relayer_node"`
+	ForwarderAddress   string       `mapstructure:"forwarder_address"`    // CRE contracts only
+	ExpectedWorkflowID string       `mapstructure:"expected_workflow_id"` // CRE contracts only, 32 bytes hex
+	Signer             SignerConfig `mapstructure:"signer"`               // overrides admin_signer for this contract
+}
+
+// BatchConfig is relayer_batch with the contract's overrides
+func (c ContractConfig) BatchConfig(global RelayerBatchConfig) RelayerBatchConfig {
+	batch := global
+	if c.Batch.MaxIssuances > 0 {
+		batch.MaxIssuances = c.Batch.MaxIssuances
+	}
+	if c.Batch.FlushIntervalSeconds > 0 {
+		batch.FlushIntervalSeconds = c.Batch.FlushIntervalSeconds
+	}
+	if c.Batch.ChannelBuffer > 0 {
+		batch.ChannelBuffer = c.Batch.ChannelBuffer
+	}
+	return batch
+}
+
+// Endpoints lists the rpc urls of the contract's chain, ALCHEMY_URL when
+// none is configured
+func (c ContractConfig) Endpoints() []string {
+	urls := make([]string, 0, len(c.RPCs)+1)
+	seen := make(map[string]struct{}, len(c.RPCs)+1)
+	for _, url := range append([]string{c.RPC}, c.RPCs...) {
+		url = strings.TrimSpace(url)
+		if url == "" {
+			continue
+		}
+		if _, ok := seen[url]; ok {
+			continue
+		}
+		seen[url] = struct{}{}
+		urls = append(urls, url)
+	}
+	if len(urls) == 0 {
+		if url := os.Getenv("ALCHEMY_URL"); url != "" {
+			urls = append(urls, url)
+		}
+	}
+	return urls
+}
+
+// Priority fee strategies
+const (
+	PriorityFeeFixed      = "fixed"      // always priority_fee_gwei
+	PriorityFeePercentile = "percentile" // percentile of the tips in recent blocks
+	PriorityFeeOracle     = "oracle"     // the node's eth_maxPriorityFeePerGas
+)
+
+// FeeConfig is the fee strategy of the transactions sent to a chain
+type FeeConfig struct {
+	// send legacy transactions even when the chain has a base fee
+	Legacy bool `mapstructure:"legacy"`
+	// cap on the max fee per gas, and on the gas price of legacy txs, 0 is no cap
+	MaxFeeGwei float64 `mapstructure:"max_fee_gwei"`
+	// max fee per gas as a multiple of the base fee, room for base fee rises
+	BaseFeeMultiplier float64 `mapstructure:"base_fee_multiplier"`
+	// "fixed", "percentile" or "oracle"
+	PriorityStrategy string `mapstructure:"priority_strategy"`
+	// the tip of "fixed", the minimum tip of the other strategies
+	PriorityFeeGwei float64 `mapstructure:"priority_fee_gwei"`
+	// percentile: which percentile of the tips in the last fee_history_blocks
+	PriorityPercentile float64 `mapstructure:"priority_percentile"`
+	FeeHistoryBlocks   int     `mapstructure:"fee_history_blocks"`
+	// gas limit is the estimate plus this share, 0.2 is 20% on top
+	GasLimitHeadroom float64 `mapstructure:"gas_limit_headroom"`
+	// most the relayer may spend on this chain in a rolling hour, in the native token, 0 is no cap
+	MaxSpendPerHour float64 `mapstructure:"max_spend_per_hour"`
+}
+
+// WithDefaults fills the unset fields of a fee config
+func (f FeeConfig) WithDefaults() FeeConfig {
+	if f.BaseFeeMultiplier <= 0 {
+		f.BaseFeeMultiplier = 2
+	}
+	if f.PriorityStrategy == "" {
+		f.PriorityStrategy = PriorityFeeOracle
+	}
+	if f.PriorityPercentile <= 0 || f.PriorityPercentile > 100 {
+		f.PriorityPercentile = 50
+	}
+	if f.FeeHistoryBlocks <= 0 {
+		f.FeeHistoryBlocks = 20
+	}
+	if f.GasLimitHeadroom <= 0 {
+		f.GasLimitHeadroom = 0.2
+	}
+	return f
+}
+
+const (
+	// RelayerModeLive submits to the configured rpc endpoints
+	RelayerModeLive = "live"
+	// RelayerModeSimulated submits to an in process chain per configured
+	// chain, nothing leaves the process and no gas is spent
+	RelayerModeSimulated = "simulated"
+)
+
+// SimulatedChainConfig points at the compiled contracts the simulated
+// chains deploy, forge or hardhat artifacts with the abi and bytecode
+type SimulatedChainConfig struct {
+	VerifierArtifact  string `mapstructure:"verifier_artifact"`   // IfaPriceFeedVerifier
+	PriceFeedArtifact string `mapstructure:"price_feed_artifact"` // IfaPriceFeed
 }
 
 type RelayerBatchConfig struct {
@@ -29,13 +160,189 @@ type RelayerBatchConfig struct {
 	ChannelBuffer        int  `mapstructure:"channel_buffer"`
 }
 
+// RelayOutboxConfig drives how relayer workers work off the outbox
+type RelayOutboxConfig struct {
+	LeaseSeconds        int `mapstructure:"lease_seconds"` // a claim not finished by then is claimable again
+	MaxAttempts         int `mapstructure:"max_attempts"`  // claims of a relay before it is given up
+	RetryBackoffSeconds int `mapstructure:"retry_backoff_seconds"`
+}
+
+// TxTrackerConfig drives how submitted transactions are followed until final
+type TxTrackerConfig struct {
+	PollIntervalSeconds int    `mapstructure:"poll_interval_seconds"`
+	StuckTimeoutSeconds int    `mapstructure:"stuck_timeout_seconds"` // unmined this long, replace the tx
+	FeeBumpPercent      int    `mapstructure:"fee_bump_percent"`      // fee increase per replacement, nodes want at least 10
+	MaxReplacements     int    `mapstructure:"max_replacements"`
+	Confirmations       uint64 `mapstructure:"confirmations"`   // blocks on top before an issuance counts as confirmed
+	FinalityBlocks      uint64 `mapstructure:"finality_blocks"` // blocks a mined tx is watched for reorgs
+}
+
+// RPCPoolConfig drives the ranking and failover of a chain's rpc endpoints
+type RPCPoolConfig struct {
+	HealthIntervalSeconds int    `mapstructure:"health_interval_seconds"` // how often every endpoint's head is checked
+	RequestTimeoutSeconds int    `mapstructure:"request_timeout_seconds"`
+	MaxBlockLag           uint64 `mapstructure:"max_block_lag"`    // blocks behind the best head before an endpoint counts as stale
+	CooldownSeconds       int    `mapstructure:"cooldown_seconds"` // a failing endpoint is skipped this long
+}
+
+const (
+	// SignerKeystore decrypts a go-ethereum keystore file at startup
+	SignerKeystore = "keystore"
+	// SignerRemote has an external signer like clef sign every tx
+	SignerRemote = "remote"
+	// SignerRaw signs with a plaintext hex key, for development only
+	SignerRaw = "raw"
+)
+
+// SignerConfig is one relayer address and how its txs get signed
+type SignerConfig struct {
+	Type           string `mapstructure:"type"`            // "keystore", "remote" or "raw"
+	Keystore       string `mapstructure:"keystore"`        // keystore: the encrypted key file
+	PassphraseFile string `mapstructure:"passphrase_file"` // keystore: file holding the passphrase
+	PassphraseEnv  string `mapstructure:"passphrase_env"`  // keystore: env var holding the passphrase, RELAYER_KEYSTORE_PASSPHRASE by default
+	URL            string `mapstructure:"url"`             // remote: the signer's endpoint
+	Address        string `mapstructure:"address"`         // remote: the account it signs for
+	Method         string `mapstructure:"method"`          // remote: eth_signTransaction by default, account_signTransaction for clef
+	Key            string `mapstructure:"key"`             // raw: hex private key
+}
+
+// ReconcileConfig drives the monitor comparing what the contracts hold
+// with what the engine issued
+type ReconcileConfig struct {
+	Enabled           bool    `mapstructure:"enabled"`
+	IntervalSeconds   int     `mapstructure:"interval_seconds"`
+	MaxDrift          float64 `mapstructure:"max_drift"`           // relative, on top of the contract's deviation threshold
+	StaleGraceSeconds int     `mapstructure:"stale_grace_seconds"` // slack past the heartbeat, and time an issuance gets to land
+	AlertWebhook      string  `mapstructure:"alert_webhook"`       // receives every status change as json, empty only logs
+}
+
+// ChainHealthConfig holds the relayer back while a chain is unhealthy
+type ChainHealthConfig struct {
+	Enabled               bool `mapstructure:"enabled"`
+	MaxBlockAgeSeconds    int  `mapstructure:"max_block_age_seconds"`   // an older head block is a stalled chain
+	SequencerGraceSeconds int  `mapstructure:"sequencer_grace_seconds"` // wait this long after the sequencer is back up
+	// per chain id, overrides the limits and names the chain's sequencer uptime feed
+	Chains map[string]ChainHealthSettings `mapstructure:"chains"`
+}
+
+// ChainHealthSettings is the health check of one chain
+type ChainHealthSettings struct {
+	SequencerUptimeFeed   string `mapstructure:"sequencer_uptime_feed"` // chainlink style feed, answer 0 is up, empty skips the check
+	MaxBlockAgeSeconds    int    `mapstructure:"max_block_age_seconds"`
+	SequencerGraceSeconds int    `mapstructure:"sequencer_grace_seconds"`
+}
+
+// ForChain is the health check of a chain, the global limits where the
+// chain sets none
+func (c ChainHealthConfig) ForChain(chainID string) ChainHealthSettings {
+	chain := c.Chains[chainID]
+	if chain.MaxBlockAgeSeconds <= 0 {
+		chain.MaxBlockAgeSeconds = c.MaxBlockAgeSeconds
+	}
+	if chain.SequencerGraceSeconds <= 0 {
+		chain.SequencerGraceSeconds = c.SequencerGraceSeconds
+	}
+	return chain
+}
+
+// CostAccountingConfig prices the relayer's tx costs in usd
+type CostAccountingConfig struct {
+	NativeAsset  string            `mapstructure:"native_asset"`  // asset priced as the native token of every chain, empty reports no usd
+	NativeAssets map[string]string `mapstructure:"native_assets"` // per chain id, for chains whose native token is not native_asset
+}
+
+// NativeAssetFor is the asset priced as the native token of a chain
+func (c CostAccountingConfig) NativeAssetFor(chainID string) string {
+	if asset, ok := c.NativeAssets[chainID]; ok {
+		return asset
+	}
+	return c.NativeAsset
+}
+
+// BalanceMonitorConfig drives the watch over the native balances of the
+// relayer wallets
+type BalanceMonitorConfig struct {
+	Enabled             bool    `mapstructure:"enabled"`
+	IntervalSeconds     int     `mapstructure:"interval_seconds"`
+	RunwayWindowSeconds int     `mapstructure:"runway_window_seconds"` // spend over this long projects the runway
+	MinBalance          float64 `mapstructure:"min_balance"`           // native token, below it a wallet is low
+	MinRunwayHours      float64 `mapstructure:"min_runway_hours"`      // below it a wallet is running out
+	AlertWebhook        string  `mapstructure:"alert_webhook"`         // receives every status change as json, empty only logs
+	// per chain id, overrides the thresholds and names the chain's BalanceReader
+	Chains map[string]ChainBalanceConfig `mapstructure:"chains"`
+}
+
+// ChainBalanceConfig is the balance watch of one chain
+type ChainBalanceConfig struct {
+	BalanceReader  string  `mapstructure:"balance_reader"` // reads every wallet in one call, empty asks eth_getBalance per wallet
+	MinBalance     float64 `mapstructure:"min_balance"`
+	MinRunwayHours float64 `mapstructure:"min_runway_hours"`
+}
+
+// ForChain is the balance watch of a chain, the global thresholds where
+// the chain sets none
+func (c BalanceMonitorConfig) ForChain(chainID string) ChainBalanceConfig {
+	chain := c.Chains[chainID]
+	if chain.MinBalance <= 0 {
+		chain.MinBalance = c.MinBalance
+	}
+	if chain.MinRunwayHours <= 0 {
+		chain.MinRunwayHours = c.MinRunwayHours
+	}
+	return chain
+}
+
 type AssetSetting struct {
 	// ttl in seconds
 	TTL int `mapstructure:"ttl"` // Time to live for price pool
 	// percentage deviation for consensus in perc eg 0.01
 	DevPerc float32 `mapstructure:"dev_perc"` // Deviation percentage for consensus
+	// Quorum: minimum number of distinct sources inside one aggregation window
+	MinSources int `mapstructure:"min_sources"`
+	// Quorum: sources that must be part of every aggregate eg ["pyth"]
+	RequiredSources []string `mapstructure:"required_sources"`
+	// Quorum: what to do with an aggregate that misses quorum, "withhold" or "degrade"
+	QuorumAction string `mapstructure:"quorum_action"`
+	// max age in seconds of a price at aggregation time, older ones are dropped as stale.
+	// 0 keeps prices of any age, providers like currencylayer stamp prices with
+	// their last update, often an hour old, so set it per asset from its feeds' cadence
+	MaxPriceAge int `mapstructure:"max_price_age"`
+	// Push policy: issue when the price moves more than this fraction from the last issuance eg 0.005,
+	// 0 issues on every change, unset takes the default
+	DeviationThreshold *float64 `mapstructure:"deviation_threshold"`
+	// Push policy: issue anyway once this many seconds passed since the last issuance
+	Heartbeat int `mapstructure:"heartbeat"`
+	// History: recent aggregates consensus compares a new one against
+	HistoryWindow int `mapstructure:"history_window"`
+	// History: "linear", "ewma" or "volatility"
+	HistoryMode string `mapstructure:"history_mode"`
+	// History: smoothing factor of the ewma modes, weight of the newest price eg 0.3
+	EWMAAlpha float64 `mapstructure:"ewma_alpha"`
+	// History: band width in standard deviations of realized returns for "volatility"
+	VolatilityBand float64 `mapstructure:"volatility_band"`
+	// Breaker: consecutive denied aggregates that halt the asset
+	BreakerDenials int `mapstructure:"breaker_denials"`
+	// Breaker: move from the last approved issuance that halts at once eg 0.2
+	BreakerMaxJump float64 `mapstructure:"breaker_max_jump"`
+	// Breaker: consecutive aggregates below source quorum that halt the asset
+	BreakerQuorumLosses int `mapstructure:"breaker_quorum_losses"`
+	// Breaker: seconds a halt lasts at least before it can resume on its own
+	BreakerCooldown int `mapstructure:"breaker_cooldown"`
+	// Breaker: consecutive stable aggregates after the cooldown that resume the asset
+	BreakerStableSamples int `mapstructure:"breaker_stable_samples"`
 }
 
+const (
+	HistoryModeLinear     = "linear"
+	HistoryModeEWMA       = "ewma"
+	HistoryModeVolatility = "volatility"
+)
+
+const (
+	QuorumActionWithhold = "withhold"
+	QuorumActionDegrade  = "degrade"
+)
+
 type AssetConfig struct {
 	Name                  string       `mapstructure:"name"`                  // e.g., "BTC/USD"
 	InternalAssetIdentity string       `mapstructure:"internalAssetIdentity"` // eg "0xUSDT"
@@ -44,9 +351,129 @@ type AssetConfig struct {
 	Settings AssetSetting `mapstructure:"settings"` // Settings for the asset
 }
 
+var defaultDeviationThreshold = 0.005
+
 var DefaultAssetSetting = AssetSetting{
-	TTL:     10,   // Default TTL in seconds
-	DevPerc: 0.04, // Default deviation percentage for consensus
+	TTL:          10,   // Default TTL in seconds
+	DevPerc:      0.04, // Default deviation percentage for consensus
+	MinSources:   1,    // A single source is enough unless configured
+	QuorumAction: QuorumActionDegrade,
+	// Issue on a 0.5% move or at least hourly
+	DeviationThreshold: &defaultDeviationThreshold,
+	Heartbeat:          3600,
+	// Compare against the last 10 aggregates, linearly weighted
+	HistoryWindow:  10,
+	HistoryMode:    HistoryModeLinear,
+	EWMAAlpha:      0.3,
+	VolatilityBand: 3,
+	// Halt on 3 denials in a row, a 20% jump or 3 aggregates without quorum
+	BreakerDenials:       3,
+	BreakerMaxJump:       0.2,
+	BreakerQuorumLosses:  3,
+	BreakerCooldown:      300,
+	BreakerStableSamples: 5,
+}
+
+// WithDefaults fills unset asset settings from DefaultAssetSetting
+func (s AssetSetting) WithDefaults() AssetSetting {
+	if s.TTL == 0 {
+		s.TTL = DefaultAssetSetting.TTL
+	}
+	if s.DevPerc == 0 {
+		s.DevPerc = DefaultAssetSetting.DevPerc
+	}
+	if s.MinSources <= 0 {
+		s.MinSources = DefaultAssetSetting.MinSources
+	}
+	if s.QuorumAction == "" {
+		s.QuorumAction = DefaultAssetSetting.QuorumAction
+	}
+	if s.DeviationThreshold == nil {
+		threshold := *DefaultAssetSetting.DeviationThreshold
+		s.DeviationThreshold = &threshold
+	}
+	if s.Heartbeat <= 0 {
+		s.Heartbeat = DefaultAssetSetting.Heartbeat
+	}
+	if s.HistoryWindow <= 0 {
+		s.HistoryWindow = DefaultAssetSetting.HistoryWindow
+	}
+	if s.HistoryMode == "" {
+		s.HistoryMode = DefaultAssetSetting.HistoryMode
+	}
+	if s.EWMAAlpha <= 0 || s.EWMAAlpha > 1 {
+		s.EWMAAlpha = DefaultAssetSetting.EWMAAlpha
+	}
+	if s.VolatilityBand <= 0 {
+		s.VolatilityBand = DefaultAssetSetting.VolatilityBand
+	}
+	if s.BreakerDenials == 0 {
+		s.BreakerDenials = DefaultAssetSetting.BreakerDenials
+	}
+	if s.BreakerMaxJump == 0 {
+		s.BreakerMaxJump = DefaultAssetSetting.BreakerMaxJump
+	}
+	if s.BreakerQuorumLosses == 0 {
+		s.BreakerQuorumLosses = DefaultAssetSetting.BreakerQuorumLosses
+	}
+	if s.BreakerCooldown <= 0 {
+		s.BreakerCooldown = DefaultAssetSetting.BreakerCooldown
+	}
+	if s.BreakerStableSamples <= 0 {
+		s.BreakerStableSamples = DefaultAssetSetting.BreakerStableSamples
+	}
+	return s
+}
+
+// AggregatorConfig controls how aggregator units are spun up and scaled
+type AggregatorConfig struct {
+	// workers every unit starts with, it scales up to aggregator_nodes under load
+	InitialWorkers int `mapstructure:"initial_workers"`
+	// cap on units created on demand for assets missing from the config
+	MaxUnknownUnits int `mapstructure:"max_unknown_units"`
+	// units created on demand are torn down after this long without prices
+	IdleUnitTimeoutSeconds int `mapstructure:"idle_unit_timeout_seconds"`
+	// incoming price buffer of each unit
+	UnitBuffer int `mapstructure:"unit_buffer"`
+}
+
+// ReputationConfig tunes the adaptive source reputation scores
+type ReputationConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// time after which an observation counts half as much
+	HalfLifeSeconds int `mapstructure:"half_life_seconds"`
+	// relative deviation from the aggregate at which a score halves eg 0.01
+	DeviationScale float64 `mapstructure:"deviation_scale"`
+	// sample age at which a score halves
+	StalenessScaleSeconds int `mapstructure:"staleness_scale_seconds"`
+	// lowest weight a source can drop to, keeps it observable
+	MinWeight              float64 `mapstructure:"min_weight"`
+	PersistIntervalSeconds int     `mapstructure:"persist_interval_seconds"`
+}
+
+// MultiNodeConfig runs consensus across several engine nodes that sign
+// and exchange their observations before anything is relayed
+type MultiNodeConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// hex private key this node signs observations with, env MULTINODE_NODE_KEY
+	NodeKey string `mapstructure:"node_key"`
+	// address the peer endpoint listens on eg ":9100"
+	ListenAddr string `mapstructure:"listen_addr"`
+	// shared bearer token peers present on the peer endpoint, env MULTINODE_AUTH_TOKEN
+	AuthToken string `mapstructure:"auth_token"`
+	// allowlisted nodes, this node included
+	Peers []PeerConfig `mapstructure:"peers"`
+	// signatures that must agree before a report is produced
+	Quorum int `mapstructure:"quorum"`
+	// max relative distance from the median for an observation to agree eg 0.005
+	Tolerance float64 `mapstructure:"tolerance"`
+	// observations made in the same window of this many seconds share a round
+	RoundSeconds int `mapstructure:"round_seconds"`
+}
+
+type PeerConfig struct {
+	Address string `mapstructure:"address"` // signer address of the node
+	URL     string `mapstructure:"url"`     // peer endpoint, empty for this node
 }
 
 type ApiKey map[string]string
@@ -69,20 +496,86 @@ type Config struct {
 	PricePoolTTL         int                         `mapstructure:"price_pool_ttl"`
 	RELAY_TIME_THRESHOLD int                         `mapstructure:"RELAY_TIME_THRESHOLD"`
 	AggregatorNodes      int                         `mapstructure:"aggregator_nodes"`
+	Aggregator           AggregatorConfig            `mapstructure:"aggregator"`
 	ConsensusThresh      float64                     `mapstructure:"consensus_threshold"`
 	AggrDevPerc          float32                     `mapstructure:"aggr_dev_perc"`
 	Assets               []AssetConfig               `mapstructure:"assets"`
 	ApiKeys              ApiKey                      `mapstructure:"api_keys"`
 	Contracts            []ContractConfig            `mapstructure:"contracts"`
+	RelayerMode          string                      `mapstructure:"relayer_mode"` // "live" or "simulated"
+	SimulatedChain       SimulatedChainConfig        `mapstructure:"simulated_chain"`
 	RelayerBatch         RelayerBatchConfig          `mapstructure:"relayer_batch"`
+	RelayOutbox          RelayOutboxConfig           `mapstructure:"relay_outbox"`
+	TxTracker            TxTrackerConfig             `mapstructure:"tx_tracker"`
+	ChainHealth          ChainHealthConfig           `mapstructure:"chain_health"`
+	RPCPool              RPCPoolConfig               `mapstructure:"rpc_pool"`
+	Reconcile            ReconcileConfig             `mapstructure:"reconcile"`
+	BalanceMonitor       BalanceMonitorConfig        `mapstructure:"balance_monitor"`
+	CostAccounting       CostAccountingConfig        `mapstructure:"cost_accounting"`
+	Reputation           ReputationConfig            `mapstructure:"reputation"`
+	MultiNode            MultiNodeConfig             `mapstructure:"multinode"`
 	PrivateKey           string                      `mapstructure:"private_key"`
+	RelayerKeys          map[string][]string         `mapstructure:"relayer_keys"` // chain id -> relayer key pool
+	Signers              map[string][]SignerConfig   `mapstructure:"signers"`      // chain id -> relayer signer pool, replaces the raw keys
+	AdminSigner          SignerConfig                `mapstructure:"admin_signer"` // signs `oracle admin` txs, the owner or the new owner in a handover
 	DB_URL               string                      `mapstructure:"DB_URL"`
 	SERVER_PORT          string                      `mapstructure:"server_port"`
 	JWTSecret            string                      `mapstructure:"jwt_secret"`
+	AdminTokens          map[string]string           `mapstructure:"admin_tokens"` // operator name -> token
 	SubscriptionPlans    map[string]SubscriptionPlan `mapstructure:"subscription_plans"`
 }
 
+// SignersFor is the relayer signer pool of a chain, its raw keys when it
+// configures no signers
+func (c *Config) SignersFor(chainID string) []SignerConfig {
+	if signers := c.Signers[chainID]; len(signers) > 0 {
+		return signers
+	}
+	keys := c.KeysFor(chainID)
+	signers := make([]SignerConfig, 0, len(keys))
+	for _, key := range keys {
+		signers = append(signers, SignerConfig{Type: SignerRaw, Key: key})
+	}
+	return signers
+}
+
+// AdminSignerFor is the signer of admin txs to ctrct, admin_signer when
+// the contract has none of its own
+func (c *Config) AdminSignerFor(ctrct ContractConfig) SignerConfig {
+	if own := ctrct.Admin.Signer; own.Type != "" || own.Key != "" {
+		return own
+	}
+	return c.AdminSigner
+}
+
+// KeysFor is the relayer key pool of a chain, private_key when the chain
+// has none of its own
+func (c *Config) KeysFor(chainID string) []string {
+	if keys := c.RelayerKeys[chainID]; len(keys) > 0 {
+		return keys
+	}
+	if c.PrivateKey == "" {
+		return nil
+	}
+	return []string{c.PrivateKey}
+}
+
 func Load() *Config {
+	cfg, err := Read()
+	if err != nil {
+		log.Fatalf("Config error: %v", err)
+	}
+	return cfg
+}
+
+// File is the config file in use, empty when running on defaults
+func File() string {
+	return viper.ConfigFileUsed()
+}
+
+// Read is Load returning its errors, a missing config file still falls
+// back to the defaults but a broken one fails
+func Read() (*Config, error) {
 	viper.SetConfigName("config")
 	viper.AddConfigPath(".")
 	viper.SetConfigType("yaml")
@@ -90,12 +583,74 @@ func Load() *Config {
 	viper.SetDefault("price_pool_ttl", 10)
 	viper.SetDefault("aggregator_nodes", 3)
 	viper.SetDefault("consensus_threshold", 0.01)
+	viper.SetDefault("relayer_mode", RelayerModeLive)
 	viper.SetDefault("relayer_batch", map[string]interface{}{
 		"enabled":                true,
 		"max_issuances":          20,
 		"flush_interval_seconds": 3,
 		"channel_buffer":         256,
 	})
+	viper.SetDefault("relay_outbox", map[string]interface{}{
+		"lease_seconds":         120,
+		"max_attempts":          5,
+		"retry_backoff_seconds": 15,
+	})
+	viper.SetDefault("tx_tracker", map[string]interface{}{
+		"poll_interval_seconds": 5,
+		"stuck_timeout_seconds": 120,
+		"fee_bump_percent":      20,
+		"max_replacements":      5,
+		"confirmations":         1,
+		"finality_blocks":       20,
+	})
+	viper.SetDefault("rpc_pool", map[string]interface{}{
+		"health_interval_seconds": 15,
+		"request_timeout_seconds": 10,
+		"max_block_lag":           5,
+		"cooldown_seconds":        30,
+	})
+	viper.SetDefault("reconcile", map[string]interface{}{
+		"enabled":             true,
+		"interval_seconds":    60,
+		"max_drift":           0.01,
+		"stale_grace_seconds": 300,
+	})
+	viper.SetDefault("balance_monitor", map[string]interface{}{
+		"enabled":               true,
+		"interval_seconds":      300,
+		"runway_window_seconds": 21600,
+		"min_balance":           0.01,
+		"min_runway_hours":      72,
+	})
+	viper.SetDefault("chain_health", map[string]interface{}{
+		"enabled":                 false,
+		"max_block_age_seconds":   120,
+		"sequencer_grace_seconds": 0,
+	})
+	viper.SetDefault("cost_accounting", map[string]interface{}{
+		"native_asset": "ETH/USD",
+	})
+	viper.SetDefault("aggregator", map[string]interface{}{
+		"initial_workers":           1,
+		"max_unknown_units":         16,
+		"idle_unit_timeout_seconds": 900,
+		"unit_buffer":               10,
+	})
+	viper.SetDefault("reputation", map[string]interface{}{
+		"enabled":                  true,
+		"half_life_seconds":        3600,
+		"deviation_scale":          0.01,
+		"staleness_scale_seconds":  300,
+		"min_weight":               0.05,
+		"persist_interval_seconds": 30,
+	})
+	viper.SetDefault("multinode", map[string]interface{}{
+		"enabled":       false,
+		"listen_addr":   ":9100",
+		"quorum":        3,
+		"tolerance":     0.005,
+		"round_seconds": 60,
+	})
 	viper.SetDefault("api_keys", map[string]string{
 		"monierate":     os.Getenv("MONIERATE_API_KEY"),
 		"exchangerate":  os.Getenv("EXCHANGERATE_API_KEY"),
@@ -162,22 +717,78 @@ func Load() *Config {
 	_ = godotenv.Load()
 
 	if err := viper.ReadInConfig(); err != nil {
+		var notFound viper.ConfigFileNotFoundError
+		if !errors.As(err, &notFound) {
+			return nil, err
+		}
 		log.Printf("Using defaults: %v", err)
 	}
 
 	var cfg Config
 	if err := viper.Unmarshal(&cfg); err != nil {
-		log.Fatalf("Config error: %v", err)
+		return nil, err
 	}
 
 	if cfg.PrivateKey == "" {
 		cfg.PrivateKey = os.Getenv("PRIVATE_KEY")
 	}
 
+	if mode := os.Getenv("RELAYER_MODE"); mode != "" {
+		cfg.RelayerMode = mode
+	}
+	if cfg.RelayerMode != RelayerModeLive && cfg.RelayerMode != RelayerModeSimulated {
+		return nil, fmt.Errorf("unknown relayer_mode %q", cfg.RelayerMode)
+	}
+	if cfg.RelayerMode == RelayerModeSimulated &&
+		(cfg.SimulatedChain.VerifierArtifact == "" || cfg.SimulatedChain.PriceFeedArtifact == "") {
+		return nil, errors.New("relayer_mode simulated needs simulated_chain.verifier_artifact and price_feed_artifact")
+	}
+	// units count their workers in a byte
+	if cfg.AggregatorNodes < 0 || cfg.AggregatorNodes > math.MaxUint8 {
+		return nil, fmt.Errorf("aggregator_nodes %d out of range 0-%d", cfg.AggregatorNodes, math.MaxUint8)
+	}
+	if cfg.Aggregator.InitialWorkers < 0 || cfg.Aggregator.InitialWorkers > math.MaxUint8 {
+		return nil, fmt.Errorf("aggregator.initial_workers %d out of range 0-%d", cfg.Aggregator.InitialWorkers, math.MaxUint8)
+	}
+
+	// RELAYER_KEYS_<chainID> holds a comma separated key pool of a chain
+	for _, ctrct := range cfg.Contracts {
+		env := os.Getenv("RELAYER_KEYS_" + ctrct.ChainID)
+		if env == "" {
+			continue
+		}
+		if cfg.RelayerKeys == nil {
+			cfg.RelayerKeys = make(map[string][]string)
+		}
+		cfg.RelayerKeys[ctrct.ChainID] = nil
+		for _, key := range strings.Split(env, ",") {
+			if key = strings.TrimSpace(key); key != "" {
+				cfg.RelayerKeys[ctrct.ChainID] = append(cfg.RelayerKeys[ctrct.ChainID], key)
+			}
+		}
+	}
+
+	if cfg.MultiNode.NodeKey == "" {
+		cfg.MultiNode.NodeKey = os.Getenv("MULTINODE_NODE_KEY")
+	}
+
+	if cfg.MultiNode.AuthToken == "" {
+		cfg.MultiNode.AuthToken = os.Getenv("MULTINODE_AUTH_TOKEN")
+	}
+
 	if cfg.DB_URL == "" {
 		cfg.DB_URL = os.Getenv("DB_URL")
 	}
 
+	if token := os.Getenv("ADMIN_TOKEN"); token != "" {
+		if cfg.AdminTokens == nil {
+			cfg.AdminTokens = make(map[string]string)
+		}
+		if _, ok := cfg.AdminTokens["admin"]; !ok {
+			cfg.AdminTokens["admin"] = token
+		}
+	}
+
 	if cfg.JWTSecret == "" {
 		cfg.JWTSecret = os.Getenv("JWT_SECRET")
 		if cfg.JWTSecret == "" {
@@ -185,5 +796,5 @@ func Load() *Config {
 		}
 	}
 
-	return &cfg
+	return &cfg, nil
 }
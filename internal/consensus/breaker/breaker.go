@@ -0,0 +1,250 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"oracle_engine/internal/config"
+	"oracle_engine/internal/database/timescale"
+	"oracle_engine/internal/logging"
+	"oracle_engine/internal/models"
+
+	"go.uber.org/zap"
+)
+
+/*
+Breaker:
+A per asset circuit breaker in front of the relayer. It trips when
+breaker_denials aggregates in a row are denied, when an aggregate moves
+more than breaker_max_jump from the last approved issuance, or when
+breaker_quorum_losses aggregates in a row miss the source quorum.
+A halted asset is not published on chain. It resumes on its own once
+breaker_cooldown passed and breaker_stable_samples aggregates in a row
+stayed within dev_perc of each other, or when an operator acks it.
+While halted only the step between aggregates counts, consensus denies
+a new level against the history from before the halt. On resume that
+history is dropped, so the new level becomes the baseline.
+*/
+
+var ErrNotHalted = errors.New("asset is not halted")
+
+// Observation is what the breaker learns from one aggregate
+type Observation struct {
+	AssetID  string
+	Value    float64
+	Denied   bool
+	Jump     float64 // relative move from the last approved issuance, 0 when unknown
+	Degraded bool    // aggregate below the source quorum
+}
+
+type state struct {
+	halt          models.AssetHalt
+	denials       int
+	quorumLosses  int
+	stable        int
+	lastValue     float64
+	skipJumpCheck bool // after a resume the new level becomes the baseline
+}
+
+type Breaker struct {
+	db       *timescale.TimescaleDB
+	settings func(assetID string) config.AssetSetting
+	now      func() time.Time
+
+	mu       sync.RWMutex
+	states   map[string]*state
+	onResume func(assetID string)
+}
+
+// New creates a breaker reading the settings of an asset through settings
+func New(settings func(assetID string) config.AssetSetting, db *timescale.TimescaleDB) *Breaker {
+	return &Breaker{
+		db:       db,
+		settings: settings,
+		now:      time.Now,
+		states:   make(map[string]*state),
+	}
+}
+
+// Load restores the halts that were open when the engine stopped
+func (b *Breaker) Load(ctx context.Context) error {
+	halts, err := b.db.GetAssetHalts(ctx)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, halt := range halts {
+		b.states[halt.AssetID] = &state{halt: halt}
+	}
+	return nil
+}
+
+func (b *Breaker) stateFor(assetID string) *state {
+	s, ok := b.states[assetID]
+	if !ok {
+		s = &state{halt: models.AssetHalt{AssetID: assetID}}
+		b.states[assetID] = s
+	}
+	return s
+}
+
+// OnResume has fn called with the asset of every resume, auto or acked
+func (b *Breaker) OnResume(fn func(assetID string)) {
+	b.mu.Lock()
+	b.onResume = fn
+	b.mu.Unlock()
+}
+
+// Halted reports whether publishing of the asset is frozen
+func (b *Breaker) Halted(assetID string) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	s, ok := b.states[assetID]
+	return ok && s.halt.Halted
+}
+
+// Observe feeds an aggregate through the breaker and reports whether
+// the asset is halted afterwards
+func (b *Breaker) Observe(ctx context.Context, obs Observation) bool {
+	setting := b.settings(obs.AssetID)
+
+	b.mu.Lock()
+	s := b.stateFor(obs.AssetID)
+	step := 0.0
+	if s.lastValue != 0 {
+		step = math.Abs(obs.Value-s.lastValue) / math.Abs(s.lastValue)
+	}
+	s.lastValue = obs.Value
+
+	var event string
+	if s.halt.Halted {
+		if !obs.Degraded && step <= float64(setting.DevPerc) {
+			s.stable++
+		} else {
+			s.stable = 0
+		}
+		cooled := s.halt.TrippedAt == nil ||
+			b.now().Sub(*s.halt.TrippedAt) >= time.Duration(setting.BreakerCooldown)*time.Second
+		if cooled && s.stable >= setting.BreakerStableSamples {
+			b.resume(s, "auto")
+			event = "resumed"
+		}
+	} else {
+		s.denials = countIf(obs.Denied, s.denials)
+		s.quorumLosses = countIf(obs.Degraded, s.quorumLosses)
+		jumpChecked := !s.skipJumpCheck
+		s.skipJumpCheck = false
+
+		switch {
+		case jumpChecked && setting.BreakerMaxJump > 0 && obs.Jump > setting.BreakerMaxJump:
+			b.trip(s, models.HaltReasonJump,
+				fmt.Sprintf("moved %.2f%% from the last issuance, limit %.2f%%", obs.Jump*100, setting.BreakerMaxJump*100))
+			event = "tripped"
+		case setting.BreakerDenials > 0 && s.denials >= setting.BreakerDenials:
+			b.trip(s, models.HaltReasonDenials, fmt.Sprintf("%d denied aggregates in a row", s.denials))
+			event = "tripped"
+		case setting.BreakerQuorumLosses > 0 && s.quorumLosses >= setting.BreakerQuorumLosses:
+			b.trip(s, models.HaltReasonQuorum, fmt.Sprintf("%d aggregates in a row below source quorum", s.quorumLosses))
+			event = "tripped"
+		}
+	}
+	halt := s.halt
+	onResume := b.onResume
+	b.mu.Unlock()
+
+	if event != "" {
+		b.persist(ctx, halt, event)
+	}
+	if event == "resumed" && onResume != nil {
+		onResume(obs.AssetID)
+	}
+	return halt.Halted
+}
+
+// Resume lifts the halt of an asset on an operator's ack
+func (b *Breaker) Resume(ctx context.Context, assetID string, operator string) (models.AssetHalt, error) {
+	b.mu.Lock()
+	s, ok := b.states[assetID]
+	if !ok || !s.halt.Halted {
+		b.mu.Unlock()
+		return models.AssetHalt{}, ErrNotHalted
+	}
+	b.resume(s, operator)
+	halt := s.halt
+	onResume := b.onResume
+	b.mu.Unlock()
+
+	b.persist(ctx, halt, "resumed")
+	if onResume != nil {
+		onResume(assetID)
+	}
+	return halt, nil
+}
+
+// Statuses returns the breaker state of every asset it has seen
+func (b *Breaker) Statuses() []models.AssetHalt {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	halts := make([]models.AssetHalt, 0, len(b.states))
+	for _, s := range b.states {
+		halts = append(halts, s.halt)
+	}
+	return halts
+}
+
+func (b *Breaker) trip(s *state, reason, detail string) {
+	now := b.now()
+	s.halt.Halted = true
+	s.halt.Reason = reason
+	s.halt.Detail = detail
+	s.halt.TrippedAt = &now
+	s.halt.ResumedAt = nil
+	s.halt.ResumedBy = ""
+	s.halt.UpdatedAt = now
+	s.stable = 0
+
+	logging.Logger.Warn("Circuit breaker tripped, asset halted",
+		zap.String("asset", s.halt.AssetID),
+		zap.String("reason", reason),
+		zap.String("detail", detail),
+	)
+}
+
+func (b *Breaker) resume(s *state, by string) {
+	now := b.now()
+	s.halt.Halted = false
+	s.halt.ResumedAt = &now
+	s.halt.ResumedBy = by
+	s.halt.UpdatedAt = now
+	s.denials = 0
+	s.quorumLosses = 0
+	s.stable = 0
+	s.skipJumpCheck = true
+
+	logging.Logger.Info("Circuit breaker resumed asset",
+		zap.String("asset", s.halt.AssetID),
+		zap.String("by", by),
+	)
+}
+
+func (b *Breaker) persist(ctx context.Context, halt models.AssetHalt, event string) {
+	if b.db == nil {
+		return
+	}
+	if err := b.db.SaveAssetHalt(ctx, halt, event); err != nil {
+		logging.Logger.Error("Error saving asset halt", zap.String("asset", halt.AssetID), zap.Error(err))
+	}
+}
+
+func countIf(cond bool, count int) int {
+	if cond {
+		return count + 1
+	}
+	return 0
+}
@@ -0,0 +1,148 @@
+package breaker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"oracle_engine/internal/config"
+	"oracle_engine/internal/logging"
+	"oracle_engine/internal/models"
+
+	"go.uber.org/zap"
+)
+
+const testAsset = "asset"
+
+func newTestBreaker(t *testing.T) (*Breaker, *time.Time) {
+	t.Helper()
+	logging.Logger = zap.NewNop()
+
+	now := time.Unix(1_750_000_000, 0)
+	setting := config.AssetSetting{
+		DevPerc:              0.01,
+		BreakerDenials:       3,
+		BreakerMaxJump:       0.1,
+		BreakerQuorumLosses:  2,
+		BreakerCooldown:      60,
+		BreakerStableSamples: 3,
+	}.WithDefaults()
+	b := New(func(string) config.AssetSetting { return setting }, nil)
+	b.now = func() time.Time { return now }
+	return b, &now
+}
+
+func observe(b *Breaker, obs Observation) bool {
+	obs.AssetID = testAsset
+	if obs.Value == 0 {
+		obs.Value = 1
+	}
+	return b.Observe(context.Background(), obs)
+}
+
+func TestTripsOnEachCondition(t *testing.T) {
+	cases := []struct {
+		name   string
+		obs    []Observation
+		reason string
+	}{
+		{"jump", []Observation{{Jump: 0.2}}, models.HaltReasonJump},
+		{"denials", []Observation{{Denied: true}, {Denied: true}, {Denied: true}}, models.HaltReasonDenials},
+		{"quorum", []Observation{{Degraded: true}, {Degraded: true}}, models.HaltReasonQuorum},
+	}
+	for _, tc := range cases {
+		b, _ := newTestBreaker(t)
+		halted := false
+		for _, obs := range tc.obs {
+			halted = observe(b, obs)
+		}
+		if !halted {
+			t.Fatalf("%s: breaker did not trip", tc.name)
+		}
+		if reason := b.Statuses()[0].Reason; reason != tc.reason {
+			t.Fatalf("%s: tripped for %q, want %q", tc.name, reason, tc.reason)
+		}
+	}
+}
+
+func TestInterruptedDenialsDoNotTrip(t *testing.T) {
+	b, _ := newTestBreaker(t)
+	for _, obs := range []Observation{{Denied: true}, {Denied: true}, {}, {Denied: true}, {Denied: true}} {
+		if observe(b, obs) {
+			t.Fatal("tripped although the denials were not consecutive")
+		}
+	}
+}
+
+func TestResumesAfterCooldownAndStability(t *testing.T) {
+	b, now := newTestBreaker(t)
+	observe(b, Observation{Jump: 0.5, Value: 0.8})
+
+	// stable at the new level but still cooling down
+	for i := 0; i < 5; i++ {
+		if !observe(b, Observation{Value: 0.8}) {
+			t.Fatal("resumed during the cooldown")
+		}
+	}
+
+	*now = now.Add(2 * time.Minute)
+	observe(b, Observation{Value: 0.9}) // a swing restarts the stable count
+	observe(b, Observation{Value: 0.9})
+	if !observe(b, Observation{Value: 0.9}) {
+		t.Fatal("resumed before enough stable samples")
+	}
+	if observe(b, Observation{Value: 0.9}) {
+		t.Fatal("did not resume after cooldown and stable samples")
+	}
+	if by := b.Statuses()[0].ResumedBy; by != "auto" {
+		t.Fatalf("resumed by %q, want auto", by)
+	}
+}
+
+func TestOperatorResumeRebaselines(t *testing.T) {
+	b, _ := newTestBreaker(t)
+	if _, err := b.Resume(context.Background(), testAsset, "ops"); err != ErrNotHalted {
+		t.Fatalf("resumed an asset that was not halted, err %v", err)
+	}
+
+	observe(b, Observation{Jump: 0.5})
+	halt, err := b.Resume(context.Background(), testAsset, "ops")
+	if err != nil || halt.Halted || halt.ResumedBy != "ops" {
+		t.Fatalf("operator resume failed: %+v, %v", halt, err)
+	}
+
+	// the first aggregate after the ack is the new baseline
+	if observe(b, Observation{Jump: 0.5}) {
+		t.Fatal("tripped again on the level the operator acked")
+	}
+	if !observe(b, Observation{Jump: 0.5}) {
+		t.Fatal("did not trip on a later jump")
+	}
+}
+
+func TestResumesAtANewLevelConsensusDenies(t *testing.T) {
+	b, now := newTestBreaker(t)
+	var resumed []string
+	b.OnResume(func(assetID string) { resumed = append(resumed, assetID) })
+	observe(b, Observation{Jump: 0.5, Value: 0.8})
+
+	// consensus keeps denying the new level against the old history
+	*now = now.Add(2 * time.Minute)
+	for i := 0; i < 2; i++ {
+		if !observe(b, Observation{Value: 0.8, Denied: true}) {
+			t.Fatal("resumed before enough stable samples")
+		}
+	}
+	if observe(b, Observation{Value: 0.8, Denied: true}) {
+		t.Fatal("stable but denied aggregates never resume the asset")
+	}
+	if len(resumed) != 1 || resumed[0] != testAsset {
+		t.Fatalf("resume hook saw %v", resumed)
+	}
+
+	observe(b, Observation{Value: 0.8}) // the new baseline
+	observe(b, Observation{Jump: 0.5})
+	if _, err := b.Resume(context.Background(), testAsset, "ops"); err != nil || len(resumed) != 2 {
+		t.Fatalf("operator resume did not call the hook, %v", err)
+	}
+}
@@ -2,13 +2,20 @@ package consensus
 
 import (
 	"context"
+	"database/sql"
+	"math"
 	"time"
 
 	"oracle_engine/internal/aggregator"
+	"oracle_engine/internal/config"
+	"oracle_engine/internal/consensus/breaker"
+	"oracle_engine/internal/consensus/history"
+	"oracle_engine/internal/consensus/multinode"
+	"oracle_engine/internal/consensus/policy"
 	"oracle_engine/internal/consensus/voting/weighted"
-	"oracle_engine/internal/database/timescale"
 	"oracle_engine/internal/logging"
 	"oracle_engine/internal/models"
+	"oracle_engine/internal/registry"
 	"oracle_engine/internal/relayer"
 
 	"github.com/google/uuid"
@@ -25,32 +32,95 @@ import (
 // Else issue relay request to relayer
 type Consensus struct {
 	// out channel
-	relayer    relayer.Relayer
-	db         timescale.TimescaleDB
+	relayer    *relayer.Relayer
+	db         Store
 	issuanceCh chan models.Issuance
+	registry   *registry.Registry
+	history    *history.Window
+	breaker    *breaker.Breaker
+	// multi node mode, issuances are only relayed once peers signed off
+	node    *multinode.Node
+	pending map[string]models.Issuance // latest local issuance per asset
 }
 
-func New(relayer *relayer.Relayer, db *timescale.TimescaleDB) *Consensus {
+// Store persists what consensus judges, timescale.TimescaleDB in the engine
+type Store interface {
+	GetRecentPrices(ctx context.Context, assetID string, limit int) ([]models.UnifiedPrice, error)
+	GetLastIssuance(ctx context.Context, assetID string) (*models.Issuance, error)
+	GetLastApprovedIssuance(ctx context.Context, assetID string) (*models.Issuance, error)
+	SaveIssuance(ctx context.Context, issuance models.Issuance) error
+	SaveIssuanceWithOutbox(ctx context.Context, issuance models.Issuance, entries []models.RelayOutboxEntry) error
+	SaveIssuanceSkip(ctx context.Context, skip models.IssuanceSkip) error
+	SaveReviewInputs(ctx context.Context, issuance models.Issuance) error
+	SaveConsensusReport(ctx context.Context, report models.ConsensusReport, issuanceID string) error
+	SaveReportedIssuance(ctx context.Context, issuance models.Issuance, isNew bool, entries []models.RelayOutboxEntry) error
+	SaveAggregationProvenance(ctx context.Context, priceID string, timestamp time.Time, prov *models.AggregationProvenance) error
+	LinkRawPricesToAggregatedPrice(ctx context.Context, aggregatedPriceID string, timestamp time.Time, rawPriceIDs []string) error
+}
+
+func New(registry *registry.Registry, relayer *relayer.Relayer, db Store, breaker *breaker.Breaker) *Consensus {
+	issuanceCh := make(chan models.Issuance, 10)
+	relayer.StreamTransitions(issuanceCh)
 
-	logging.Logger.Warn("---yy-- home run")
-	return &Consensus{
-		relayer:    *relayer,
-		db:         *db,
-		issuanceCh: make(chan models.Issuance, 10),
+	c := &Consensus{
+		relayer:    relayer,
+		db:         db,
+		issuanceCh: issuanceCh,
+		registry:   registry,
+		history:    history.New(db.GetRecentPrices),
+		breaker:    breaker,
+		pending:    make(map[string]models.Issuance),
 	}
+	// a resumed asset starts over at the level it resumed on
+	breaker.OnResume(c.history.Reset)
+	return c
+}
+
+// History is the window consensus judges new aggregates against
+func (c *Consensus) History() *history.Window {
+	return c.history
+}
+
+// UseMultiNode switches to multi node consensus, the node must be running
+func (c *Consensus) UseMultiNode(node *multinode.Node) {
+	c.node = node
+}
+
+// settingFor returns the settings of an asset, defaults when unknown
+func (c *Consensus) settingFor(assetID string) config.AssetSetting {
+	return c.registry.Setting(assetID)
 }
 
 func (c *Consensus) IssuanceChan() chan models.Issuance {
 	return c.issuanceCh
 }
 
+// relay is an issuance on its way to the relayer with the outbox entries
+// stored with it, nil when they were not
+type relay struct {
+	issuance models.Issuance
+	entries  []models.RelayOutboxEntry
+}
+
 func (c *Consensus) Ambassador(ctx context.Context, incomingCh aggregator.AggrUnitCh) {
-	tmpIssuanceCh := make(chan models.Issuance, 10)
-	go c.relayer.Start(ctx)
+	tmpIssuanceCh := make(chan relay, 10)
+	relayerDone := make(chan struct{})
+	go func() {
+		defer close(relayerDone)
+		c.relayer.Start(ctx)
+	}()
+
+	// nil in single node mode, never fires
+	var reportCh <-chan models.ConsensusReport
+	if c.node != nil {
+		reportCh = c.node.Reports()
+	}
 
 	for {
 		select {
 		case <-ctx.Done():
+			// the relayer's routines and trackers are done when this returns
+			<-relayerDone
 			return
 		case p := <-incomingCh:
 			logging.Logger.Warn(
@@ -62,37 +132,138 @@ func (c *Consensus) Ambassador(ctx context.Context, incomingCh aggregator.AggrUn
 				logging.Logger.Info("Invalid------------")
 				continue
 			}
-			tmpIssuanceCh <- c.processAggrPrice(ctx, p)
-		case issuance := <-tmpIssuanceCh:
-			c.handleIssuance(ctx, issuance)
+			issuance, entries, issue := c.processAggrPrice(ctx, p)
+			if !issue {
+				continue
+			}
+			if c.node != nil && issuance.State == models.Approved {
+				c.observe(ctx, issuance)
+				continue
+			}
+			tmpIssuanceCh <- relay{issuance: issuance, entries: entries}
+		case r := <-tmpIssuanceCh:
+			c.handleIssuance(ctx, r.issuance, r.entries)
+		case report := <-reportCh:
+			c.handleReport(ctx, report)
 		}
 	}
 }
 
-func (c *Consensus) handleIssuance(ctx context.Context, issuance models.Issuance) {
+// handleIssuance streams an issuance and passes it to the relayer, with the
+// outbox entries stored with it
+func (c *Consensus) handleIssuance(ctx context.Context, issuance models.Issuance, entries []models.RelayOutboxEntry) {
 	logging.Logger.Debug("Issuance", zap.Int("num", int(issuance.Price.Number())))
 	c.issuanceCh <- issuance
 	// Pass to relayer
-	if err := c.relayer.AcceptIssuance(&issuance); err != nil {
+	var err error
+	if entries != nil {
+		err = c.relayer.AcceptQueued(&issuance, entries)
+	} else {
+		err = c.relayer.AcceptIssuance(&issuance)
+	}
+	if err != nil {
 		logging.Logger.Panic("Error relaying issuance", zap.Any("err", err))
 		return
 	}
 }
 
+// observe shares an approved issuance with the peers instead of relaying
+// it, it is streamed once its report lands
+func (c *Consensus) observe(ctx context.Context, issuance models.Issuance) {
+	c.pending[issuance.PriceAssetID] = issuance
+	if err := c.node.Observe(ctx, issuance.Price); err != nil {
+		logging.Logger.Warn("Failed to share observation with all peers",
+			zap.String("asset", issuance.PriceAssetID), zap.Error(err))
+	}
+}
+
+// handleReport records the quorum value on the local issuance and relays
+// it when this node leads the round
+func (c *Consensus) handleReport(ctx context.Context, report models.ConsensusReport) {
+	issuance, ok := c.pending[report.AssetID]
+	delete(c.pending, report.AssetID)
+	if !ok && report.IsLeader {
+		// the report stands on the peers' signatures, not on a local issuance
+		issuance = models.Issuance{
+			ID:           uuid.NewString(),
+			State:        models.Approved,
+			PriceAssetID: report.AssetID,
+			CreatedAt:    report.CreatedAt,
+			Price: models.UnifiedPrice{
+				ID:      uuid.NewString(),
+				AssetID: report.AssetID,
+				Expo:    -int8(models.TargetExpo),
+				Source:  "ifa_labs",
+				IsAggr:  true,
+			},
+		}
+	}
+	if err := c.db.SaveConsensusReport(ctx, report, issuance.ID); err != nil {
+		logging.Logger.Error("Error saving consensus report", zap.Error(err))
+	}
+
+	logging.Logger.Info("Consensus report reached quorum",
+		zap.String("asset", report.AssetID),
+		zap.Uint64("round", report.RoundID),
+		zap.Int("signatures", len(report.Observations)),
+		zap.String("leader", report.Leader),
+	)
+	if issuance.ID == "" {
+		return
+	}
+	if c.breaker.Halted(report.AssetID) {
+		logging.Logger.Warn("Not relaying consensus report of halted asset", zap.String("asset", report.AssetID))
+		return
+	}
+
+	// store and relay the value the quorum agreed on, not the local one
+	issuance.Price.Value = report.Value
+	issuance.Price.Timestamp = report.CreatedAt
+	issuance.PriceValue = issuance.Price.Number()
+	issuance.PriceTimestamp = report.CreatedAt
+	issuance.UpdatedAt = report.CreatedAt
+	issuance.Report = &report
+	// the leader stores its relays with the issuance, like a single node
+	var entries []models.RelayOutboxEntry
+	if report.IsLeader {
+		entries = c.relayer.Outbox(&issuance)
+	}
+	if err := c.db.SaveReportedIssuance(ctx, issuance, !ok, entries); err != nil {
+		logging.Logger.Error("Error saving reported issuance", zap.Error(err))
+		entries = nil
+	}
+	if !report.IsLeader {
+		c.issuanceCh <- issuance
+		return
+	}
+	c.handleIssuance(ctx, issuance, entries)
+}
+
+// processAggrPrice judges an aggregate and stores the issuance, with the
+// outbox entries it was stored with
 func (c *Consensus) processAggrPrice(
 	ctx context.Context,
 	price models.UnifiedPrice,
-) models.Issuance {
+) (models.Issuance, []models.RelayOutboxEntry, bool) {
+	if price.Quorum != nil && price.Quorum.Withheld {
+		c.countWithheld(ctx, price)
+		return models.Issuance{}, nil, false
+	}
 	id := uuid.NewString()
-	// TODO: fetch more prices from db (last n prices)
-	lastPrice, err := c.db.GetLastPrice(ctx, price.AssetID)
-	// todo: get last issuance for asset instead
-	if err != nil {
-		// handle error
-		logging.Logger.Error("Couldn't fetch last price for consensus", zap.Any("id", price.AssetID))
-		lastPrice = &price
+	if price.Quorum != nil && price.Quorum.Degraded {
+		logging.Logger.Warn("Consensus on degraded aggregate",
+			zap.String("asset", price.AssetID),
+			zap.Int("distinctSources", price.Quorum.DistinctSources),
+			zap.Int("minSources", price.Quorum.MinSources),
+		)
+	}
+	// judge the aggregate against the recent history of the asset
+	historySize := c.settingFor(price.AssetID).HistoryWindow
+	lastPrices := c.history.Recent(ctx, price.AssetID, historySize)
+	if len(lastPrices) == 0 {
+		lastPrices = []models.UnifiedPrice{price}
 	}
-	lastPrices := []models.UnifiedPrice{*lastPrice}
+	lastPrice := &lastPrices[0]
 	// fetch last issuance
 	lastIssuance, err := c.db.GetLastIssuance(ctx, price.AssetID)
 	if err != nil {
@@ -107,23 +278,154 @@ func (c *Consensus) processAggrPrice(
 			RoundID:       0,
 		}
 	}
-	issuance := weighted.CalculateWeightedAveragePrice(id, price, lastPrices, *lastIssuance)
+	issuance := weighted.CalculateWeightedAveragePrice(id, price, lastPrices, *lastIssuance, c.settingFor(price.AssetID))
+	// rounds count the issuances of an asset
+	issuance.RoundID = lastIssuance.RoundID + 1
 
 	logging.Logger.Debug("Isk", zap.Any("iss", issuance))
 
+	// a halted asset is streamed flagged but neither stored nor published
+	if c.tripsBreaker(ctx, price, issuance) {
+		issuance.Halted = true
+		// the denial that tripped the breaker still awaits review
+		if issuance.State == models.Denied {
+			if err := c.db.SaveIssuance(ctx, issuance); err != nil {
+				logging.Logger.Error("Error saving issuance", zap.Any("err", err))
+			} else {
+				c.awaitReview(ctx, issuance)
+			}
+		}
+		c.issuanceCh <- issuance
+		return issuance, nil, false
+	}
+
+	if issuance.State == models.Approved && !c.passesPushPolicy(ctx, issuance) {
+		return issuance, nil, false
+	}
+
+	// an issuance to relay is stored together with its relays, a crash
+	// after this leaves it to the relayer, not lost. In multi node mode
+	// the leader stores them with the report's value instead.
+	var relays []models.RelayOutboxEntry
+	if c.node == nil {
+		relays = c.relayer.Outbox(&issuance)
+	}
 	// Save the aggregated price in price and link
-	if err := c.db.SaveIssuance(ctx, issuance); err != nil {
+	if err := c.db.SaveIssuanceWithOutbox(ctx, issuance, relays); err != nil {
 		logging.Logger.Error("Error saving issuance", zap.Any("err", err))
-		return issuance
+		return issuance, nil, true
+	}
+	// the window follows what prices stores, denied aggregates stay out
+	if issuance.State == models.Approved {
+		c.history.Push(issuance.Price, historySize)
+	}
+
+	if issuance.State == models.Denied {
+		c.awaitReview(ctx, issuance)
+		return issuance, nil, true
+	}
+
+	// the batch through ids, with why each price did or did not count
+	if price.Provenance != nil {
+		if err := c.db.SaveAggregationProvenance(
+			ctx,
+			issuance.Price.ID,
+			issuance.Price.Timestamp,
+			price.Provenance,
+		); err != nil {
+			logging.Logger.Error("Error saving aggregation provenance", zap.Error(err))
+		}
+	} else {
+		c.db.LinkRawPricesToAggregatedPrice(
+			ctx,
+			issuance.Price.ID,
+			issuance.Price.Timestamp,
+			price.ConnectedPriceIDs,
+		)
 	}
 
-	// the batch through ids
-	c.db.LinkRawPricesToAggregatedPrice(
-		ctx,
-		issuance.Price.ID,
-		issuance.Price.Timestamp,
-		price.ConnectedPriceIDs,
+	return issuance, relays, true
+}
+
+// awaitReview keeps what consensus saw for the operators reviewing a
+// saved denied issuance, denied prices are not stored
+func (c *Consensus) awaitReview(ctx context.Context, issuance models.Issuance) {
+	if err := c.db.SaveReviewInputs(ctx, issuance); err != nil {
+		logging.Logger.Error("Error saving review inputs", zap.Error(err))
+	}
+	logging.Logger.Warn("Issuance denied, awaiting operator review",
+		zap.String("issuance", issuance.ID),
+		zap.String("asset", issuance.PriceAssetID),
+		zap.Float64("value", issuance.PriceValue),
 	)
+}
 
-	return issuance
+// countWithheld feeds an aggregate withheld for missing the source quorum
+// to the breaker, it is neither judged nor issued
+func (c *Consensus) countWithheld(ctx context.Context, price models.UnifiedPrice) {
+	c.breaker.Observe(ctx, breaker.Observation{
+		AssetID:  price.AssetID,
+		Value:    price.Number(),
+		Degraded: true,
+	})
+}
+
+// tripsBreaker feeds the aggregate to the circuit breaker, true while
+// the asset is halted
+func (c *Consensus) tripsBreaker(ctx context.Context, price models.UnifiedPrice, issuance models.Issuance) bool {
+	obs := breaker.Observation{
+		AssetID:  issuance.PriceAssetID,
+		Value:    issuance.PriceValue,
+		Denied:   issuance.State == models.Denied,
+		Degraded: price.Quorum != nil && !price.Quorum.Met,
+	}
+	last, err := c.db.GetLastApprovedIssuance(ctx, issuance.PriceAssetID)
+	if err == nil && last != nil && last.PriceValue != 0 {
+		obs.Jump = math.Abs(issuance.PriceValue-last.PriceValue) / math.Abs(last.PriceValue)
+	}
+	return c.breaker.Observe(ctx, obs)
+}
+
+// passesPushPolicy checks an approved issuance against the deviation and
+// heartbeat policy of its asset, skipped ones are recorded with the reason
+func (c *Consensus) passesPushPolicy(ctx context.Context, issuance models.Issuance) bool {
+	last, err := c.db.GetLastApprovedIssuance(ctx, issuance.PriceAssetID)
+	if err != nil && err != sql.ErrNoRows {
+		// fail open, a missed update is worse than an extra one
+		logging.Logger.Error("Couldn't fetch last approved issuance for push policy",
+			zap.String("asset", issuance.PriceAssetID), zap.Error(err))
+		return true
+	}
+
+	decision := policy.Evaluate(c.settingFor(issuance.PriceAssetID), issuance.PriceValue, last, issuance.CreatedAt)
+	if decision.Issue {
+		logging.Logger.Debug("Push policy issuing",
+			zap.String("asset", issuance.PriceAssetID),
+			zap.String("reason", decision.Reason),
+			zap.Float64("deviation", decision.Deviation),
+		)
+		return true
+	}
+
+	skip := models.IssuanceSkip{
+		AssetID:        issuance.PriceAssetID,
+		Price:          issuance.Price,
+		PriceValue:     issuance.PriceValue,
+		LastIssuanceID: last.ID,
+		LastPriceValue: last.PriceValue,
+		Deviation:      decision.Deviation,
+		SinceLast:      decision.SinceLast.Seconds(),
+		Reason:         decision.Reason,
+		CreatedAt:      time.Now(),
+	}
+	if err := c.db.SaveIssuanceSkip(ctx, skip); err != nil {
+		logging.Logger.Error("Error saving issuance skip", zap.Error(err))
+	}
+	logging.Logger.Debug("Push policy skipped issuance",
+		zap.String("asset", issuance.PriceAssetID),
+		zap.String("reason", decision.Reason),
+		zap.Float64("deviation", decision.Deviation),
+		zap.Duration("sinceLast", decision.SinceLast),
+	)
+	return false
 }
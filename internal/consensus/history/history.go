@@ -0,0 +1,94 @@
+package history
+
+import (
+	"context"
+	"sync"
+
+	"oracle_engine/internal/logging"
+	"oracle_engine/internal/models"
+
+	"go.uber.org/zap"
+)
+
+// Loader fetches the most recent stored prices of an asset, newest first
+type Loader func(ctx context.Context, assetID string, limit int) ([]models.UnifiedPrice, error)
+
+/*
+History:
+Rolling window of recent issued prices per asset for consensus to judge
+a new aggregate against. A window starts from the stored issued prices
+and every price consensus issues after is pushed on top of it, so it
+holds the same values a restart seeds it with. Denied, skipped and
+halted aggregates never enter it. An asset resumed by the breaker
+starts over with an empty window, one an operator approved a denied
+price of starts over at that price.
+*/
+type Window struct {
+	mu      sync.Mutex
+	load    Loader
+	byAsset map[string][]models.UnifiedPrice // newest first
+	seeded  map[string]bool
+}
+
+func New(load Loader) *Window {
+	return &Window{
+		load:    load,
+		byAsset: make(map[string][]models.UnifiedPrice),
+		seeded:  make(map[string]bool),
+	}
+}
+
+// Recent returns up to size prices of an asset, newest first
+func (w *Window) Recent(ctx context.Context, assetID string, size int) []models.UnifiedPrice {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.seeded[assetID] && w.load != nil {
+		stored, err := w.load(ctx, assetID, size)
+		if err != nil {
+			logging.Logger.Error("Couldn't seed price history", zap.String("asset", assetID), zap.Error(err))
+		} else {
+			// aggregates seen before seeding are newer than anything stored
+			w.byAsset[assetID] = append(w.byAsset[assetID], stored...)
+			w.seeded[assetID] = true
+		}
+	}
+
+	window := w.byAsset[assetID]
+	if len(window) > size {
+		window = window[:size]
+	}
+	out := make([]models.UnifiedPrice, len(window))
+	copy(out, window)
+	return out
+}
+
+// Reset drops the window of an asset without seeding it again, the next
+// aggregate is judged against itself and becomes the baseline
+func (w *Window) Reset(assetID string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.byAsset, assetID)
+	w.seeded[assetID] = true
+}
+
+// Rebase starts the window of an asset over at an approved price, the
+// new level an operator acked by approving a denied issuance
+func (w *Window) Rebase(price models.UnifiedPrice) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.byAsset[price.AssetID] = []models.UnifiedPrice{price}
+	w.seeded[price.AssetID] = true
+}
+
+// Push adds an aggregate to the window of its asset, keeping at most size
+func (w *Window) Push(price models.UnifiedPrice, size int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	window := append([]models.UnifiedPrice{price}, w.byAsset[price.AssetID]...)
+	if len(window) > size {
+		window = window[:size]
+	}
+	w.byAsset[price.AssetID] = window
+}
@@ -0,0 +1,49 @@
+package history
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"oracle_engine/internal/config"
+	"oracle_engine/internal/consensus/voting/weighted"
+	"oracle_engine/internal/logging"
+	"oracle_engine/internal/models"
+
+	"go.uber.org/zap"
+)
+
+func TestApprovedLevelShiftPassesTheNextAggregate(t *testing.T) {
+	logging.Logger = zap.NewNop()
+	setting := config.AssetSetting{DevPerc: 0.01}.WithDefaults()
+	now := time.Now()
+	at := func(value float64, ago time.Duration) models.UnifiedPrice {
+		return models.UnifiedPrice{AssetID: "asset", Value: value, Timestamp: now.Add(-ago)}
+	}
+	w := New(func(ctx context.Context, assetID string, limit int) ([]models.UnifiedPrice, error) {
+		stored := make([]models.UnifiedPrice, limit)
+		for i := range stored {
+			stored[i] = at(1, time.Duration(i+1)*time.Minute)
+		}
+		return stored, nil
+	})
+	judge := func(price models.UnifiedPrice) models.IssuanceState {
+		past := w.Recent(context.Background(), "asset", setting.HistoryWindow)
+		return weighted.CalculateWeightedAveragePrice("id", price, past, models.Issuance{}, setting).State
+	}
+
+	// the new level is denied against the old one until an operator approves it
+	if state := judge(at(1.5, 0)); state != models.Denied {
+		t.Fatalf("a 50%% shift was %s", state)
+	}
+	w.Rebase(at(1.5, time.Second))
+	if state := judge(at(1.501, 0)); state != models.Approved {
+		t.Fatalf("the aggregate after the approved shift was %s", state)
+	}
+
+	// a resume starts over with the next aggregate
+	w.Reset("asset")
+	if state := judge(at(2, 0)); state != models.Approved {
+		t.Fatalf("the first aggregate after a reset was %s", state)
+	}
+}
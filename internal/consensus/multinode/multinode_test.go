@@ -0,0 +1,233 @@
+package multinode
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math"
+	"testing"
+	"time"
+
+	"oracle_engine/internal/config"
+	"oracle_engine/internal/logging"
+	"oracle_engine/internal/models"
+	"oracle_engine/internal/utils"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"go.uber.org/zap"
+)
+
+var testAssetID = utils.GenerateIDForAsset("0xUSDT")
+
+// harness runs several nodes in process over a memory network
+type harness struct {
+	t     *testing.T
+	nodes []*Node
+	keys  []*ecdsa.PrivateKey
+	now   time.Time
+}
+
+func newHarness(t *testing.T, count, quorum int, tolerance float64) *harness {
+	t.Helper()
+	logging.Logger = zap.NewNop()
+
+	keys := make([]*ecdsa.PrivateKey, count)
+	peers := make([]config.PeerConfig, count)
+	for i := range keys {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[i] = key
+		peers[i] = config.PeerConfig{Address: crypto.PubkeyToAddress(key.PublicKey).Hex()}
+	}
+
+	cfg := config.MultiNodeConfig{
+		Enabled:      true,
+		Peers:        peers,
+		Quorum:       quorum,
+		Tolerance:    tolerance,
+		RoundSeconds: 60,
+	}
+
+	h := &harness{t: t, keys: keys, now: time.Unix(1_750_000_000, 0)}
+	network := NewMemoryNetwork()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	for _, key := range keys {
+		node, err := New(cfg, key, network.Join(crypto.PubkeyToAddress(key.PublicKey).Hex()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		node.now = func() time.Time { return h.now }
+		h.nodes = append(h.nodes, node)
+		go node.Run(ctx)
+	}
+	return h
+}
+
+func (h *harness) observe(node int, value float64) {
+	h.t.Helper()
+	price := models.UnifiedPrice{AssetID: testAssetID, Value: value * 1e18, Expo: -18, Timestamp: h.now}
+	if err := h.nodes[node].Observe(context.Background(), price); err != nil {
+		h.t.Fatal(err)
+	}
+}
+
+// report waits for the report of a node, nil when none shows up
+func (h *harness) report(node int) *models.ConsensusReport {
+	select {
+	case report := <-h.nodes[node].Reports():
+		return &report
+	case <-time.After(200 * time.Millisecond):
+		return nil
+	}
+}
+
+func TestQuorumProducesSameReportOnEveryNode(t *testing.T) {
+	h := newHarness(t, 4, 3, 0.01)
+	h.observe(0, 1.000)
+	h.observe(1, 1.001)
+	h.observe(2, 0.999)
+
+	leaders := 0
+	for i := range h.nodes[:3] {
+		report := h.report(i)
+		if report == nil {
+			t.Fatalf("node %d produced no report", i)
+		}
+		if len(report.Observations) != 3 {
+			t.Fatalf("node %d report has %d signatures, want 3", i, len(report.Observations))
+		}
+		if math.Abs(report.Value-1e18) > 1e12 {
+			t.Fatalf("node %d reported %v, want the median 1e18", i, report.Value)
+		}
+		for _, obs := range report.Observations {
+			if _, err := Recover(obs); err != nil {
+				t.Fatalf("report carries an invalid signature: %v", err)
+			}
+		}
+		if report.IsLeader {
+			leaders++
+		}
+	}
+	// the silent node never leads, its rounds fail over to a live peer
+	if leaders != 1 {
+		t.Fatalf("%d nodes think they lead the round, want 1", leaders)
+	}
+}
+
+func TestSilentLeaderHandsTheRoundToTheNextPeer(t *testing.T) {
+	h := newHarness(t, 3, 2, 0.01)
+	leader := h.nodes[0].Leader(h.nodes[0].roundFor(h.now))
+
+	var live []int
+	for i, node := range h.nodes {
+		if node.Address() != leader {
+			live = append(live, i)
+		}
+	}
+	for _, i := range live {
+		h.observe(i, 1.0)
+	}
+
+	var relayedBy string
+	leaders := 0
+	for _, i := range live {
+		report := h.report(i)
+		if report == nil {
+			t.Fatalf("node %d produced no report", i)
+		}
+		if report.Leader == leader.Hex() {
+			t.Fatal("the silent leader still leads the round")
+		}
+		if relayedBy != "" && report.Leader != relayedBy {
+			t.Fatalf("nodes disagree on the leader, %s and %s", relayedBy, report.Leader)
+		}
+		relayedBy = report.Leader
+		if report.IsLeader {
+			leaders++
+		}
+	}
+	if leaders != 1 {
+		t.Fatalf("%d nodes took over the round, want 1", leaders)
+	}
+}
+
+func TestBelowQuorumProducesNoReport(t *testing.T) {
+	h := newHarness(t, 4, 3, 0.01)
+	h.observe(0, 1.000)
+	h.observe(1, 1.001)
+
+	for i := range h.nodes {
+		if report := h.report(i); report != nil {
+			t.Fatalf("node %d reported with only two observations", i)
+		}
+	}
+}
+
+func TestCompromisedNodeCannotMoveTheReport(t *testing.T) {
+	h := newHarness(t, 4, 3, 0.01)
+	h.observe(3, 5.0) // compromised
+	h.observe(0, 1.000)
+	h.observe(1, 1.002)
+	h.observe(2, 0.998)
+
+	report := h.report(0)
+	if report == nil {
+		t.Fatal("honest quorum produced no report")
+	}
+	for _, obs := range report.Observations {
+		if obs.Node == h.nodes[3].Address().Hex() {
+			t.Fatal("outlying observation was counted in the report")
+		}
+	}
+	if math.Abs(report.Value-1e18) > 1e15 {
+		t.Fatalf("report value %v pulled away from the honest median", report.Value)
+	}
+}
+
+func TestDisagreeingNodesProduceNoReport(t *testing.T) {
+	h := newHarness(t, 3, 3, 0.01)
+	h.observe(0, 1.0)
+	h.observe(1, 1.0)
+	h.observe(2, 1.5)
+
+	if report := h.report(0); report != nil {
+		t.Fatal("reported although only two of three agreed")
+	}
+}
+
+func TestRejectsObservationsOutsideTheAllowlist(t *testing.T) {
+	h := newHarness(t, 3, 2, 0.01)
+	outsider, _ := crypto.GenerateKey()
+
+	obs := models.SignedObservation{AssetID: testAssetID, RoundID: h.nodes[0].roundFor(h.now), Value: "1000000000000000000", Timestamp: h.now.Unix()}
+	if err := Sign(&obs, outsider); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.nodes[0].verify(obs); err == nil {
+		t.Fatal("accepted an observation from a node outside the allowlist")
+	}
+
+	// a peer's observation with a tampered value no longer recovers to the peer
+	if err := Sign(&obs, h.keys[1]); err != nil {
+		t.Fatal(err)
+	}
+	obs.Value = "2000000000000000000"
+	if _, err := h.nodes[0].verify(obs); err == nil {
+		t.Fatal("accepted a tampered observation")
+	}
+}
+
+func TestRejectsReplayedRounds(t *testing.T) {
+	h := newHarness(t, 3, 2, 0.01)
+
+	obs := models.SignedObservation{AssetID: testAssetID, RoundID: h.nodes[0].roundFor(h.now) - 10, Value: "1000000000000000000", Timestamp: h.now.Unix()}
+	if err := Sign(&obs, h.keys[1]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.nodes[0].verify(obs); err == nil {
+		t.Fatal("accepted an observation of an old round")
+	}
+}
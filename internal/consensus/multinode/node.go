@@ -0,0 +1,346 @@
+package multinode
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"oracle_engine/internal/config"
+	"oracle_engine/internal/logging"
+	"oracle_engine/internal/models"
+	"oracle_engine/internal/utils"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"go.uber.org/zap"
+)
+
+/*
+Multinode:
+Every node signs its own aggregate for a round and sends it to the
+allowlisted peers. A round is the window of round_seconds the
+observation was made in. Once a quorum of signed observations sits
+within tolerance of their median, every node produces the same report.
+Only the round leader, picked by round id over the sorted allowlist,
+relays it, so one compromised node can neither publish on its own
+nor drag the reported value outside the honest range.
+
+A leader no observation was heard from for leaderLiveness round lengths
+is taken for down, and the next live peer in allowlist order relays its
+rounds. A peer that comes back mid round may relay the round as well,
+that costs a tx but publishes the same value.
+
+The report is relayed as its quorum value. The verifier contract has no
+entrypoint taking the node signatures yet, so they are kept in
+consensus_reports and checking them on chain is left to a contract
+upgrade.
+*/
+
+// rounds older than this many round lengths are dropped
+const roundRetention = 5
+
+// peers silent for this many round lengths lose the lead
+const leaderLiveness = 2
+
+type Node struct {
+	key       *ecdsa.PrivateKey
+	address   common.Address
+	transport Transport
+	peers     []common.Address // sorted, this node included
+	allowed   map[common.Address]struct{}
+	quorum    int
+	tolerance float64
+	roundLen  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	rounds   map[string]*round
+	lastSeen map[common.Address]time.Time // last observation per peer
+	reports  chan models.ConsensusReport
+}
+
+type round struct {
+	assetID      string
+	roundID      uint64
+	observations map[common.Address]models.SignedObservation
+	reported     bool
+}
+
+// New creates a node signing with key, the key's address must be allowlisted
+func New(cfg config.MultiNodeConfig, key *ecdsa.PrivateKey, transport Transport) (*Node, error) {
+	address := crypto.PubkeyToAddress(key.PublicKey)
+
+	allowed := make(map[common.Address]struct{}, len(cfg.Peers))
+	peers := make([]common.Address, 0, len(cfg.Peers))
+	for _, peer := range cfg.Peers {
+		if !common.IsHexAddress(peer.Address) {
+			return nil, fmt.Errorf("invalid peer address %q", peer.Address)
+		}
+		addr := common.HexToAddress(peer.Address)
+		if _, ok := allowed[addr]; ok {
+			continue
+		}
+		allowed[addr] = struct{}{}
+		peers = append(peers, addr)
+	}
+	if _, ok := allowed[address]; !ok {
+		return nil, fmt.Errorf("node %s is not in the peer allowlist", address.Hex())
+	}
+	sort.Slice(peers, func(i, j int) bool {
+		return strings.ToLower(peers[i].Hex()) < strings.ToLower(peers[j].Hex())
+	})
+
+	if cfg.Quorum <= 0 || cfg.Quorum > len(peers) {
+		return nil, fmt.Errorf("quorum %d outside 1..%d peers", cfg.Quorum, len(peers))
+	}
+	// a quorum that is not a majority lets two disjoint sets report
+	if cfg.Quorum*2 <= len(peers) {
+		logging.Logger.Warn("Multinode quorum is not a majority of peers",
+			zap.Int("quorum", cfg.Quorum), zap.Int("peers", len(peers)))
+	}
+
+	roundLen := time.Duration(cfg.RoundSeconds) * time.Second
+	if roundLen <= 0 {
+		roundLen = time.Minute
+	}
+
+	return &Node{
+		key:       key,
+		address:   address,
+		transport: transport,
+		peers:     peers,
+		allowed:   allowed,
+		quorum:    cfg.Quorum,
+		tolerance: cfg.Tolerance,
+		roundLen:  roundLen,
+		now:       time.Now,
+		rounds:    make(map[string]*round),
+		lastSeen:  make(map[common.Address]time.Time),
+		reports:   make(chan models.ConsensusReport, 32),
+	}, nil
+}
+
+// NewFromConfig loads the node key from the config
+func NewFromConfig(cfg config.MultiNodeConfig, transport func(self string) Transport) (*Node, error) {
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(cfg.NodeKey, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid multinode node key: %w", err)
+	}
+	return New(cfg, key, transport(crypto.PubkeyToAddress(key.PublicKey).Hex()))
+}
+
+func (n *Node) Address() common.Address {
+	return n.address
+}
+
+// Reports emits every report this node saw reach quorum
+func (n *Node) Reports() <-chan models.ConsensusReport {
+	return n.reports
+}
+
+// Leader is the node that relays the reports of a round
+func (n *Node) Leader(roundID uint64) common.Address {
+	return n.peers[roundID%uint64(len(n.peers))]
+}
+
+// liveLeader is the first peer from the round's leader on that was heard
+// from lately, the caller holds mu
+func (n *Node) liveLeader(roundID uint64) common.Address {
+	now := n.now()
+	for i := range n.peers {
+		peer := n.peers[(roundID+uint64(i))%uint64(len(n.peers))]
+		if peer == n.address || now.Sub(n.lastSeen[peer]) <= leaderLiveness*n.roundLen {
+			return peer
+		}
+	}
+	return n.Leader(roundID)
+}
+
+func (n *Node) roundFor(at time.Time) uint64 {
+	return uint64(at.Unix()) / uint64(n.roundLen.Seconds())
+}
+
+// Observe signs this node's aggregate for the current round and shares it
+func (n *Node) Observe(ctx context.Context, price models.UnifiedPrice) error {
+	now := n.now()
+	obs := models.SignedObservation{
+		AssetID:   price.AssetID,
+		RoundID:   n.roundFor(now),
+		Value:     utils.Float64ToBigInt(price.Value).String(),
+		Timestamp: price.Timestamp.Unix(),
+	}
+	if err := Sign(&obs, n.key); err != nil {
+		return err
+	}
+
+	n.accept(obs, n.address)
+	return n.transport.Broadcast(ctx, obs)
+}
+
+// Run verifies and collects the observations of the peers
+func (n *Node) Run(ctx context.Context) {
+	prune := time.NewTicker(n.roundLen)
+	defer prune.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case obs := <-n.transport.Receive():
+			signer, err := n.verify(obs)
+			if err != nil {
+				logging.Logger.Warn("Rejected peer observation",
+					zap.String("node", obs.Node),
+					zap.String("asset", obs.AssetID),
+					zap.Error(err),
+				)
+				continue
+			}
+			n.accept(obs, signer)
+		case <-prune.C:
+			n.prune()
+		}
+	}
+}
+
+func (n *Node) verify(obs models.SignedObservation) (common.Address, error) {
+	signer, err := Recover(obs)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if _, ok := n.allowed[signer]; !ok {
+		return common.Address{}, fmt.Errorf("signer %s is not allowlisted", signer.Hex())
+	}
+	// only the previous, current and next round, no replays of old rounds
+	current := n.roundFor(n.now())
+	if obs.RoundID+1 < current || obs.RoundID > current+1 {
+		return common.Address{}, fmt.Errorf("round %d outside current round %d", obs.RoundID, current)
+	}
+	return signer, nil
+}
+
+func (n *Node) accept(obs models.SignedObservation, signer common.Address) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	k := fmt.Sprintf("%s|%d", obs.AssetID, obs.RoundID)
+	r, ok := n.rounds[k]
+	if !ok {
+		r = &round{
+			assetID:      obs.AssetID,
+			roundID:      obs.RoundID,
+			observations: make(map[common.Address]models.SignedObservation),
+		}
+		n.rounds[k] = r
+	}
+	if seen := n.now(); seen.After(n.lastSeen[signer]) {
+		n.lastSeen[signer] = seen
+	}
+	if r.reported {
+		return
+	}
+	// one observation per node and round, the latest wins
+	if existing, ok := r.observations[signer]; ok && existing.Timestamp > obs.Timestamp {
+		return
+	}
+	r.observations[signer] = obs
+
+	report, ok := n.tryReport(r)
+	if !ok {
+		return
+	}
+	r.reported = true
+
+	select {
+	case n.reports <- report:
+	default:
+		logging.Logger.Warn("Dropping consensus report, consumer too slow",
+			zap.String("asset", report.AssetID), zap.Uint64("round", report.RoundID))
+	}
+}
+
+// tryReport builds a report once a quorum agrees within tolerance of the median
+func (n *Node) tryReport(r *round) (models.ConsensusReport, bool) {
+	if len(r.observations) < n.quorum {
+		return models.ConsensusReport{}, false
+	}
+
+	values := make([]float64, 0, len(r.observations))
+	for _, obs := range r.observations {
+		values = append(values, observationValue(obs))
+	}
+	median := medianOf(values)
+	if median == 0 {
+		return models.ConsensusReport{}, false
+	}
+
+	agreeing := make([]models.SignedObservation, 0, len(r.observations))
+	agreeingValues := make([]float64, 0, len(r.observations))
+	for _, obs := range r.observations {
+		value := observationValue(obs)
+		if math.Abs(value-median)/math.Abs(median) <= n.tolerance {
+			agreeing = append(agreeing, obs)
+			agreeingValues = append(agreeingValues, value)
+		}
+	}
+	if len(agreeing) < n.quorum {
+		return models.ConsensusReport{}, false
+	}
+	sort.Slice(agreeing, func(i, j int) bool {
+		return strings.ToLower(agreeing[i].Node) < strings.ToLower(agreeing[j].Node)
+	})
+
+	leader := n.liveLeader(r.roundID)
+	return models.ConsensusReport{
+		AssetID:      r.assetID,
+		RoundID:      r.roundID,
+		Value:        medianOf(agreeingValues),
+		Leader:       leader.Hex(),
+		IsLeader:     leader == n.address,
+		Observations: agreeing,
+		CreatedAt:    n.now(),
+	}, true
+}
+
+func (n *Node) prune() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	current := n.roundFor(n.now())
+	for k, r := range n.rounds {
+		if r.roundID+roundRetention < current {
+			delete(n.rounds, k)
+		}
+	}
+}
+
+func observationValue(obs models.SignedObservation) float64 {
+	value, ok := new(big.Float).SetString(obs.Value)
+	if !ok {
+		return 0
+	}
+	f, _ := value.Float64()
+	return f
+}
+
+// medianOf averages the two middle values of an even count, so every
+// node gets the same value from the same set of observations
+func medianOf(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
@@ -0,0 +1,76 @@
+package multinode
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"oracle_engine/internal/models"
+	"oracle_engine/internal/utils"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Digest is what a node signs for an observation, the abi encoding of
+// (bytes32 assetId, uint64 roundId, int256 value, uint64 timestamp)
+// so a contract can rebuild it, wrapped as an EIP-191 personal message
+func Digest(obs models.SignedObservation) ([]byte, error) {
+	value, ok := new(big.Int).SetString(obs.Value, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid observation value %q", obs.Value)
+	}
+	asset := utils.HexToBytes32(obs.AssetID)
+
+	encoded := make([]byte, 0, 128)
+	encoded = append(encoded, asset[:]...)
+	encoded = append(encoded, common.LeftPadBytes(new(big.Int).SetUint64(obs.RoundID).Bytes(), 32)...)
+	encoded = append(encoded, math.U256Bytes(new(big.Int).Set(value))...)
+	encoded = append(encoded, common.LeftPadBytes(big.NewInt(obs.Timestamp).Bytes(), 32)...)
+
+	return accounts.TextHash(crypto.Keccak256(encoded)), nil
+}
+
+// Sign fills the node and signature of an observation
+func Sign(obs *models.SignedObservation, key *ecdsa.PrivateKey) error {
+	obs.Node = crypto.PubkeyToAddress(key.PublicKey).Hex()
+	digest, err := Digest(*obs)
+	if err != nil {
+		return err
+	}
+	sig, err := crypto.Sign(digest, key)
+	if err != nil {
+		return err
+	}
+	obs.Signature = hexutil.Encode(sig)
+	return nil
+}
+
+// Recover returns the address that signed an observation and checks it
+// is the node the observation claims to come from
+func Recover(obs models.SignedObservation) (common.Address, error) {
+	sig, err := hexutil.Decode(obs.Signature)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("invalid signature encoding: %w", err)
+	}
+	if len(sig) != crypto.SignatureLength {
+		return common.Address{}, fmt.Errorf("invalid signature length %d", len(sig))
+	}
+	digest, err := Digest(obs)
+	if err != nil {
+		return common.Address{}, err
+	}
+	pub, err := crypto.SigToPub(digest, sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+	signer := crypto.PubkeyToAddress(*pub)
+	if !strings.EqualFold(signer.Hex(), obs.Node) {
+		return common.Address{}, fmt.Errorf("observation from %s signed by %s", obs.Node, signer.Hex())
+	}
+	return signer, nil
+}
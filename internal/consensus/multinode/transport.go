@@ -0,0 +1,199 @@
+package multinode
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"oracle_engine/internal/config"
+	"oracle_engine/internal/logging"
+	"oracle_engine/internal/models"
+
+	"go.uber.org/zap"
+)
+
+// Transport moves signed observations between nodes. It only carries
+// them, every observation is still verified by the receiving node.
+type Transport interface {
+	Broadcast(ctx context.Context, obs models.SignedObservation) error
+	Receive() <-chan models.SignedObservation
+}
+
+/*
+In memory transport:
+For running several nodes inside one process, eg the test harness.
+*/
+
+type MemoryNetwork struct {
+	mu    sync.RWMutex
+	nodes map[string]*MemoryTransport
+}
+
+func NewMemoryNetwork() *MemoryNetwork {
+	return &MemoryNetwork{nodes: make(map[string]*MemoryTransport)}
+}
+
+// Join connects a node to the network under its signer address
+func (n *MemoryNetwork) Join(address string) *MemoryTransport {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	t := &MemoryTransport{
+		address: strings.ToLower(address),
+		network: n,
+		inbox:   make(chan models.SignedObservation, 64),
+	}
+	n.nodes[t.address] = t
+	return t
+}
+
+type MemoryTransport struct {
+	address string
+	network *MemoryNetwork
+	inbox   chan models.SignedObservation
+}
+
+func (t *MemoryTransport) Broadcast(ctx context.Context, obs models.SignedObservation) error {
+	t.network.mu.RLock()
+	defer t.network.mu.RUnlock()
+
+	for address, peer := range t.network.nodes {
+		if address == t.address {
+			continue
+		}
+		select {
+		case peer.inbox <- obs:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (t *MemoryTransport) Receive() <-chan models.SignedObservation {
+	return t.inbox
+}
+
+/*
+HTTP transport:
+Observations are POSTed as json to every peer endpoint. Peers present
+the shared bearer token, the observation itself is signed by the node.
+*/
+
+const ObservationPath = "/multinode/observations"
+
+type HTTPTransport struct {
+	self   string
+	token  string
+	peers  []config.PeerConfig
+	client *http.Client
+	inbox  chan models.SignedObservation
+	server *http.Server
+}
+
+func NewHTTPTransport(cfg config.MultiNodeConfig, self string) *HTTPTransport {
+	t := &HTTPTransport{
+		self:   strings.ToLower(self),
+		token:  cfg.AuthToken,
+		peers:  cfg.Peers,
+		client: &http.Client{Timeout: 3 * time.Second},
+		inbox:  make(chan models.SignedObservation, 256),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(ObservationPath, t.handleObservation)
+	t.server = &http.Server{
+		Addr:              cfg.ListenAddr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	return t
+}
+
+// Start serves the peer endpoint until ctx is done
+func (t *HTTPTransport) Start(ctx context.Context) error {
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		t.server.Shutdown(shutdownCtx)
+	}()
+
+	logging.Logger.Info("Multinode peer endpoint listening", zap.String("addr", t.server.Addr))
+	if err := t.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (t *HTTPTransport) Broadcast(ctx context.Context, obs models.SignedObservation) error {
+	body, err := json.Marshal(obs)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, peer := range t.peers {
+		if peer.URL == "" || strings.EqualFold(peer.Address, t.self) {
+			continue
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(peer.URL, "/")+ObservationPath, bytes.NewReader(body))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if t.token != "" {
+			req.Header.Set("Authorization", "Bearer "+t.token)
+		}
+
+		resp, err := t.client.Do(req)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("peer %s: %w", peer.Address, err))
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusAccepted {
+			errs = append(errs, fmt.Errorf("peer %s: status %d", peer.Address, resp.StatusCode))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (t *HTTPTransport) Receive() <-chan models.SignedObservation {
+	return t.inbox
+}
+
+func (t *HTTPTransport) handleObservation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if t.token != "" {
+		presented := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(presented), []byte(t.token)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	var obs models.SignedObservation
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&obs); err != nil {
+		http.Error(w, "invalid observation", http.StatusBadRequest)
+		return
+	}
+
+	select {
+	case t.inbox <- obs:
+		w.WriteHeader(http.StatusAccepted)
+	default:
+		http.Error(w, "busy", http.StatusServiceUnavailable)
+	}
+}
@@ -0,0 +1,61 @@
+package policy
+
+import (
+	"math"
+	"time"
+
+	"oracle_engine/internal/config"
+	"oracle_engine/internal/models"
+)
+
+/*
+Policy:
+Push policy for issuances, per asset.
+A new price is only issued when it moved more than the deviation
+threshold away from the last approved issuance, or when the heartbeat
+interval passed since that issuance. Everything else is skipped.
+*/
+
+const (
+	ReasonFirstIssuance = "first_issuance"
+	ReasonDeviation     = "deviation"
+	ReasonHeartbeat     = "heartbeat"
+	// skips
+	ReasonBelowThreshold = "below_deviation_threshold"
+)
+
+type Decision struct {
+	Issue     bool
+	Reason    string
+	Deviation float64       // relative move from the last issuance
+	SinceLast time.Duration // time since the last issuance
+}
+
+// Evaluate decides whether value should be issued given the last
+// approved issuance of the asset, last is nil when there is none
+func Evaluate(setting config.AssetSetting, value float64, last *models.Issuance, now time.Time) Decision {
+	if last == nil || last.PriceValue == 0 {
+		return Decision{Issue: true, Reason: ReasonFirstIssuance}
+	}
+
+	decision := Decision{
+		Deviation: math.Abs(value-last.PriceValue) / math.Abs(last.PriceValue),
+		SinceLast: now.Sub(last.CreatedAt),
+	}
+
+	threshold := 0.0
+	if setting.DeviationThreshold != nil {
+		threshold = *setting.DeviationThreshold
+	}
+	switch {
+	case decision.Deviation > threshold:
+		decision.Issue = true
+		decision.Reason = ReasonDeviation
+	case setting.Heartbeat > 0 && decision.SinceLast >= time.Duration(setting.Heartbeat)*time.Second:
+		decision.Issue = true
+		decision.Reason = ReasonHeartbeat
+	default:
+		decision.Reason = ReasonBelowThreshold
+	}
+	return decision
+}
@@ -0,0 +1,154 @@
+package weighted
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"oracle_engine/internal/config"
+	"oracle_engine/internal/models"
+)
+
+// Band is what a new price is judged against, built from recent history
+type Band struct {
+	Mode       string
+	Center     float64 // smoothed value including the new price, what gets issued
+	Reference  float64 // smoothed value of the history alone
+	Threshold  float64 // allowed distance from the reference
+	Volatility float64 // stddev of the relative moves inside the history
+	Samples    int
+}
+
+func (b Band) Lower() float64 { return b.Reference - b.Threshold }
+func (b Band) Upper() float64 { return b.Reference + b.Threshold }
+
+// Deviated reports whether value falls outside the band
+func (b Band) Deviated(value float64) bool {
+	return b.Threshold > 0 && math.Abs(value-b.Reference) > b.Threshold
+}
+
+// ComputeBand builds the band of the asset's history mode, past is
+// sorted newest first in place
+func ComputeBand(curr models.UnifiedPrice, past []models.UnifiedPrice, setting config.AssetSetting) Band {
+	sort.Slice(past, func(i, j int) bool {
+		return past[i].Timestamp.After(past[j].Timestamp)
+	})
+	if setting.HistoryWindow > 0 && len(past) > setting.HistoryWindow {
+		past = past[:setting.HistoryWindow]
+	}
+
+	switch strings.ToLower(setting.HistoryMode) {
+	case config.HistoryModeEWMA:
+		return ewmaBand(curr, past, setting)
+	case config.HistoryModeVolatility:
+		return volatilityBand(curr, past, setting)
+	default:
+		return linearBand(curr, past, setting)
+	}
+}
+
+// linearBand is the original check, the new price weighted highest and
+// the history descending, compared to the plain mean of all of them
+func linearBand(curr models.UnifiedPrice, past []models.UnifiedPrice, setting config.AssetSetting) Band {
+	totalWeight := 0.0
+	weightedSum := 0.0
+
+	// Base weight for currPrice
+	currWeight := float64(len(past) + 1) // Highest weight
+	totalWeight += currWeight
+	weightedSum += curr.Value * currWeight
+
+	// Assign descending weights to past prices
+	for i, price := range past {
+		weight := float64(len(past) - i)
+		totalWeight += weight
+		weightedSum += price.Value * weight
+	}
+
+	// Check for deviation
+	prices := append(append([]models.UnifiedPrice{}, past...), curr)
+	mean := CalculatePriceMean(prices)
+
+	return Band{
+		Mode:      config.HistoryModeLinear,
+		Center:    weightedSum / totalWeight,
+		Reference: mean,
+		Threshold: float64(setting.DevPerc) * mean,
+		Samples:   len(past),
+	}
+}
+
+// ewmaBand compares the new price to the ewma of the history
+func ewmaBand(curr models.UnifiedPrice, past []models.UnifiedPrice, setting config.AssetSetting) Band {
+	alpha := setting.EWMAAlpha
+	if alpha <= 0 || alpha > 1 {
+		alpha = config.DefaultAssetSetting.EWMAAlpha
+	}
+	if len(past) == 0 {
+		return Band{Mode: config.HistoryModeEWMA, Center: curr.Value, Reference: curr.Value}
+	}
+
+	reference := ewma(past, alpha)
+	return Band{
+		Mode:      config.HistoryModeEWMA,
+		Center:    reference + alpha*(curr.Value-reference),
+		Reference: reference,
+		Threshold: float64(setting.DevPerc) * reference,
+		Samples:   len(past),
+	}
+}
+
+// volatilityBand widens the ewma band with the realized volatility of the
+// history, never narrower than the asset's deviation percentage
+func volatilityBand(curr models.UnifiedPrice, past []models.UnifiedPrice, setting config.AssetSetting) Band {
+	band := ewmaBand(curr, past, setting)
+	band.Mode = config.HistoryModeVolatility
+	if len(past) < 3 {
+		return band
+	}
+
+	k := setting.VolatilityBand
+	if k <= 0 {
+		k = config.DefaultAssetSetting.VolatilityBand
+	}
+	band.Volatility = realizedVolatility(past)
+	band.Threshold = math.Max(float64(setting.DevPerc), k*band.Volatility) * band.Reference
+	return band
+}
+
+// ewma of a newest first window, seeded with its oldest price
+func ewma(newestFirst []models.UnifiedPrice, alpha float64) float64 {
+	value := newestFirst[len(newestFirst)-1].Value
+	for i := len(newestFirst) - 2; i >= 0; i-- {
+		value += alpha * (newestFirst[i].Value - value)
+	}
+	return value
+}
+
+// realizedVolatility is the stddev of the relative moves between
+// consecutive prices of a newest first window
+func realizedVolatility(newestFirst []models.UnifiedPrice) float64 {
+	returns := make([]float64, 0, len(newestFirst)-1)
+	for i := len(newestFirst) - 1; i > 0; i-- {
+		prev := newestFirst[i].Value
+		if prev == 0 {
+			continue
+		}
+		returns = append(returns, (newestFirst[i-1].Value-prev)/prev)
+	}
+	if len(returns) < 2 {
+		return 0
+	}
+
+	mean := 0.0
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+
+	variance := 0.0
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	return math.Sqrt(variance / float64(len(returns)-1))
+}
@@ -0,0 +1,70 @@
+package weighted
+
+import (
+	"testing"
+	"time"
+
+	"oracle_engine/internal/config"
+	"oracle_engine/internal/models"
+)
+
+// history builds a newest first window from oldest first values
+func history(values ...float64) []models.UnifiedPrice {
+	start := time.Unix(1_750_000_000, 0)
+	out := make([]models.UnifiedPrice, len(values))
+	for i, v := range values {
+		out[len(values)-1-i] = models.UnifiedPrice{Value: v, Timestamp: start.Add(time.Duration(i) * time.Minute)}
+	}
+	return out
+}
+
+func price(v float64) models.UnifiedPrice {
+	return models.UnifiedPrice{Value: v, Timestamp: time.Unix(1_750_000_000, 0).Add(time.Hour)}
+}
+
+func TestVolatilityBandAllowsNormalMovesOfVolatileAssets(t *testing.T) {
+	setting := config.DefaultAssetSetting
+	setting.DevPerc = 0.02
+
+	// swings of around 5% between aggregates
+	past := history(100, 105, 99, 104, 98, 103, 97, 102)
+	move := price(108) // ~5% off the smoothed history
+
+	setting.HistoryMode = config.HistoryModeEWMA
+	if band := ComputeBand(move, append([]models.UnifiedPrice{}, past...), setting); !band.Deviated(move.Value) {
+		t.Fatalf("ewma band [%v, %v] should reject %v", band.Lower(), band.Upper(), move.Value)
+	}
+
+	setting.HistoryMode = config.HistoryModeVolatility
+	band := ComputeBand(move, append([]models.UnifiedPrice{}, past...), setting)
+	if band.Deviated(move.Value) {
+		t.Fatalf("volatility band [%v, %v] rejected a normal move to %v", band.Lower(), band.Upper(), move.Value)
+	}
+	if !band.Deviated(150) {
+		t.Fatalf("volatility band [%v, %v] accepted an outrageous move", band.Lower(), band.Upper())
+	}
+}
+
+func TestVolatilityBandFloorsAtDevPercForFlatHistory(t *testing.T) {
+	setting := config.DefaultAssetSetting
+	setting.DevPerc = 0.01
+	setting.HistoryMode = config.HistoryModeVolatility
+
+	band := ComputeBand(price(1.005), history(1, 1, 1, 1, 1, 1), setting)
+	if band.Deviated(1.005) {
+		t.Fatalf("flat history band [%v, %v] rejected a move inside dev_perc", band.Lower(), band.Upper())
+	}
+	if !band.Deviated(1.02) {
+		t.Fatalf("flat history band [%v, %v] accepted a move beyond dev_perc", band.Lower(), band.Upper())
+	}
+}
+
+func TestHistoryWindowLimitsSamples(t *testing.T) {
+	setting := config.DefaultAssetSetting
+	setting.HistoryWindow = 3
+
+	band := ComputeBand(price(1), history(1, 1, 1, 1, 1, 1), setting)
+	if band.Samples != 3 {
+		t.Fatalf("band used %d samples, want 3", band.Samples)
+	}
+}
@@ -1,8 +1,6 @@
 package weighted
 
 import (
-	"math"
-	"sort"
 	"time"
 
 	"oracle_engine/internal/config"
@@ -12,78 +10,40 @@ import (
 	"go.uber.org/zap"
 )
 
+// CalculateWeightedAveragePrice judges an aggregate against the history
+// band of its asset's setting
 func CalculateWeightedAveragePrice(
 	id string,
 	currPrice models.UnifiedPrice,
 	pastXPrices []models.UnifiedPrice,
 	lastIssuance models.Issuance,
+	setting config.AssetSetting,
 ) models.Issuance {
-	cfg := config.Load()
-	// fetch settings from config for asset
-	assetsSettings := cfg.Assets
-	// this asset id
-	assetId := currPrice.AssetID
-	var assetSetting *config.AssetSetting
-	for _, asset := range assetsSettings {
-		if asset.InternalAssetIdentity == assetId {
-			assetSetting = &asset.Settings
-			logging.Logger.Info("Found asset setting",
-				zap.Any("asset", asset.Name),
-				zap.Any("setting", assetSetting),
-			)
-			break
-		}
-	}
-	// If no asset setting found, use default
-	if assetSetting == nil {
-		assetSetting = &config.DefaultAssetSetting
-	}
-
-	// Sort past prices by Timestamp descending (latest first)
-	sort.Slice(pastXPrices, func(i, j int) bool {
-		return pastXPrices[i].Timestamp.After(pastXPrices[j].Timestamp)
-	})
-
-	// Assign weights: highest for currPrice, descending for pastXPrices
-	totalWeight := 0.0
-	weightedSum := 0.0
-
-	// Base weight for currPrice
-	currWeight := float64(len(pastXPrices) + 1) // Highest weight
-	totalWeight += currWeight
-	weightedSum += currPrice.Value * currWeight
-
-	// Assign descending weights to past prices
-	for i, price := range pastXPrices {
-		weight := float64(len(pastXPrices) - i)
-		totalWeight += weight
-		weightedSum += price.Value * weight
-	}
-
-	// Compute weighted average
-	weightedAvg := weightedSum / totalWeight
-	isDeviated := false
-
-	// Check for deviation
-	prices := append(pastXPrices, currPrice)
-	mean := CalculatePriceMean(prices)
-
-	// deviationThreshold := 0.4 * mean
-	deviationThreshold := float64(assetSetting.DevPerc) * mean
+	assetSetting := setting.WithDefaults()
+	band := ComputeBand(currPrice, pastXPrices, assetSetting)
 	var state models.IssuanceState
+	checked := currPrice.Value
 
 	// also, allow if the last update timeout is more than 10s
 	lastUpdate := time.Since(currPrice.Timestamp)
 	deviationTTL := time.Duration(assetSetting.TTL) * time.Second
-	if lastUpdate > deviationTTL || deviationThreshold == 0 {
+	if lastUpdate > deviationTTL || band.Threshold == 0 {
 		state = models.Approved
 	} else {
-		isDeviated = math.Abs(weightedAvg-mean) > deviationThreshold
+		// linear keeps the original check of the smoothed value against the mean
+		if band.Mode == config.HistoryModeLinear {
+			checked = band.Center
+		}
+		isDeviated := band.Deviated(checked)
 		logging.Logger.Info("Deviation check",
-			zap.Any("weightedAvg", weightedAvg),
-			zap.Any("mean", mean),
-			zap.Any("deviationThreshold", deviationThreshold),
-			zap.Any("isDeviated", isDeviated),
+			zap.String("mode", band.Mode),
+			zap.Float64("checked", checked),
+			zap.Float64("reference", band.Reference),
+			zap.Float64("lower", band.Lower()),
+			zap.Float64("upper", band.Upper()),
+			zap.Float64("volatility", band.Volatility),
+			zap.Int("samples", band.Samples),
+			zap.Bool("isDeviated", isDeviated),
 		)
 		if !isDeviated {
 			state = models.Approved
@@ -93,7 +53,7 @@ func CalculateWeightedAveragePrice(
 	}
 
 	modPrice := currPrice
-	modPrice.Value = weightedAvg
+	modPrice.Value = band.Center
 	logging.Logger.Info("issuance value",
 		zap.Any("usual str", modPrice.ID),
 		zap.Any("val", modPrice.Value),
@@ -116,6 +76,15 @@ func CalculateWeightedAveragePrice(
 		UpdatedAt:      modPrice.Timestamp,
 		PriceSource:    modPrice.Source,
 		Metadata:       modPrice.ConnectedPriceIDs,
+		Band: &models.DeviationBand{
+			Mode:       band.Mode,
+			Checked:    checked,
+			Reference:  band.Reference,
+			Lower:      band.Lower(),
+			Upper:      band.Upper(),
+			Volatility: band.Volatility,
+			Samples:    band.Samples,
+		},
 	}
 }
 
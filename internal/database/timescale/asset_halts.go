@@ -0,0 +1,76 @@
+package timescale
+
+import (
+	"context"
+	"database/sql"
+
+	"oracle_engine/internal/models"
+)
+
+// SaveAssetHalt stores the breaker state of an asset and logs the
+// change, event is "tripped" or "resumed"
+func (t *TimescaleDB) SaveAssetHalt(ctx context.Context, halt models.AssetHalt, event string) error {
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+        INSERT INTO asset_halts (
+            asset_id, halted, reason, detail, tripped_at, resumed_at, resumed_by, updated_at
+        ) VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5, $6, NULLIF($7, ''), $8)
+        ON CONFLICT (asset_id) DO UPDATE SET
+            halted = EXCLUDED.halted,
+            reason = EXCLUDED.reason,
+            detail = EXCLUDED.detail,
+            tripped_at = EXCLUDED.tripped_at,
+            resumed_at = EXCLUDED.resumed_at,
+            resumed_by = EXCLUDED.resumed_by,
+            updated_at = EXCLUDED.updated_at`,
+		halt.AssetID, halt.Halted, halt.Reason, halt.Detail,
+		halt.TrippedAt, halt.ResumedAt, halt.ResumedBy, halt.UpdatedAt,
+	); err != nil {
+		return err
+	}
+
+	operator := ""
+	if event == "resumed" {
+		operator = halt.ResumedBy
+	}
+	if _, err := tx.ExecContext(ctx, `
+        INSERT INTO asset_halt_events (asset_id, event, reason, detail, operator, created_at)
+        VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, ''), $6)`,
+		halt.AssetID, event, halt.Reason, halt.Detail, operator, halt.UpdatedAt,
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (t *TimescaleDB) GetAssetHalts(ctx context.Context) ([]models.AssetHalt, error) {
+	rows, err := t.db.QueryContext(ctx, `
+        SELECT asset_id, halted, reason, detail, tripped_at, resumed_at, resumed_by, updated_at
+        FROM asset_halts`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	halts := make([]models.AssetHalt, 0)
+	for rows.Next() {
+		var halt models.AssetHalt
+		var reason, detail, resumedBy sql.NullString
+		if err := rows.Scan(
+			&halt.AssetID, &halt.Halted, &reason, &detail,
+			&halt.TrippedAt, &halt.ResumedAt, &resumedBy, &halt.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		halt.Reason = reason.String
+		halt.Detail = detail.String
+		halt.ResumedBy = resumedBy.String
+		halts = append(halts, halt)
+	}
+	return halts, rows.Err()
+}
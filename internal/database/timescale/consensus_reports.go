@@ -0,0 +1,57 @@
+package timescale
+
+import (
+	"context"
+	"encoding/json"
+
+	"oracle_engine/internal/models"
+)
+
+// SaveConsensusReport keeps the signed observations behind a multi node
+// report, issuanceID is empty when this node had no issuance for it
+func (t *TimescaleDB) SaveConsensusReport(ctx context.Context, report models.ConsensusReport, issuanceID string) error {
+	observations, err := json.Marshal(report.Observations)
+	if err != nil {
+		return err
+	}
+	query := `
+        INSERT INTO consensus_reports (
+            asset_id, round_id, issuance_id, value, leader, observations, created_at
+        ) VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7)
+        ON CONFLICT (asset_id, round_id) DO NOTHING
+    `
+	_, err = t.db.ExecContext(ctx, query,
+		report.AssetID, report.RoundID, issuanceID, report.Value,
+		report.Leader, observations, report.CreatedAt,
+	)
+	return err
+}
+
+// SaveReportedIssuance stores the quorum value of a report on the issuance
+// relaying it, the issuance is saved whole when the report came without
+// one. It is queued for the contracts of entries in the same tx.
+func (t *TimescaleDB) SaveReportedIssuance(ctx context.Context, issuance models.Issuance, isNew bool, entries []models.RelayOutboxEntry) error {
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if isNew {
+		err = saveIssuance(ctx, tx, issuance)
+	} else {
+		_, err = tx.ExecContext(ctx, `
+            UPDATE issuances
+            SET price_value = $2, price_timestamp = $3, updated_at = $4
+            WHERE id = $1`,
+			issuance.ID, issuance.PriceValue, issuance.PriceTimestamp, issuance.UpdatedAt,
+		)
+	}
+	if err != nil {
+		return err
+	}
+	if err := insertRelays(ctx, tx, entries); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
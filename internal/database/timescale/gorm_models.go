@@ -17,6 +17,8 @@ type Price struct {
 	Timestamp time.Time `gorm:"type:timestamptz;not null;primaryKey" json:"timestamp"`
 	Source    string    `gorm:"type:text;not null" json:"source"`
 	ReqHash   string    `gorm:"type:text" json:"req_hash"`
+	// Source quorum of the aggregation window
+	Quorum datatypes.JSON `gorm:"type:jsonb" json:"quorum,omitempty"`
 
 	// Relationships
 	RawPriceLinks []PriceRawPriceLink `gorm:"foreignKey:PriceID,PriceTimestamp;references:ID,Timestamp" json:"raw_price_links,omitempty"`
@@ -60,6 +62,10 @@ type PriceRawPriceLink struct {
 	PriceID        uuid.UUID `gorm:"type:uuid;not null" json:"price_id"`
 	PriceTimestamp time.Time `gorm:"type:timestamptz;not null" json:"price_timestamp"`
 	RawPriceID     string    `gorm:"type:text;not null" json:"raw_price_id"`
+	// provenance, excluded prices carry the reason and no weight
+	Included        bool     `gorm:"type:boolean;not null;default:true" json:"included"`
+	ExclusionReason *string  `gorm:"type:text" json:"exclusion_reason,omitempty"`
+	Weight          *float64 `gorm:"type:float8" json:"weight,omitempty"`
 
 	// Relationships
 	Price    Price    `gorm:"foreignKey:PriceID,PriceTimestamp;references:ID,Timestamp" json:"price,omitempty"`
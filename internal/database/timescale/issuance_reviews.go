@@ -0,0 +1,301 @@
+package timescale
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"oracle_engine/internal/models"
+)
+
+var (
+	ErrNotReviewable  = errors.New("issuance is not awaiting review")
+	ErrReviewOutdated = errors.New("a newer issuance of the asset was approved since")
+)
+
+// SaveReviewInputs keeps the aggregate and band a denied issuance was
+// judged on, denied prices are not stored with the approved ones
+func (t *TimescaleDB) SaveReviewInputs(ctx context.Context, issuance models.Issuance) error {
+	price, err := json.Marshal(issuance.Price)
+	if err != nil {
+		return err
+	}
+	var band []byte
+	if issuance.Band != nil {
+		if band, err = json.Marshal(issuance.Band); err != nil {
+			return err
+		}
+	}
+	_, err = t.db.ExecContext(ctx, `
+        INSERT INTO issuance_review_inputs (issuance_id, price, band, created_at)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (issuance_id) DO NOTHING`,
+		issuance.ID, price, band, issuance.CreatedAt,
+	)
+	return err
+}
+
+const reviewItemColumns = `
+    i.id, i.state, i.issuer_address, i.round_id, i.created_at, i.updated_at,
+    i.price_value, i.price_asset_id, i.price_source, i.price_timestamp,
+    i.metadata, ri.price, ri.band`
+
+// GetPendingReviews lists denied issuances no operator decided on yet,
+// newest first, assetID empty for all assets
+func (t *TimescaleDB) GetPendingReviews(ctx context.Context, assetID string, limit int) ([]models.Issuance, error) {
+	rows, err := t.db.QueryContext(ctx, `
+        SELECT `+reviewItemColumns+`
+        FROM issuances i
+        JOIN issuance_review_inputs ri ON ri.issuance_id = i.id
+        WHERE i.state = $1
+          AND ($2 = '' OR i.price_asset_id = $2)
+          AND NOT EXISTS (SELECT 1 FROM issuance_reviews r WHERE r.issuance_id = i.id)
+        ORDER BY i.created_at DESC
+        LIMIT $3`,
+		models.Denied, assetID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	issuances := make([]models.Issuance, 0)
+	for rows.Next() {
+		issuance, err := scanReviewItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		issuances = append(issuances, *issuance)
+	}
+	return issuances, rows.Err()
+}
+
+// GetReviewIssuance returns a denied issuance with its review inputs
+func (t *TimescaleDB) GetReviewIssuance(ctx context.Context, issuanceID string) (*models.Issuance, error) {
+	row := t.db.QueryRowContext(ctx, `
+        SELECT `+reviewItemColumns+`
+        FROM issuances i
+        JOIN issuance_review_inputs ri ON ri.issuance_id = i.id
+        WHERE i.id = $1`,
+		issuanceID,
+	)
+	return scanReviewItem(row)
+}
+
+func scanReviewItem(row interface{ Scan(...any) error }) (*models.Issuance, error) {
+	var issuance models.Issuance
+	var price, band []byte
+	if err := row.Scan(
+		&issuance.ID, &issuance.State, &issuance.IssuerAddress, &issuance.RoundID,
+		&issuance.CreatedAt, &issuance.UpdatedAt, &issuance.PriceValue,
+		&issuance.PriceAssetID, &issuance.PriceSource, &issuance.PriceTimestamp,
+		&issuance.Metadata, &price, &band,
+	); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(price, &issuance.Price); err != nil {
+		return nil, err
+	}
+	if len(band) > 0 {
+		issuance.Band = &models.DeviationBand{}
+		if err := json.Unmarshal(band, issuance.Band); err != nil {
+			return nil, err
+		}
+	}
+	return &issuance, nil
+}
+
+// ReviewIssuance records an operator decision on a denied issuance. An
+// approval moves the issuance to approved and stores its price together
+// with the relays outbox returns for it, it is refused once a newer
+// issuance of the asset was approved.
+func (t *TimescaleDB) ReviewIssuance(ctx context.Context, review *models.IssuanceReview, outbox func(*models.Issuance) []models.RelayOutboxEntry) (*models.Issuance, []models.RelayOutboxEntry, error) {
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	var state models.IssuanceState
+	var createdAt time.Time
+	err = tx.QueryRowContext(ctx,
+		`SELECT state, price_asset_id, created_at FROM issuances WHERE id = $1 FOR UPDATE`,
+		review.IssuanceID,
+	).Scan(&state, &review.AssetID, &createdAt)
+	if err != nil {
+		return nil, nil, err
+	}
+	var reviewed bool
+	if err := tx.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM issuance_reviews WHERE issuance_id = $1)`,
+		review.IssuanceID,
+	).Scan(&reviewed); err != nil {
+		return nil, nil, err
+	}
+	if state != models.Denied || reviewed {
+		return nil, nil, ErrNotReviewable
+	}
+
+	issuance, err := scanReviewItem(tx.QueryRowContext(ctx, `
+        SELECT `+reviewItemColumns+`
+        FROM issuances i
+        JOIN issuance_review_inputs ri ON ri.issuance_id = i.id
+        WHERE i.id = $1`,
+		review.IssuanceID,
+	))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var entries []models.RelayOutboxEntry
+	if review.Decision == models.ReviewApprove {
+		if !state.CanTransitionTo(models.Approved) {
+			return nil, nil, fmt.Errorf("issuance %s cannot move from %s to approved", review.IssuanceID, state)
+		}
+		var newer bool
+		if err := tx.QueryRowContext(ctx, `
+            SELECT EXISTS (
+                SELECT 1 FROM issuances
+                WHERE price_asset_id = $1 AND created_at > $2 AND state <> $3
+            )`,
+			review.AssetID, createdAt, models.Denied,
+		).Scan(&newer); err != nil {
+			return nil, nil, err
+		}
+		if newer {
+			return nil, nil, ErrReviewOutdated
+		}
+
+		quorum, err := encodeQuorum(issuance.Price.Quorum)
+		if err != nil {
+			return nil, nil, err
+		}
+		price := issuance.Price
+		if _, err := tx.ExecContext(ctx, `
+            INSERT INTO prices (id, asset_id, value, expo, timestamp, source, req_hash, quorum)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+			price.ID, price.AssetID, price.Value, price.Expo, price.Timestamp, price.Source, price.ReqHash, quorum,
+		); err != nil {
+			return nil, nil, err
+		}
+
+		issuance.State = models.Approved
+		issuance.UpdatedAt = review.CreatedAt
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE issuances SET state = $2, updated_at = $3 WHERE id = $1`,
+			issuance.ID, issuance.State, issuance.UpdatedAt,
+		); err != nil {
+			return nil, nil, err
+		}
+		// a crash after the commit leaves the relays to the relayer, not lost
+		if outbox != nil {
+			entries = outbox(issuance)
+		}
+		if err := insertRelays(ctx, tx, entries); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := tx.QueryRowContext(ctx, `
+        INSERT INTO issuance_reviews (issuance_id, asset_id, decision, reason, operator, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING id`,
+		review.IssuanceID, review.AssetID, review.Decision, review.Reason, review.Operator, review.CreatedAt,
+	).Scan(&review.ID); err != nil {
+		return nil, nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+	return issuance, entries, nil
+}
+
+// GetIssuanceReviews returns the review audit log newest first, filters
+// left empty match everything
+func (t *TimescaleDB) GetIssuanceReviews(ctx context.Context, assetID, operator string, limit int) ([]models.IssuanceReview, error) {
+	rows, err := t.db.QueryContext(ctx, `
+        SELECT id, issuance_id, asset_id, decision, reason, operator, created_at
+        FROM issuance_reviews
+        WHERE ($1 = '' OR asset_id = $1) AND ($2 = '' OR operator = $2)
+        ORDER BY created_at DESC
+        LIMIT $3`,
+		assetID, operator, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reviews := make([]models.IssuanceReview, 0)
+	for rows.Next() {
+		var review models.IssuanceReview
+		if err := rows.Scan(
+			&review.ID, &review.IssuanceID, &review.AssetID, &review.Decision,
+			&review.Reason, &review.Operator, &review.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, review)
+	}
+	return reviews, rows.Err()
+}
+
+// GetLastApprovedIssuanceBefore is the approved issuance a denied one was compared to
+func (t *TimescaleDB) GetLastApprovedIssuanceBefore(ctx context.Context, assetID string, before time.Time) (*models.Issuance, error) {
+	var issuance models.Issuance
+	err := t.db.QueryRowContext(ctx, `
+        SELECT id, state, issuer_address, round_id, created_at, updated_at,
+            price_value, price_asset_id, price_source, price_timestamp, metadata
+        FROM issuances
+        WHERE price_asset_id = $1 AND created_at < $2 AND state <> $3
+        ORDER BY created_at DESC
+        LIMIT 1`,
+		assetID, before, models.Denied,
+	).Scan(
+		&issuance.ID, &issuance.State, &issuance.IssuerAddress, &issuance.RoundID,
+		&issuance.CreatedAt, &issuance.UpdatedAt, &issuance.PriceValue,
+		&issuance.PriceAssetID, &issuance.PriceSource, &issuance.PriceTimestamp,
+		&issuance.Metadata,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &issuance, nil
+}
+
+// GetLastRelayedIssuanceBefore is the last issuance of an asset that went
+// out on chain, submitted or confirmed, or relayed by the leader of its
+// consensus report. Failed and never relayed issuances are skipped. In
+// multi node mode the stored value is the relayed quorum value.
+func (t *TimescaleDB) GetLastRelayedIssuanceBefore(ctx context.Context, assetID string, before time.Time) (*models.Issuance, error) {
+	var issuance models.Issuance
+	err := t.db.QueryRowContext(ctx, `
+        SELECT i.id, i.state, i.issuer_address, i.round_id, i.created_at, i.updated_at,
+            i.price_value, i.price_asset_id, i.price_source, i.price_timestamp, i.metadata
+        FROM issuances i
+        WHERE i.price_asset_id = $1 AND i.created_at < $2
+            AND (i.state IN ($3, $4)
+                OR (i.state = $5 AND EXISTS (SELECT 1 FROM consensus_reports r WHERE r.issuance_id = i.id)))
+        ORDER BY i.created_at DESC
+        LIMIT 1`,
+		assetID, before, models.Submitted, models.Confirmed, models.Approved,
+	).Scan(
+		&issuance.ID, &issuance.State, &issuance.IssuerAddress, &issuance.RoundID,
+		&issuance.CreatedAt, &issuance.UpdatedAt, &issuance.PriceValue,
+		&issuance.PriceAssetID, &issuance.PriceSource, &issuance.PriceTimestamp,
+		&issuance.Metadata,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &issuance, nil
+}
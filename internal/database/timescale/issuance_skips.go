@@ -0,0 +1,59 @@
+package timescale
+
+import (
+	"context"
+	"encoding/json"
+
+	"oracle_engine/internal/models"
+)
+
+func (t *TimescaleDB) SaveIssuanceSkip(ctx context.Context, skip models.IssuanceSkip) error {
+	price, err := json.Marshal(skip.Price)
+	if err != nil {
+		return err
+	}
+	query := `
+        INSERT INTO issuance_skips (
+            asset_id, price, price_value, last_issuance_id, last_price_value,
+            deviation, since_last_seconds, reason, created_at
+        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+    `
+	_, err = t.db.ExecContext(ctx, query,
+		skip.AssetID, price, skip.PriceValue, skip.LastIssuanceID, skip.LastPriceValue,
+		skip.Deviation, skip.SinceLast, skip.Reason, skip.CreatedAt,
+	)
+	return err
+}
+
+// GetLastApprovedIssuance returns the last issuance of an asset that was
+// approved, wherever it got to in the relayer since, the baseline of the
+// push policy
+func (t *TimescaleDB) GetLastApprovedIssuance(ctx context.Context, assetID string) (*models.Issuance, error) {
+	query := `
+		SELECT id, state, issuer_address, round_id, created_at, updated_at,
+		price_value, price_asset_id, price_source, price_timestamp,
+		metadata
+		FROM issuances
+		WHERE price_asset_id = $1 AND state <> $2
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+	var issuance models.Issuance
+	err := t.db.QueryRowContext(ctx, query, assetID, models.Denied).Scan(
+		&issuance.ID,
+		&issuance.State,
+		&issuance.IssuerAddress,
+		&issuance.RoundID,
+		&issuance.CreatedAt,
+		&issuance.UpdatedAt,
+		&issuance.PriceValue,
+		&issuance.PriceAssetID,
+		&issuance.PriceSource,
+		&issuance.PriceTimestamp,
+		&issuance.Metadata,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &issuance, nil
+}
@@ -0,0 +1,156 @@
+package timescale
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"oracle_engine/internal/models"
+)
+
+// RecordIssuanceTransition moves an issuance to a new state on one contract.
+// The move is checked against the lifecycle from the state the issuance is
+// in on that contract, and the issuance row follows the latest transition.
+func (t *TimescaleDB) RecordIssuanceTransition(ctx context.Context, tr *models.IssuanceTransition) error {
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// lock the issuance so concurrent transitions of it serialise
+	var row models.IssuanceState
+	err = tx.QueryRowContext(ctx, `SELECT state FROM issuances WHERE id = $1 FOR UPDATE`, tr.IssuanceID).Scan(&row)
+	if err != nil {
+		return err
+	}
+	var recorded models.IssuanceState
+	var last *models.IssuanceState
+	err = tx.QueryRowContext(ctx, `
+        SELECT to_state FROM issuance_transitions
+        WHERE issuance_id = $1 AND chain_id = $2 AND contract = $3
+        ORDER BY created_at DESC
+        LIMIT 1`,
+		tr.IssuanceID, tr.ChainID, tr.Contract,
+	).Scan(&recorded)
+	switch {
+	case err == nil:
+		last = &recorded
+	case err != sql.ErrNoRows:
+		return err
+	}
+	current := contractState(row, last)
+	if !current.CanTransitionTo(tr.To) {
+		return fmt.Errorf("issuance %s on %s:%s cannot move from %s to %s",
+			tr.IssuanceID, tr.ChainID, tr.Contract, current, tr.To)
+	}
+	tr.From = current
+
+	if _, err := tx.ExecContext(ctx, `
+        INSERT INTO issuance_transitions (
+            issuance_id, chain_id, contract, from_state, to_state,
+            issuer, tx_hash, block_number, gas_used, error, created_at
+        ) VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''), NULLIF($8, 0), NULLIF($9, 0), NULLIF($10, ''), $11)`,
+		tr.IssuanceID, tr.ChainID, tr.Contract, tr.From, tr.To,
+		tr.Issuer, tr.TxHash, int64(tr.BlockNumber), int64(tr.GasUsed), tr.Error, tr.CreatedAt,
+	); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+        UPDATE issuances
+        SET state = $2, updated_at = $3,
+            issuer_address = COALESCE(NULLIF($4, ''), issuer_address)
+        WHERE id = $1`,
+		tr.IssuanceID, tr.To, tr.CreatedAt, tr.Issuer,
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// contractState is the state of an issuance on one contract, the last
+// transition recorded there. Before the first one it is approved, the
+// issuance row follows whichever contract moved last and says nothing
+// about this one. Denied issuances never reach a contract.
+func contractState(row models.IssuanceState, last *models.IssuanceState) models.IssuanceState {
+	if last != nil {
+		return *last
+	}
+	if row == models.Denied {
+		return models.Denied
+	}
+	return models.Approved
+}
+
+// GetIssuanceContractStates returns the latest state of an issuance on every contract
+func (t *TimescaleDB) GetIssuanceContractStates(ctx context.Context, issuanceID string) ([]models.IssuanceContractState, error) {
+	query := `
+        SELECT DISTINCT ON (chain_id, contract)
+            chain_id, contract, to_state, tx_hash, block_number, gas_used, error, created_at
+        FROM issuance_transitions
+        WHERE issuance_id = $1
+        ORDER BY chain_id, contract, created_at DESC
+    `
+	rows, err := t.db.QueryContext(ctx, query, issuanceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	states := make([]models.IssuanceContractState, 0)
+	for rows.Next() {
+		var state models.IssuanceContractState
+		var txHash, errMsg sql.NullString
+		var block, gas sql.NullInt64
+		if err := rows.Scan(
+			&state.ChainID, &state.Contract, &state.State,
+			&txHash, &block, &gas, &errMsg, &state.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		state.StateName = state.State.String()
+		state.TxHash = txHash.String
+		state.BlockNumber = uint64(block.Int64)
+		state.GasUsed = uint64(gas.Int64)
+		state.Error = errMsg.String
+		states = append(states, state)
+	}
+	return states, rows.Err()
+}
+
+// GetIssuanceTransitions returns the full lifecycle of an issuance, oldest first
+func (t *TimescaleDB) GetIssuanceTransitions(ctx context.Context, issuanceID string) ([]models.IssuanceTransition, error) {
+	query := `
+        SELECT issuance_id, chain_id, contract, from_state, to_state,
+            issuer, tx_hash, block_number, gas_used, error, created_at
+        FROM issuance_transitions
+        WHERE issuance_id = $1
+        ORDER BY created_at
+    `
+	rows, err := t.db.QueryContext(ctx, query, issuanceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transitions := make([]models.IssuanceTransition, 0)
+	for rows.Next() {
+		var tr models.IssuanceTransition
+		var issuer, txHash, errMsg sql.NullString
+		var block, gas sql.NullInt64
+		if err := rows.Scan(
+			&tr.IssuanceID, &tr.ChainID, &tr.Contract, &tr.From, &tr.To,
+			&issuer, &txHash, &block, &gas, &errMsg, &tr.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		tr.Issuer = issuer.String
+		tr.TxHash = txHash.String
+		tr.BlockNumber = uint64(block.Int64)
+		tr.GasUsed = uint64(gas.Int64)
+		tr.Error = errMsg.String
+		transitions = append(transitions, tr)
+	}
+	return transitions, rows.Err()
+}
@@ -0,0 +1,43 @@
+package timescale
+
+import (
+	"testing"
+
+	"oracle_engine/internal/models"
+)
+
+func TestContractsMoveIndependentlyOfTheIssuanceRow(t *testing.T) {
+	// the row follows the last transition of any contract, each contract
+	// only its own
+	row := models.Approved
+	last := map[string]*models.IssuanceState{}
+	move := func(contract string, to models.IssuanceState) bool {
+		current := contractState(row, last[contract])
+		if !current.CanTransitionTo(to) {
+			return false
+		}
+		row = to
+		last[contract] = &to
+		return true
+	}
+
+	for _, to := range []models.IssuanceState{models.Queued, models.Submitted, models.Confirmed} {
+		if !move("base", to) {
+			t.Fatalf("base cannot move to %s", to)
+		}
+	}
+	// the row says confirmed, arbitrum has not started yet
+	if got := contractState(row, last["arbitrum"]); got != models.Approved {
+		t.Fatalf("arbitrum starts at %s, want approved", got)
+	}
+	if !move("arbitrum", models.Queued) {
+		t.Fatal("arbitrum cannot queue an issuance confirmed on base")
+	}
+	if move("base", models.Queued) {
+		t.Fatal("base requeued a confirmed issuance after arbitrum queued it")
+	}
+
+	if got := contractState(models.Denied, nil); got != models.Denied {
+		t.Fatalf("denied issuance starts at %s on a contract", got)
+	}
+}
@@ -0,0 +1,120 @@
+package timescale
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"oracle_engine/internal/models"
+)
+
+// SaveAggregationProvenance links every raw price of an aggregation window
+// to the aggregate, included ones with their weight and excluded ones with
+// the reason, and stores the window statistics next to the links
+func (t *TimescaleDB) SaveAggregationProvenance(ctx context.Context, priceID string, timestamp time.Time, prov *models.AggregationProvenance) error {
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	statsQuery := `
+        INSERT INTO price_aggregation_stats (
+            price_id, price_timestamp, sample_count, distinct_sources, stddev, min_value, max_value
+        ) VALUES ($1, $2, $3, $4, $5, $6, $7)
+        ON CONFLICT (price_id) DO NOTHING
+    `
+	if _, err := tx.ExecContext(ctx, statsQuery,
+		priceID, timestamp, prov.SampleCount, prov.DistinctSources,
+		prov.StdDev, prov.Min, prov.Max,
+	); err != nil {
+		return err
+	}
+
+	linkQuery := `
+        INSERT INTO price_raw_price_links (
+            price_id, price_timestamp, raw_price_id, included, exclusion_reason, weight
+        ) VALUES ($1, $2, $3, $4, $5, $6)
+    `
+	for _, c := range prov.Contributions {
+		if _, err := tx.ExecContext(ctx, linkQuery,
+			priceID, timestamp, c.PriceID, true, nil, c.Weight,
+		); err != nil {
+			return err
+		}
+	}
+	for _, e := range prov.Exclusions {
+		if _, err := tx.ExecContext(ctx, linkQuery,
+			priceID, timestamp, e.PriceID, false, e.Reason, nil,
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetAggregationProvenance rebuilds the provenance of an aggregate,
+// nil when it was aggregated before provenance was recorded
+func (t *TimescaleDB) GetAggregationProvenance(ctx context.Context, priceID string) (*models.AggregationProvenance, error) {
+	statsQuery := `
+        SELECT sample_count, distinct_sources, stddev, min_value, max_value
+        FROM price_aggregation_stats
+        WHERE price_id = $1
+    `
+	prov := models.AggregationProvenance{
+		Contributions: make([]models.SourceContribution, 0),
+		Exclusions:    make([]models.PriceExclusion, 0),
+	}
+	err := t.db.QueryRowContext(ctx, statsQuery, priceID).Scan(
+		&prov.SampleCount, &prov.DistinctSources, &prov.StdDev, &prov.Min, &prov.Max,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	linkQuery := `
+        SELECT r.id, r.source, r.value, r.expo, l.included, l.exclusion_reason, l.weight
+        FROM price_raw_price_links l
+        INNER JOIN raw_prices r ON r.id = l.raw_price_id
+        WHERE l.price_id = $1
+        ORDER BY r.timestamp
+    `
+	rows, err := t.db.QueryContext(ctx, linkQuery, priceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var raw models.Price
+		var included bool
+		var reason sql.NullString
+		var weight sql.NullFloat64
+		if err := rows.Scan(
+			&raw.ID, &raw.Source, &raw.Value, &raw.Expo, &included, &reason, &weight,
+		); err != nil {
+			return nil, err
+		}
+		// same normalisation the aggregator saw
+		value := raw.ToUnified().Value
+		if included {
+			prov.Contributions = append(prov.Contributions, models.SourceContribution{
+				PriceID: raw.ID,
+				Source:  raw.Source,
+				Value:   value,
+				Weight:  weight.Float64,
+			})
+			continue
+		}
+		prov.Exclusions = append(prov.Exclusions, models.PriceExclusion{
+			PriceID: raw.ID,
+			Source:  raw.Source,
+			Value:   value,
+			Reason:  reason.String,
+		})
+	}
+	return &prov, rows.Err()
+}
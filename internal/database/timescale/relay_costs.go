@@ -0,0 +1,111 @@
+package timescale
+
+import (
+	"context"
+	"database/sql"
+
+	"oracle_engine/internal/models"
+)
+
+// SaveRelayCosts stores the shares of a mined tx, a tx seen mined again
+// after a reorg overwrites its shares
+func (t *TimescaleDB) SaveRelayCosts(ctx context.Context, costs []models.RelayCost) error {
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, cost := range costs {
+		if _, err := tx.ExecContext(ctx, `
+            INSERT INTO relay_costs (
+                tx_hash, chain_id, contract, issuance_id, asset_id, issuer, block_number,
+                batch_size, gas_used, cost_wei, effective_gas_price, tx_gas_used, tx_cost_wei,
+                reverted, mined_at, l1_fee_wei
+            ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10::NUMERIC, $11::NUMERIC, $12, $13::NUMERIC, $14, $15, $16::NUMERIC)
+            ON CONFLICT (tx_hash, issuance_id) DO UPDATE SET
+                block_number = EXCLUDED.block_number,
+                gas_used = EXCLUDED.gas_used,
+                cost_wei = EXCLUDED.cost_wei,
+                effective_gas_price = EXCLUDED.effective_gas_price,
+                tx_gas_used = EXCLUDED.tx_gas_used,
+                tx_cost_wei = EXCLUDED.tx_cost_wei,
+                reverted = EXCLUDED.reverted,
+                mined_at = EXCLUDED.mined_at,
+                l1_fee_wei = EXCLUDED.l1_fee_wei`,
+			cost.TxHash, cost.ChainID, cost.Contract, cost.IssuanceID, cost.AssetID, cost.Issuer,
+			int64(cost.BlockNumber), cost.BatchSize, int64(cost.GasUsed), cost.CostWei,
+			cost.EffectiveGasPrice, int64(cost.TxGasUsed), cost.TxCostWei, cost.Reverted, cost.MinedAt,
+			cost.L1FeeWei,
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// DeleteRelayCosts drops the shares of a tx reorged out of its block
+func (t *TimescaleDB) DeleteRelayCosts(ctx context.Context, txHash string) error {
+	_, err := t.db.ExecContext(ctx, `DELETE FROM relay_costs WHERE tx_hash = $1`, txHash)
+	return err
+}
+
+// GetRelayCostReport sums the costs per period, chain and asset, newest
+// first. nativeAssets maps chain ids to the asset id of their native
+// token, each share is priced in usd with the last price stored for it
+// when the tx was mined. A period with a share that has no such price
+// has no usd cost.
+func (t *TimescaleDB) GetRelayCostReport(ctx context.Context, q models.RelayCostQuery, nativeAssets map[string]string) ([]models.RelayCostSummary, error) {
+	chains := make([]string, 0, len(nativeAssets))
+	assets := make([]string, 0, len(nativeAssets))
+	for chainID, assetID := range nativeAssets {
+		chains = append(chains, chainID)
+		assets = append(assets, assetID)
+	}
+
+	rows, err := t.db.QueryContext(ctx, `
+        WITH native AS (
+            SELECT * FROM unnest($1::TEXT[], $2::TEXT[]) AS n(chain_id, asset_id)
+        )
+        SELECT date_trunc($3, c.mined_at) AS period, c.chain_id, c.asset_id,
+            COUNT(DISTINCT c.tx_hash), COUNT(*), SUM(c.gas_used), SUM(c.cost_wei)::TEXT,
+            (SUM(c.cost_wei) / 1e18)::FLOAT8,
+            CASE WHEN COUNT(p.usd) = COUNT(*) THEN SUM(c.cost_wei / 1e18 * p.usd)::FLOAT8 END
+        FROM relay_costs c
+        LEFT JOIN native n ON n.chain_id = c.chain_id
+        LEFT JOIN LATERAL (
+            SELECT value * power(10, expo) AS usd FROM prices
+            WHERE asset_id = n.asset_id AND timestamp <= c.mined_at
+            ORDER BY timestamp DESC
+            LIMIT 1
+        ) p ON TRUE
+        WHERE c.mined_at >= $4 AND c.mined_at < $5
+          AND ($6 = '' OR c.chain_id = $6)
+          AND ($7 = '' OR c.asset_id = $7)
+        GROUP BY 1, 2, 3
+        ORDER BY 1 DESC, 2, 3`,
+		chains, assets, q.Interval, q.From, q.To, q.ChainID, q.AssetID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summaries := make([]models.RelayCostSummary, 0)
+	for rows.Next() {
+		var s models.RelayCostSummary
+		var gasUsed int64
+		var usd sql.NullFloat64
+		if err := rows.Scan(&s.Period, &s.ChainID, &s.AssetID, &s.Txs, &s.Updates, &gasUsed, &s.CostWei, &s.Cost, &usd); err != nil {
+			return nil, err
+		}
+		s.GasUsed = uint64(gasUsed)
+		if usd.Valid {
+			perUpdate := usd.Float64 / float64(s.Updates)
+			s.CostUSD = &usd.Float64
+			s.CostPerUpdateUSD = &perUpdate
+		}
+		summaries = append(summaries, s)
+	}
+	return summaries, rows.Err()
+}
@@ -0,0 +1,167 @@
+package timescale
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"oracle_engine/internal/models"
+)
+
+// execer runs statements on the db or inside a tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// SaveIssuanceWithOutbox saves an issuance and queues it for the contracts
+// of entries in one tx, so an issuance is never stored without its relays
+func (t *TimescaleDB) SaveIssuanceWithOutbox(ctx context.Context, issuance models.Issuance, entries []models.RelayOutboxEntry) error {
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := saveIssuance(ctx, tx, issuance); err != nil {
+		return err
+	}
+	if err := insertRelays(ctx, tx, entries); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// EnqueueRelays queues issuances for their contracts, an issuance already
+// queued for a contract is left as is
+func (t *TimescaleDB) EnqueueRelays(ctx context.Context, entries []models.RelayOutboxEntry) error {
+	return insertRelays(ctx, t.db, entries)
+}
+
+func insertRelays(ctx context.Context, q execer, entries []models.RelayOutboxEntry) error {
+	for _, entry := range entries {
+		payload, err := json.Marshal(entry.Issuance)
+		if err != nil {
+			return err
+		}
+		if _, err := q.ExecContext(ctx, `
+            INSERT INTO relay_outbox (issuance_id, chain_id, contract, asset_id, payload, created_at)
+            VALUES ($1, $2, $3, $4, $5, NOW())
+            ON CONFLICT (issuance_id, chain_id, contract) DO NOTHING`,
+			entry.Issuance.ID, entry.ChainID, entry.Contract, entry.Issuance.Price.AssetID, payload,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ClaimRelays leases up to limit pending relays of a contract, oldest
+// first. Rows another worker holds are skipped, a lease that runs out
+// unfinished, say because the engine died, makes the relay claimable again.
+func (t *TimescaleDB) ClaimRelays(ctx context.Context, chainID, contract string, limit int, lease time.Duration) ([]models.RelayOutboxEntry, error) {
+	rows, err := t.db.QueryContext(ctx, `
+        UPDATE relay_outbox
+        SET claimed_until = NOW() + make_interval(secs => $4), attempts = attempts + 1
+        WHERE id IN (
+            SELECT id FROM relay_outbox
+            WHERE chain_id = $1 AND contract = $2
+              AND done_at IS NULL
+              AND (claimed_until IS NULL OR claimed_until < NOW())
+            ORDER BY id
+            LIMIT $3
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING id, chain_id, contract, attempts, payload`,
+		chainID, contract, limit, lease.Seconds(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []models.RelayOutboxEntry
+	for rows.Next() {
+		var entry models.RelayOutboxEntry
+		var payload []byte
+		if err := rows.Scan(&entry.ID, &entry.ChainID, &entry.Contract, &entry.Attempts, &payload); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(payload, &entry.Issuance); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// CompleteRelays marks claimed relays as done, they are never claimed again
+func (t *TimescaleDB) CompleteRelays(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := t.db.ExecContext(ctx, `
+        UPDATE relay_outbox SET done_at = NOW(), claimed_until = NULL
+        WHERE id = ANY($1)`,
+		ids,
+	)
+	return err
+}
+
+// RetryRelays hands claimed relays back, claimable again after backoff
+func (t *TimescaleDB) RetryRelays(ctx context.Context, ids []int64, backoff time.Duration) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := t.db.ExecContext(ctx, `
+        UPDATE relay_outbox SET claimed_until = NOW() + make_interval(secs => $2)
+        WHERE id = ANY($1) AND done_at IS NULL`,
+		ids, backoff.Seconds(),
+	)
+	return err
+}
+
+// ExtendRelays renews the claim on relays still in flight for another
+// lease, the same update as a retry
+func (t *TimescaleDB) ExtendRelays(ctx context.Context, ids []int64, lease time.Duration) error {
+	return t.RetryRelays(ctx, ids, lease)
+}
+
+// SupersedeRelays marks done the unclaimed relays of a contract that a
+// newer pending relay of the same asset replaces, and returns them
+func (t *TimescaleDB) SupersedeRelays(ctx context.Context, chainID, contract string) ([]models.RelayOutboxEntry, error) {
+	rows, err := t.db.QueryContext(ctx, `
+        UPDATE relay_outbox o
+        SET done_at = NOW(), claimed_until = NULL
+        WHERE o.chain_id = $1 AND o.contract = $2
+          AND o.done_at IS NULL
+          AND (o.claimed_until IS NULL OR o.claimed_until < NOW())
+          AND EXISTS (
+              SELECT 1 FROM relay_outbox n
+              WHERE n.chain_id = o.chain_id AND n.contract = o.contract
+                AND n.asset_id = o.asset_id
+                AND n.done_at IS NULL
+                AND n.id > o.id
+          )
+        RETURNING o.id, o.chain_id, o.contract, o.attempts, o.payload`,
+		chainID, contract,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []models.RelayOutboxEntry
+	for rows.Next() {
+		var entry models.RelayOutboxEntry
+		var payload []byte
+		if err := rows.Scan(&entry.ID, &entry.ChainID, &entry.Contract, &entry.Attempts, &payload); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(payload, &entry.Issuance); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,48 @@
+package timescale
+
+import (
+	"context"
+	"time"
+
+	"oracle_engine/internal/models"
+)
+
+// GetRelaySpend returns the spend of a chain booked since
+func (t *TimescaleDB) GetRelaySpend(ctx context.Context, chainID string, since time.Time) ([]models.RelaySpend, error) {
+	rows, err := t.db.QueryContext(ctx, `
+        SELECT id, chain_id, wei::TEXT, at FROM relay_spend
+        WHERE chain_id = $1 AND at >= $2`,
+		chainID, since,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	spends := make([]models.RelaySpend, 0)
+	for rows.Next() {
+		var spend models.RelaySpend
+		if err := rows.Scan(&spend.ID, &spend.ChainID, &spend.Wei, &spend.At); err != nil {
+			return nil, err
+		}
+		spends = append(spends, spend)
+	}
+	return spends, rows.Err()
+}
+
+// SaveRelaySpend books a tx, or moves its booking to a new amount
+func (t *TimescaleDB) SaveRelaySpend(ctx context.Context, spend models.RelaySpend) error {
+	_, err := t.db.ExecContext(ctx, `
+        INSERT INTO relay_spend (id, chain_id, wei, at)
+        VALUES ($1, $2, $3::NUMERIC, $4)
+        ON CONFLICT (id) DO UPDATE SET wei = EXCLUDED.wei`,
+		spend.ID, spend.ChainID, spend.Wei, spend.At,
+	)
+	return err
+}
+
+// DeleteRelaySpend drops bookings released or out of the window
+func (t *TimescaleDB) DeleteRelaySpend(ctx context.Context, ids []string) error {
+	_, err := t.db.ExecContext(ctx, `DELETE FROM relay_spend WHERE id = ANY($1::TEXT[])`, ids)
+	return err
+}
@@ -0,0 +1,37 @@
+package timescale
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// GetRelayerNonce returns the next local nonce of a relayer key, false
+// when the key never sent on the chain
+func (t *TimescaleDB) GetRelayerNonce(ctx context.Context, chainID, address string) (uint64, bool, error) {
+	var next int64
+	err := t.db.QueryRowContext(ctx, `
+        SELECT next_nonce FROM relayer_nonces
+        WHERE chain_id = $1 AND address = $2`,
+		chainID, address,
+	).Scan(&next)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return uint64(next), true, nil
+}
+
+func (t *TimescaleDB) SaveRelayerNonce(ctx context.Context, chainID, address string, next uint64) error {
+	_, err := t.db.ExecContext(ctx, `
+        INSERT INTO relayer_nonces (chain_id, address, next_nonce, updated_at)
+        VALUES ($1, $2, $3, NOW())
+        ON CONFLICT (chain_id, address) DO UPDATE SET
+            next_nonce = EXCLUDED.next_nonce,
+            updated_at = EXCLUDED.updated_at`,
+		chainID, address, int64(next),
+	)
+	return err
+}
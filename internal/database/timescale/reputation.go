@@ -0,0 +1,65 @@
+package timescale
+
+import (
+	"context"
+
+	"oracle_engine/internal/models"
+)
+
+func (t *TimescaleDB) UpsertSourceReputations(ctx context.Context, reps []models.SourceReputation) error {
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+        INSERT INTO source_reputations (
+            asset_id, source, score, deviation, uptime, staleness, samples, updated_at
+        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        ON CONFLICT (asset_id, source) DO UPDATE SET
+            score = EXCLUDED.score,
+            deviation = EXCLUDED.deviation,
+            uptime = EXCLUDED.uptime,
+            staleness = EXCLUDED.staleness,
+            samples = EXCLUDED.samples,
+            updated_at = EXCLUDED.updated_at
+    `
+	for _, rep := range reps {
+		if _, err := tx.ExecContext(ctx, query,
+			rep.AssetID, rep.Source, rep.Score, rep.Deviation,
+			rep.Uptime, rep.Staleness, rep.Samples, rep.UpdatedAt,
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetSourceReputations returns the scores of one asset, or all when assetID is empty
+func (t *TimescaleDB) GetSourceReputations(ctx context.Context, assetID string) ([]models.SourceReputation, error) {
+	query := `
+        SELECT asset_id, source, score, deviation, uptime, staleness, samples, updated_at
+        FROM source_reputations
+        WHERE ($1 = '' OR asset_id = $1)
+        ORDER BY asset_id, score DESC
+    `
+	rows, err := t.db.QueryContext(ctx, query, assetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reps := make([]models.SourceReputation, 0)
+	for rows.Next() {
+		var rep models.SourceReputation
+		if err := rows.Scan(
+			&rep.AssetID, &rep.Source, &rep.Score, &rep.Deviation,
+			&rep.Uptime, &rep.Staleness, &rep.Samples, &rep.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		reps = append(reps, rep)
+	}
+	return reps, rows.Err()
+}
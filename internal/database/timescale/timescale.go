@@ -3,6 +3,7 @@ package timescale
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
@@ -47,6 +48,8 @@ func (t *TimescaleDB) Initialize(ctx context.Context) error {
 
 	SELECT create_hypertable('prices', 'timestamp', if_not_exists => true, create_default_indexes => false);
 	CREATE INDEX ON prices(id);
+	CREATE INDEX IF NOT EXISTS prices_asset_timestamp_idx ON prices (asset_id, timestamp DESC);
+	ALTER TABLE prices ADD COLUMN IF NOT EXISTS quorum JSONB;
 
     CREATE TABLE IF NOT EXISTS raw_prices (
         id TEXT PRIMARY KEY,
@@ -68,6 +71,19 @@ func (t *TimescaleDB) Initialize(ctx context.Context) error {
 		FOREIGN KEY (price_id, price_timestamp) REFERENCES prices(id, timestamp) ON DELETE CASCADE,
 		FOREIGN KEY (raw_price_id) REFERENCES raw_prices(id) ON DELETE CASCADE
 	);
+	ALTER TABLE price_raw_price_links ADD COLUMN IF NOT EXISTS included BOOLEAN NOT NULL DEFAULT TRUE;
+	ALTER TABLE price_raw_price_links ADD COLUMN IF NOT EXISTS exclusion_reason TEXT;
+	ALTER TABLE price_raw_price_links ADD COLUMN IF NOT EXISTS weight FLOAT8;
+
+    CREATE TABLE IF NOT EXISTS price_aggregation_stats (
+        price_id UUID PRIMARY KEY,
+        price_timestamp TIMESTAMPTZ NOT NULL,
+        sample_count INT NOT NULL,
+        distinct_sources INT NOT NULL,
+        stddev FLOAT8 NOT NULL,
+        min_value FLOAT8 NOT NULL,
+        max_value FLOAT8 NOT NULL
+    );
 
 
     CREATE TABLE IF NOT EXISTS issuances (
@@ -83,6 +99,150 @@ func (t *TimescaleDB) Initialize(ctx context.Context) error {
         price_timestamp TIMESTAMPTZ NOT NULL,
         metadata JSONB
     );
+
+    CREATE TABLE IF NOT EXISTS issuance_transitions (
+        id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+        issuance_id TEXT NOT NULL REFERENCES issuances(id) ON DELETE CASCADE,
+        chain_id TEXT NOT NULL,
+        contract TEXT NOT NULL,
+        from_state SMALLINT NOT NULL,
+        to_state SMALLINT NOT NULL,
+        issuer TEXT,
+        tx_hash TEXT,
+        block_number BIGINT,
+        gas_used BIGINT,
+        error TEXT,
+        created_at TIMESTAMPTZ NOT NULL
+    );
+    CREATE INDEX IF NOT EXISTS issuance_transitions_issuance_idx ON issuance_transitions (issuance_id, chain_id, contract, created_at DESC);
+
+    CREATE TABLE IF NOT EXISTS issuance_skips (
+        id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+        asset_id TEXT NOT NULL,
+        price JSONB NOT NULL, -- skipped aggregates never reach prices, so they are kept inline
+        price_value FLOAT8 NOT NULL,
+        last_issuance_id TEXT,
+        last_price_value FLOAT8,
+        deviation FLOAT8 NOT NULL,
+        since_last_seconds FLOAT8 NOT NULL,
+        reason TEXT NOT NULL,
+        created_at TIMESTAMPTZ NOT NULL
+    );
+    CREATE INDEX IF NOT EXISTS issuance_skips_asset_created_idx ON issuance_skips (asset_id, created_at DESC);
+
+    CREATE TABLE IF NOT EXISTS consensus_reports (
+        asset_id TEXT NOT NULL,
+        round_id BIGINT NOT NULL,
+        issuance_id TEXT,
+        value FLOAT8 NOT NULL,
+        leader TEXT NOT NULL,
+        observations JSONB NOT NULL,
+        created_at TIMESTAMPTZ NOT NULL,
+        PRIMARY KEY (asset_id, round_id)
+    );
+
+    CREATE TABLE IF NOT EXISTS issuance_review_inputs (
+        issuance_id TEXT PRIMARY KEY REFERENCES issuances(id) ON DELETE CASCADE,
+        price JSONB NOT NULL,
+        band JSONB,
+        created_at TIMESTAMPTZ NOT NULL
+    );
+
+    CREATE TABLE IF NOT EXISTS issuance_reviews (
+        id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+        issuance_id TEXT NOT NULL UNIQUE REFERENCES issuances(id) ON DELETE CASCADE,
+        asset_id TEXT NOT NULL,
+        decision TEXT NOT NULL,
+        reason TEXT NOT NULL,
+        operator TEXT NOT NULL,
+        created_at TIMESTAMPTZ NOT NULL
+    );
+    CREATE INDEX IF NOT EXISTS idx_issuance_reviews_asset ON issuance_reviews(asset_id, created_at DESC);
+
+    CREATE TABLE IF NOT EXISTS asset_halts (
+        asset_id TEXT PRIMARY KEY,
+        halted BOOLEAN NOT NULL,
+        reason TEXT,
+        detail TEXT,
+        tripped_at TIMESTAMPTZ,
+        resumed_at TIMESTAMPTZ,
+        resumed_by TEXT,
+        updated_at TIMESTAMPTZ NOT NULL
+    );
+
+    CREATE TABLE IF NOT EXISTS asset_halt_events (
+        id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+        asset_id TEXT NOT NULL,
+        event TEXT NOT NULL,
+        reason TEXT,
+        detail TEXT,
+        operator TEXT,
+        created_at TIMESTAMPTZ NOT NULL
+    );
+
+    CREATE TABLE IF NOT EXISTS source_reputations (
+        asset_id TEXT NOT NULL,
+        source TEXT NOT NULL,
+        score FLOAT8 NOT NULL,
+        deviation FLOAT8 NOT NULL,
+        uptime FLOAT8 NOT NULL,
+        staleness FLOAT8 NOT NULL,
+        samples BIGINT NOT NULL,
+        updated_at TIMESTAMPTZ NOT NULL,
+        PRIMARY KEY (asset_id, source)
+    );
+
+    CREATE TABLE IF NOT EXISTS relayer_nonces (
+        chain_id TEXT NOT NULL,
+        address TEXT NOT NULL,
+        next_nonce BIGINT NOT NULL,
+        updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+        PRIMARY KEY (chain_id, address)
+    );
+
+    CREATE TABLE IF NOT EXISTS relay_outbox (
+        id BIGSERIAL PRIMARY KEY,
+        issuance_id TEXT NOT NULL,
+        chain_id TEXT NOT NULL,
+        contract TEXT NOT NULL,
+        asset_id TEXT NOT NULL,
+        payload JSONB NOT NULL,
+        attempts INT NOT NULL DEFAULT 0,
+        claimed_until TIMESTAMPTZ,
+        created_at TIMESTAMPTZ NOT NULL,
+        done_at TIMESTAMPTZ,
+        UNIQUE (issuance_id, chain_id, contract)
+    );
+    CREATE INDEX IF NOT EXISTS relay_outbox_pending_idx ON relay_outbox (chain_id, contract, id) WHERE done_at IS NULL;
+
+    CREATE TABLE IF NOT EXISTS relay_costs (
+        tx_hash TEXT NOT NULL,
+        chain_id TEXT NOT NULL,
+        contract TEXT NOT NULL,
+        issuance_id TEXT NOT NULL,
+        asset_id TEXT NOT NULL,
+        issuer TEXT NOT NULL,
+        block_number BIGINT NOT NULL,
+        batch_size INT NOT NULL,
+        gas_used BIGINT NOT NULL,
+        cost_wei NUMERIC(78, 0) NOT NULL,
+        effective_gas_price NUMERIC(78, 0) NOT NULL,
+        tx_gas_used BIGINT NOT NULL,
+        tx_cost_wei NUMERIC(78, 0) NOT NULL,
+        l1_fee_wei NUMERIC(78, 0) NOT NULL DEFAULT 0,
+        reverted BOOLEAN NOT NULL DEFAULT FALSE,
+        mined_at TIMESTAMPTZ NOT NULL,
+        PRIMARY KEY (tx_hash, issuance_id)
+    );
+    CREATE INDEX IF NOT EXISTS relay_costs_mined_idx ON relay_costs (mined_at DESC, chain_id, asset_id);
+
+    CREATE TABLE IF NOT EXISTS relay_spend (
+        id TEXT PRIMARY KEY,
+        chain_id TEXT NOT NULL,
+        wei NUMERIC(78, 0) NOT NULL,
+        at TIMESTAMPTZ NOT NULL
+    );
+    CREATE INDEX IF NOT EXISTS relay_spend_chain_at_idx ON relay_spend (chain_id, at);
 	`
 	_, err := t.db.ExecContext(ctx, query)
 	if err != nil {
@@ -94,17 +254,25 @@ func (t *TimescaleDB) Initialize(ctx context.Context) error {
 }
 
 func (t *TimescaleDB) SavePrice(ctx context.Context, price models.UnifiedPrice) error {
+	return savePrice(ctx, t.db, price)
+}
+
+func savePrice(ctx context.Context, q execer, price models.UnifiedPrice) error {
+	quorum, err := encodeQuorum(price.Quorum)
+	if err != nil {
+		return err
+	}
 	query := `
-        INSERT INTO prices (id, asset_id, value, expo, timestamp, source, req_hash)
-        VALUES ($1, $2, $3, $4, $5, $6, $7)`
-	_, err := t.db.ExecContext(ctx, query,
-		price.ID, price.AssetID, price.Value, price.Expo, price.Timestamp, price.Source, price.ReqHash)
+        INSERT INTO prices (id, asset_id, value, expo, timestamp, source, req_hash, quorum)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+	_, err = q.ExecContext(ctx, query,
+		price.ID, price.AssetID, price.Value, price.Expo, price.Timestamp, price.Source, price.ReqHash, quorum)
 	return err
 }
 
 func (t *TimescaleDB) GetLastPrice(ctx context.Context, assetID string) (*models.UnifiedPrice, error) {
 	query := `
-        SELECT id, value, expo, timestamp, source, req_hash
+        SELECT id, value, expo, timestamp, source, req_hash, quorum
         FROM prices
         WHERE asset_id = $1
         ORDER BY timestamp DESC
@@ -114,7 +282,8 @@ func (t *TimescaleDB) GetLastPrice(ctx context.Context, assetID string) (*models
 	var expo int8
 	var timestamp time.Time
 	var source, req_hash string
-	err := t.db.QueryRowContext(ctx, query, assetID).Scan(&id, &value, &expo, &timestamp, &source, &req_hash)
+	var quorum []byte
+	err := t.db.QueryRowContext(ctx, query, assetID).Scan(&id, &value, &expo, &timestamp, &source, &req_hash, &quorum)
 	if err != nil {
 		return nil, err
 	}
@@ -127,14 +296,46 @@ func (t *TimescaleDB) GetLastPrice(ctx context.Context, assetID string) (*models
 		Timestamp: timestamp,
 		ReqHash:   req_hash,
 		Source:    source,
+		Quorum:    decodeQuorum(quorum),
 	}, nil
 }
 
+// GetRecentPrices returns the last issued prices of an asset, newest first
+func (t *TimescaleDB) GetRecentPrices(ctx context.Context, assetID string, limit int) ([]models.UnifiedPrice, error) {
+	query := `
+        SELECT id, value, expo, timestamp, source, req_hash
+        FROM prices
+        WHERE asset_id = $1
+        ORDER BY timestamp DESC
+        LIMIT $2`
+	rows, err := t.db.QueryContext(ctx, query, assetID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prices := make([]models.UnifiedPrice, 0, limit)
+	for rows.Next() {
+		price := models.UnifiedPrice{AssetID: assetID, IsAggr: true}
+		var reqHash sql.NullString
+		if err := rows.Scan(&price.ID, &price.Value, &price.Expo, &price.Timestamp, &price.Source, &reqHash); err != nil {
+			return nil, err
+		}
+		price.ReqHash = reqHash.String
+		prices = append(prices, price)
+	}
+	return prices, rows.Err()
+}
+
 func (t *TimescaleDB) SaveIssuance(ctx context.Context, issuance models.Issuance) error {
+	return t.SaveIssuanceWithOutbox(ctx, issuance, nil)
+}
+
+func saveIssuance(ctx context.Context, q execer, issuance models.Issuance) error {
 	logging.Logger.Info("Saving issuance", zap.Any("issuance", issuance))
 	logging.Logger.Info("state comparison", zap.Any("state", issuance.State), zap.Any("approved", models.Approved), zap.Bool("equal", issuance.State == models.Approved))
 	if issuance.State == models.Approved {
-		if err := t.SavePrice(ctx, issuance.Price); err != nil {
+		if err := savePrice(ctx, q, issuance.Price); err != nil {
 			logging.Logger.Info("Error saving price", zap.Any("err", err), zap.Any("price", issuance.Price.ID))
 			return err
 		}
@@ -151,7 +352,7 @@ func (t *TimescaleDB) SaveIssuance(ctx context.Context, issuance models.Issuance
             updated_at = EXCLUDED.updated_at,
             metadata = EXCLUDED.metadata
     `
-	_, err := t.db.ExecContext(ctx, query,
+	_, err := q.ExecContext(ctx, query,
 		issuance.ID,
 		issuance.State,
 		issuance.IssuerAddress,
@@ -285,19 +486,21 @@ func (t *TimescaleDB) LinkRawPricesToAggregatedPrice(ctx context.Context, aggreg
 
 func (t *TimescaleDB) AuditPrice(ctx context.Context, id string) (*models.PriceAudit, error) {
 	priceQuery := `
-        SELECT id, asset_id, value, expo, timestamp, source, req_hash
+        SELECT id, asset_id, value, expo, timestamp, source, req_hash, quorum
         FROM prices
         WHERE id = $1
         ORDER BY timestamp DESC
         LIMIT 1
     `
 	var up models.UnifiedPrice
+	var quorum []byte
 	err := t.db.QueryRowContext(ctx, priceQuery, id).Scan(
-		&up.ID, &up.AssetID, &up.Value, &up.Expo, &up.Timestamp, &up.Source, &up.ReqHash,
+		&up.ID, &up.AssetID, &up.Value, &up.Expo, &up.Timestamp, &up.Source, &up.ReqHash, &quorum,
 	)
 	if err != nil {
 		return nil, err
 	}
+	up.Quorum = decodeQuorum(quorum)
 
 	// rawQuery := `
 	// 	SELECT r.id, r.source, r.req_url, r.asset_id, r.value, r.expo, r.timestamp
@@ -311,7 +514,7 @@ func (t *TimescaleDB) AuditPrice(ctx context.Context, id string) (*models.PriceA
 		SELECT r.id, r.source, r.req_url, r.asset_id, r.value, r.expo, r.timestamp
 		FROM price_raw_price_links l
 		INNER JOIN raw_prices r ON r.id = l.raw_price_id
-		WHERE l.price_id = $1
+		WHERE l.price_id = $1 AND l.included
 		ORDER BY r.timestamp;
 	`
 
@@ -331,6 +534,11 @@ func (t *TimescaleDB) AuditPrice(ctx context.Context, id string) (*models.PriceA
 		raws = append(raws, rp)
 	}
 
+	provenance, err := t.GetAggregationProvenance(ctx, up.ID)
+	if err != nil {
+		return nil, err
+	}
+
 	auditData := models.PriceAudit{
 		PriceID:         up.ID,
 		AssetID:         up.AssetID,
@@ -338,6 +546,7 @@ func (t *TimescaleDB) AuditPrice(ctx context.Context, id string) (*models.PriceA
 		RawPrices:       raws,
 		CreatedAt:       up.Timestamp,
 		UpdatedAt:       up.Timestamp,
+		Provenance:      provenance,
 	}
 
 	return &auditData, nil
@@ -398,7 +607,7 @@ func (t *TimescaleDB) AuditPriceRange(ctx context.Context, fromTime, toTime time
 			SELECT r.id, r.source, r.req_url, r.asset_id, r.value, r.expo, r.timestamp
 			FROM price_raw_price_links l
 			INNER JOIN raw_prices r ON r.id = l.raw_price_id
-			WHERE l.price_id = $1
+			WHERE l.price_id = $1 AND l.included
 			ORDER BY r.timestamp;
 		`
 
@@ -419,6 +628,11 @@ func (t *TimescaleDB) AuditPriceRange(ctx context.Context, fromTime, toTime time
 		}
 		rawRows.Close()
 
+		provenance, err := t.GetAggregationProvenance(ctx, up.ID)
+		if err != nil {
+			return nil, err
+		}
+
 		auditData := &models.PriceAudit{
 			PriceID:         up.ID,
 			AssetID:         up.AssetID,
@@ -426,6 +640,7 @@ func (t *TimescaleDB) AuditPriceRange(ctx context.Context, fromTime, toTime time
 			RawPrices:       raws,
 			CreatedAt:       up.Timestamp,
 			UpdatedAt:       up.Timestamp,
+			Provenance:      provenance,
 		}
 
 		auditRecords = append(auditRecords, auditData)
@@ -467,3 +682,23 @@ func (t *TimescaleDB) GetHistoricalPrice(ctx context.Context, assetID string, lo
 		Source:    source,
 	}, nil
 }
+
+// encodeQuorum marshals a quorum status for a nullable JSONB column
+func encodeQuorum(quorum *models.QuorumStatus) ([]byte, error) {
+	if quorum == nil {
+		return nil, nil
+	}
+	return json.Marshal(quorum)
+}
+
+func decodeQuorum(data []byte) *models.QuorumStatus {
+	if len(data) == 0 {
+		return nil
+	}
+	var quorum models.QuorumStatus
+	if err := json.Unmarshal(data, &quorum); err != nil {
+		logging.Logger.Warn("Failed to decode price quorum", zap.Error(err))
+		return nil
+	}
+	return &quorum
+}
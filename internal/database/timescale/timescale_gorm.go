@@ -68,6 +68,10 @@ func (t *TimescaleGORM) Initialize(ctx context.Context) error {
 
 // SavePrice saves a unified price to the database
 func (t *TimescaleGORM) SavePrice(ctx context.Context, price models.UnifiedPrice) error {
+	quorum, err := encodeQuorum(price.Quorum)
+	if err != nil {
+		return err
+	}
 	gormPrice := Price{
 		ID:        uuid.MustParse(price.ID),
 		AssetID:   price.AssetID,
@@ -76,6 +80,7 @@ func (t *TimescaleGORM) SavePrice(ctx context.Context, price models.UnifiedPrice
 		Timestamp: price.Timestamp,
 		Source:    price.Source,
 		ReqHash:   price.ReqHash,
+		Quorum:    datatypes.JSON(quorum),
 	}
 
 	return t.db.WithContext(ctx).Create(&gormPrice).Error
@@ -101,6 +106,7 @@ func (t *TimescaleGORM) GetLastPrice(ctx context.Context, assetID string) (*mode
 		Timestamp: price.Timestamp,
 		Source:    price.Source,
 		ReqHash:   price.ReqHash,
+		Quorum:    decodeQuorum(price.Quorum),
 	}, nil
 }
 
@@ -149,6 +155,7 @@ func (t *TimescaleGORM) LinkRawPricesToAggregatedPrice(ctx context.Context, aggr
 			PriceID:        uuid.MustParse(aggregatedPriceID),
 			PriceTimestamp: timestamp,
 			RawPriceID:     rawID,
+			Included:       true,
 		}
 
 		err := t.db.WithContext(ctx).
@@ -276,7 +283,7 @@ func (t *TimescaleGORM) AuditPrice(ctx context.Context, id string) (*models.Pric
 	var rawPrices []RawPrice
 	err = t.db.WithContext(ctx).
 		Joins("JOIN price_raw_price_links l ON raw_prices.id = l.raw_price_id").
-		Where("l.price_id = ?", id).
+		Where("l.price_id = ? AND l.included", id).
 		Order("raw_prices.timestamp").
 		Find(&rawPrices).Error
 	if err != nil {
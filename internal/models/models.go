@@ -51,6 +51,55 @@ type UnifiedPrice struct {
 	IsAggr            bool          `json:"is_aggr"`
 	ConnectedPriceIDs []string      `json:"connected_price_ids"`
 	PriceChanges      []PriceChange `json:"price_changes,omitempty"` // Optional price changes
+	// source quorum of the aggregation window, only set on aggregated prices
+	Quorum *QuorumStatus `json:"quorum,omitempty"`
+	// how the aggregate was built, only set on aggregated prices
+	Provenance *AggregationProvenance `json:"provenance,omitempty"`
+}
+
+// QuorumStatus describes how many distinct sources backed an aggregate
+type QuorumStatus struct {
+	Met             bool     `json:"met"`
+	Degraded        bool     `json:"degraded"`
+	Withheld        bool     `json:"withheld,omitempty"` // missed quorum on a withhold asset, not issued
+	DistinctSources int      `json:"distinct_sources"`
+	MinSources      int      `json:"min_sources"`
+	Sources         []string `json:"sources"`
+	MissingSources  []string `json:"missing_sources,omitempty"` // required sources absent from the window
+}
+
+// Reasons a raw price was left out of an aggregate
+const (
+	ExclusionDeviation = "deviation" // too far from the window reference price
+	ExclusionStale     = "stale"     // older than the asset's max price age
+	ExclusionOutlier   = "outlier"   // too far from the window median
+)
+
+// AggregationProvenance records why each price did or did not count
+type AggregationProvenance struct {
+	SampleCount     int                  `json:"sample_count"` // prices in the window, excluded ones too
+	DistinctSources int                  `json:"distinct_sources"`
+	StdDev          float64              `json:"stddev"` // over included prices
+	Min             float64              `json:"min"`
+	Max             float64              `json:"max"`
+	Contributions   []SourceContribution `json:"contributions"`
+	Exclusions      []PriceExclusion     `json:"exclusions"`
+}
+
+// SourceContribution is one price that made it into an aggregate
+type SourceContribution struct {
+	PriceID string  `json:"price_id"`
+	Source  string  `json:"source"`
+	Value   float64 `json:"value"`
+	Weight  float64 `json:"weight"` // share of the aggregate, weights of one aggregate sum to 1
+}
+
+// PriceExclusion is one price that was dropped from an aggregate
+type PriceExclusion struct {
+	PriceID string  `json:"price_id"`
+	Source  string  `json:"source"`
+	Value   float64 `json:"value"`
+	Reason  string  `json:"reason"`
 }
 
 func (p Price) ToUnified() UnifiedPrice {
@@ -95,12 +144,92 @@ func (up UnifiedPrice) Number() float64 {
 
 type IssuanceState int
 
+// values are persisted, only ever append
 const (
 	Denied IssuanceState = iota
 	Approved
 	Confirmed
+	Queued    // handed to the relayer for a contract
+	Submitted // tx sent, waiting for a receipt
+	Failed    // submission or tx failed
+	Replaced  // superseded by a newer issuance of the same asset
 )
 
+var issuanceStateNames = map[IssuanceState]string{
+	Denied:    "denied",
+	Approved:  "approved",
+	Confirmed: "confirmed",
+	Queued:    "queued",
+	Submitted: "submitted",
+	Failed:    "failed",
+	Replaced:  "replaced",
+}
+
+func (s IssuanceState) String() string {
+	if name, ok := issuanceStateNames[s]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// denied -> approved (operator override) -> queued -> submitted -> confirmed | failed | replaced,
+// a submitted issuance can be resubmitted with a new tx, a failed one requeued
+var issuanceTransitions = map[IssuanceState][]IssuanceState{
+	Denied:    {Approved},
+	Approved:  {Queued, Replaced},
+	Queued:    {Submitted, Failed, Replaced},
+	Submitted: {Submitted, Confirmed, Failed, Replaced},
+	Confirmed: {Submitted}, // reorged out, back to waiting for inclusion
+	Failed:    {Queued},
+}
+
+// CanTransitionTo reports whether the lifecycle allows moving to next
+func (s IssuanceState) CanTransitionTo(next IssuanceState) bool {
+	for _, allowed := range issuanceTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// IssuanceTransition is one persisted lifecycle step of an issuance on a contract
+type IssuanceTransition struct {
+	IssuanceID  string        `json:"issuance_id"`
+	ChainID     string        `json:"chain_id"`
+	Contract    string        `json:"contract"`
+	From        IssuanceState `json:"from_state"`
+	To          IssuanceState `json:"to_state"`
+	Issuer      string        `json:"issuer,omitempty"` // relayer address that sent the tx
+	TxHash      string        `json:"tx_hash,omitempty"`
+	BlockNumber uint64        `json:"block_number,omitempty"`
+	GasUsed     uint64        `json:"gas_used,omitempty"`
+	Error       string        `json:"error,omitempty"`
+	CreatedAt   time.Time     `json:"created_at"`
+}
+
+// RelayOutboxEntry is an issuance waiting in the outbox of one contract
+type RelayOutboxEntry struct {
+	ID       int64    `json:"id"`
+	ChainID  string   `json:"chain_id"`
+	Contract string   `json:"contract"`
+	Attempts int      `json:"attempts"` // claims so far, the current one included
+	Issuance Issuance `json:"issuance"`
+}
+
+// IssuanceContractState is where an issuance stands on one contract
+type IssuanceContractState struct {
+	ChainID     string        `json:"chain_id"`
+	Contract    string        `json:"contract"`
+	State       IssuanceState `json:"state"`
+	StateName   string        `json:"state_name"`
+	TxHash      string        `json:"tx_hash,omitempty"`
+	BlockNumber uint64        `json:"block_number,omitempty"`
+	GasUsed     uint64        `json:"gas_used,omitempty"`
+	Error       string        `json:"error,omitempty"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+}
+
 type Issuance struct {
 	ID             string        `json:"issuance_id"`
 	State          IssuanceState `json:"issuance_state"`
@@ -114,6 +243,253 @@ type Issuance struct {
 	PriceSource    string        `json:"price_source"`
 	PriceTimestamp time.Time     `json:"price_timestamp"`
 	Metadata       interface{}   `json:"metadata"`
+	// signed multi node report backing the issuance, nil in single node mode
+	Report *ConsensusReport `json:"report,omitempty"`
+	// lifecycle per contract once the issuance reached the relayer
+	Contracts []IssuanceContractState `json:"contracts,omitempty"`
+	// the asset's circuit breaker is open, the issuance is not published
+	Halted bool `json:"halted,omitempty"`
+	// band the aggregate was judged against
+	Band *DeviationBand `json:"band,omitempty"`
+}
+
+// DeviationBand is the history band consensus judged an aggregate against
+type DeviationBand struct {
+	Mode       string  `json:"mode"`
+	Checked    float64 `json:"checked"` // value compared to the band
+	Reference  float64 `json:"reference"`
+	Lower      float64 `json:"lower"`
+	Upper      float64 `json:"upper"`
+	Volatility float64 `json:"volatility,omitempty"`
+	Samples    int     `json:"samples"`
+}
+
+// Operator decisions on a denied issuance
+const (
+	ReviewApprove = "approve"
+	ReviewReject  = "reject"
+)
+
+// IssuanceReview is an operator decision on a denied issuance, the audit log
+type IssuanceReview struct {
+	ID         string    `json:"id"`
+	IssuanceID string    `json:"issuance_id"`
+	AssetID    string    `json:"asset_id"`
+	Decision   string    `json:"decision"`
+	Reason     string    `json:"reason"`
+	Operator   string    `json:"operator"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ReviewItem is a denied issuance waiting for an operator, with what
+// consensus saw when it denied it
+type ReviewItem struct {
+	Issuance     Issuance  `json:"issuance"`
+	LastApproved *Issuance `json:"last_approved,omitempty"`
+}
+
+type ReviewRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// SignedObservation is one node's aggregate for a consensus round
+type SignedObservation struct {
+	AssetID   string `json:"asset_id"`
+	RoundID   uint64 `json:"round_id"`
+	Value     string `json:"value"` // decimal integer, expo -18
+	Timestamp int64  `json:"timestamp"`
+	Node      string `json:"node"`      // signer address
+	Signature string `json:"signature"` // 0x prefixed, 65 bytes
+}
+
+// ConsensusReport is a value a quorum of nodes signed off on
+type ConsensusReport struct {
+	AssetID      string              `json:"asset_id"`
+	RoundID      uint64              `json:"round_id"`
+	Value        float64             `json:"value"` // median of the agreeing observations, expo -18
+	Leader       string              `json:"leader"`
+	IsLeader     bool                `json:"-"` // whether this node relays the report
+	Observations []SignedObservation `json:"observations"`
+	CreatedAt    time.Time           `json:"created_at"`
+}
+
+// RegistryVersion identifies the asset registry in use
+type RegistryVersion struct {
+	ID       string    `json:"id"` // hash of the asset configs
+	LoadedAt time.Time `json:"loaded_at"`
+	Source   string    `json:"source"` // "startup", "file" or "sighup"
+	Assets   int       `json:"assets"`
+}
+
+// RegistryStatus is the active registry version and the last reload
+// that was refused
+type RegistryStatus struct {
+	Active      RegistryVersion `json:"active"`
+	LastError   string          `json:"last_error,omitempty"`
+	LastErrorAt *time.Time      `json:"last_error_at,omitempty"`
+}
+
+// RPCEndpointStatus is the health of one rpc endpoint of a chain, rank 0
+// is the endpoint requests go to first
+type RPCEndpointStatus struct {
+	ChainID             string     `json:"chain_id"`
+	Endpoint            string     `json:"endpoint"` // host only, urls carry api keys
+	Rank                int        `json:"rank"`
+	Healthy             bool       `json:"healthy"`
+	Head                uint64     `json:"head"`
+	BlocksBehind        uint64     `json:"blocks_behind"`
+	LatencyMs           float64    `json:"latency_ms"`
+	Requests            uint64     `json:"requests"`
+	Failures            uint64     `json:"failures"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	LastError           string     `json:"last_error,omitempty"`
+	LastErrorAt         *time.Time `json:"last_error_at,omitempty"`
+	CooldownUntil       *time.Time `json:"cooldown_until,omitempty"`
+}
+
+// Reconciliation statuses of an asset on a contract
+const (
+	ReconcileOK      = "ok"
+	ReconcileDrift   = "drift"   // the contract holds a value away from the engine's
+	ReconcileStale   = "stale"   // not updated within the heartbeat
+	ReconcileMissing = "missing" // the contract never received the asset
+	ReconcileError   = "error"   // the contract could not be read
+)
+
+// ReconcileResult is the last check of one asset on one contract
+type ReconcileResult struct {
+	ChainID          string     `json:"chain_id"`
+	Contract         string     `json:"contract"`
+	AssetID          string     `json:"asset_id"`
+	Asset            string     `json:"asset"`
+	Status           string     `json:"status"`
+	Detail           string     `json:"detail,omitempty"`
+	OnChainValue     float64    `json:"on_chain_value"`
+	OnChainUpdatedAt *time.Time `json:"on_chain_updated_at,omitempty"`
+	EngineValue      float64    `json:"engine_value"`
+	EngineIssuance   string     `json:"engine_issuance,omitempty"`
+	Drift            float64    `json:"drift"`
+	CheckedAt        time.Time  `json:"checked_at"`
+	Since            time.Time  `json:"since"` // when the asset entered its status
+}
+
+// RelaySpend is one tx booked against the hourly spend cap of a chain, at
+// its max cost until its receipt settles it
+type RelaySpend struct {
+	ID      string    `json:"id"`
+	ChainID string    `json:"chain_id"`
+	Wei     string    `json:"wei"`
+	At      time.Time `json:"at"`
+}
+
+// RelayCost is the share of one asset in the cost of a mined batch tx, a
+// batch's gas and cost split evenly between its assets
+type RelayCost struct {
+	TxHash            string    `json:"tx_hash"`
+	ChainID           string    `json:"chain_id"`
+	Contract          string    `json:"contract"`
+	IssuanceID        string    `json:"issuance_id"`
+	AssetID           string    `json:"asset_id"`
+	Issuer            string    `json:"issuer"`
+	BlockNumber       uint64    `json:"block_number"`
+	BatchSize         int       `json:"batch_size"`
+	GasUsed           uint64    `json:"gas_used"`            // share of the asset
+	CostWei           string    `json:"cost_wei"`            // share of the asset
+	EffectiveGasPrice string    `json:"effective_gas_price"` // wei
+	TxGasUsed         uint64    `json:"tx_gas_used"`
+	TxCostWei         string    `json:"tx_cost_wei"` // gas and l1 fee
+	L1FeeWei          string    `json:"l1_fee_wei"`  // l1 data fee of an OP stack tx, 0 elsewhere
+	Reverted          bool      `json:"reverted"`    // a reverted tx costs gas too
+	MinedAt           time.Time `json:"mined_at"`
+}
+
+// RelayCostQuery selects the costs a report sums up
+type RelayCostQuery struct {
+	From     time.Time
+	To       time.Time
+	ChainID  string // empty is every chain
+	AssetID  string // empty is every asset
+	Interval string // hour, day, week or month
+}
+
+// RelayCostSummary is what publishing one asset on one chain cost over a period
+type RelayCostSummary struct {
+	Period           time.Time `json:"period"`
+	ChainID          string    `json:"chain_id"`
+	AssetID          string    `json:"asset_id"`
+	Asset            string    `json:"asset,omitempty"`
+	Txs              int64     `json:"txs"`
+	Updates          int64     `json:"updates"`
+	GasUsed          uint64    `json:"gas_used"`
+	CostWei          string    `json:"cost_wei"`
+	Cost             float64   `json:"cost"`               // native token
+	CostUSD          *float64  `json:"cost_usd,omitempty"` // priced with the engine's own price of the native token
+	CostPerUpdateUSD *float64  `json:"cost_per_update_usd,omitempty"`
+}
+
+// Statuses of a relayer wallet
+const (
+	WalletOK         = "ok"
+	WalletLowBalance = "low_balance" // below the chain's min balance
+	WalletLowRunway  = "low_runway"  // projected to run dry within the min runway
+	WalletError      = "error"       // the balance could not be read
+)
+
+// WalletBalance is the last check of one relayer wallet on one chain
+type WalletBalance struct {
+	ChainID      string    `json:"chain_id"`
+	Address      string    `json:"address"`
+	Status       string    `json:"status"`
+	Detail       string    `json:"detail,omitempty"`
+	BalanceWei   string    `json:"balance_wei"`
+	Balance      float64   `json:"balance"`        // native token
+	SpendPerHour float64   `json:"spend_per_hour"` // native token, over the runway window
+	RunwayHours  *float64  `json:"runway_hours,omitempty"`
+	CheckedAt    time.Time `json:"checked_at"`
+	Since        time.Time `json:"since"` // when the wallet entered its status
+}
+
+// ChainRunway sums up the relayer wallets of one chain without their
+// addresses
+type ChainRunway struct {
+	ChainID        string   `json:"chain_id"`
+	Status         string   `json:"status"` // ok, or degraded while a wallet is not ok
+	Wallets        int      `json:"wallets"`
+	NeedingFunds   int      `json:"needing_funds"`              // wallets not ok
+	MinRunwayHours *float64 `json:"min_runway_hours,omitempty"` // shortest runway of the chain's wallets
+}
+
+// Reasons the circuit breaker halts an asset
+const (
+	HaltReasonDenials = "repeated_denials"
+	HaltReasonJump    = "price_jump"
+	HaltReasonQuorum  = "quorum_loss"
+)
+
+// AssetHalt is the circuit breaker state of an asset
+type AssetHalt struct {
+	AssetID   string     `json:"asset_id"`
+	Halted    bool       `json:"halted"`
+	Reason    string     `json:"reason,omitempty"`
+	Detail    string     `json:"detail,omitempty"`
+	TrippedAt *time.Time `json:"tripped_at,omitempty"`
+	ResumedAt *time.Time `json:"resumed_at,omitempty"`
+	ResumedBy string     `json:"resumed_by,omitempty"` // operator, or "auto" after stability
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// IssuanceSkip is an aggregate the push policy chose not to issue
+type IssuanceSkip struct {
+	ID             string       `json:"id"`
+	AssetID        string       `json:"asset_id"`
+	Price          UnifiedPrice `json:"price"` // the skipped aggregate, never saved to prices
+	PriceValue     float64      `json:"price_value"`
+	LastIssuanceID string       `json:"last_issuance_id"`
+	LastPriceValue float64      `json:"last_price_value"`
+	Deviation      float64      `json:"deviation"`          // relative move from the last issuance
+	SinceLast      float64      `json:"since_last_seconds"` // time since the last issuance
+	Reason         string       `json:"reason"`
+	CreatedAt      time.Time    `json:"created_at"`
 }
 
 type PriceAudit struct {
@@ -123,11 +499,26 @@ type PriceAudit struct {
 	RawPrices       []Price      `json:"raw_prices"`
 	CreatedAt       time.Time    `json:"created_at"`
 	UpdatedAt       time.Time    `json:"updated_at"`
+	// nil for prices aggregated before provenance was recorded
+	Provenance *AggregationProvenance `json:"provenance,omitempty"`
+}
+
+// SourceReputation is the decayed track record of one source for one asset
+type SourceReputation struct {
+	AssetID   string    `json:"asset_id"`
+	Source    string    `json:"source"`
+	Score     float64   `json:"score"`             // 0..1, used as aggregation weight
+	Deviation float64   `json:"deviation"`         // mean relative deviation from the final aggregate
+	Uptime    float64   `json:"uptime"`            // share of aggregation windows the source showed up in
+	Staleness float64   `json:"staleness_seconds"` // mean sample age at aggregation time
+	Samples   int64     `json:"samples"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 type AssetData struct {
 	AssetID string `json:"asset_id"`
 	Asset   string `json:"asset"`
+	Halted  bool   `json:"halted"`
 }
 
 // CalculatePriceChange calculates the price change between two prices
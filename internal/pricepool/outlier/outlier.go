@@ -7,8 +7,14 @@ import (
 	"oracle_engine/internal/models"
 )
 
+// Need enough data for meaningful filtering
+const MinSamples = 3
+
+// Keep prices within 10% of median (configurable later)
+const MaxMedianDeviation = 0.1
+
 func FilterOutliers(prices []models.Price) []models.Price {
-	if len(prices) < 3 { // Need enough data for meaningful filtering
+	if len(prices) < MinSamples {
 		return prices
 	}
 
@@ -17,17 +23,32 @@ func FilterOutliers(prices []models.Price) []models.Price {
 	for i, p := range prices {
 		values[i] = p.Value
 	}
+	median := Median(values)
 
-	// Calculate median
-	sort.Float64s(values)
-	median := values[len(values)/2]
-
-	// Filter: Keep prices within 10% of median (configurable later)
 	var filtered []models.Price
 	for _, p := range prices {
-		if math.Abs(p.Value-median)/median <= 0.1 {
+		if !IsOutlier(p.Value, median) {
 			filtered = append(filtered, p)
 		}
 	}
 	return filtered
 }
+
+// Median of the values, the slice is left untouched
+func Median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+	return sorted[len(sorted)/2]
+}
+
+// IsOutlier reports whether value strays too far from the median
+func IsOutlier(value, median float64) bool {
+	if median == 0 {
+		return false
+	}
+	return math.Abs(value-median)/median > MaxMedianDeviation
+}
@@ -0,0 +1,96 @@
+package reconcile
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"sync"
+	"time"
+
+	"oracle_engine/internal/config"
+	"oracle_engine/internal/rpcpool"
+	"oracle_engine/internal/utils"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+
+	importVerifier "oracle_engine/pkg/abi"
+)
+
+// Callers resolves what reads the contracts of a chain
+type Callers func(chainID string) (bind.ContractCaller, error)
+
+// PoolCallers reads over the rpc endpoints of pool
+func PoolCallers(pool *rpcpool.Pool) Callers {
+	return func(chainID string) (bind.ContractCaller, error) {
+		chain, err := pool.Chain(chainID)
+		if err != nil {
+			return nil, err
+		}
+		return chain, nil
+	}
+}
+
+// chainReader reads feeds through the verifier's price feed contract
+type chainReader struct {
+	callers Callers
+
+	mu    sync.Mutex
+	feeds map[string]*importVerifier.PriceFeedReader // per verifier
+}
+
+// NewChainReader reads feeds through callers
+func NewChainReader(callers Callers) FeedReader {
+	return &chainReader{callers: callers, feeds: make(map[string]*importVerifier.PriceFeedReader)}
+}
+
+func (r *chainReader) ReadFeed(ctx context.Context, ctrct config.ContractConfig, assetID string) (Feed, error) {
+	feedContract, err := r.feedContract(ctx, ctrct)
+	if err != nil {
+		return Feed{}, err
+	}
+	info, exists, err := feedContract.GetAssetInfo(&bind.CallOpts{Context: ctx}, utils.HexToBytes32(assetID))
+	if err != nil {
+		return Feed{}, fmt.Errorf("reading asset info: %w", err)
+	}
+	if !exists || info.Price == nil || info.Price.Sign() == 0 {
+		return Feed{}, nil
+	}
+
+	value, _ := new(big.Float).SetInt(info.Price).Float64()
+	return Feed{
+		Value:     value * math.Pow10(int(info.Decimal)),
+		UpdatedAt: time.Unix(int64(info.LastUpdateTime), 0),
+		Exists:    true,
+	}, nil
+}
+
+// feedContract resolves the price feed the verifier writes to, once
+func (r *chainReader) feedContract(ctx context.Context, ctrct config.ContractConfig) (*importVerifier.PriceFeedReader, error) {
+	k := ctrct.ChainID + "|" + ctrct.Address
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if feed, ok := r.feeds[k]; ok {
+		return feed, nil
+	}
+
+	client, err := r.callers(ctrct.ChainID)
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := importVerifier.NewVerifierCaller(common.HexToAddress(ctrct.Address), client)
+	if err != nil {
+		return nil, err
+	}
+	address, err := verifier.IfaPriceFeed(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return nil, fmt.Errorf("resolving price feed of verifier %s: %w", ctrct.Address, err)
+	}
+	feed, err := importVerifier.NewPriceFeedReader(address, client)
+	if err != nil {
+		return nil, err
+	}
+	r.feeds[k] = feed
+	return feed, nil
+}
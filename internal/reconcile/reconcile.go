@@ -0,0 +1,274 @@
+package reconcile
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"oracle_engine/internal/config"
+	"oracle_engine/internal/logging"
+	"oracle_engine/internal/models"
+	"oracle_engine/internal/utils"
+
+	"go.uber.org/zap"
+)
+
+/*
+Reconcile:
+Reads the stored feed of every asset back from every contract carrying
+it and compares it with the value the engine last relayed that had
+time to land, the quorum value in multi node mode. Issuances that failed
+or never left the engine are not compared against. A contract holding a value further from it than its own
+deviation threshold plus max_drift drifted, one not updated within the
+heartbeat is stale, and one that never received an asset the engine
+issued misses it. Every status change is logged and posted to the alert
+webhook, the latest results are served by the api.
+*/
+
+// IssuanceSource is where the engine's issuances are read from
+type IssuanceSource interface {
+	GetLastRelayedIssuanceBefore(ctx context.Context, assetID string, before time.Time) (*models.Issuance, error)
+}
+
+// Feed is what a contract holds for an asset
+type Feed struct {
+	Value     float64
+	UpdatedAt time.Time
+	Exists    bool
+}
+
+// FeedReader reads the stored feed of an asset from a contract
+type FeedReader interface {
+	ReadFeed(ctx context.Context, ctrct config.ContractConfig, assetID string) (Feed, error)
+}
+
+// Assets is the live asset registry
+type Assets interface {
+	Assets() []config.AssetConfig
+	Setting(assetID string) config.AssetSetting
+}
+
+type Monitor struct {
+	cfg       config.ReconcileConfig
+	contracts []config.ContractConfig
+	assets    Assets
+	carries   func(ctrct config.ContractConfig, assetID string) bool
+	issuances IssuanceSource
+	reader    FeedReader
+	http      *http.Client
+	now       func() time.Time
+
+	mu      sync.RWMutex
+	results map[string]models.ReconcileResult
+}
+
+func New(cfg *config.Config, assets Assets, carries func(config.ContractConfig, string) bool, issuances IssuanceSource, reader FeedReader) *Monitor {
+	rc := cfg.Reconcile
+	if rc.IntervalSeconds <= 0 {
+		rc.IntervalSeconds = 60
+	}
+	if rc.StaleGraceSeconds <= 0 {
+		rc.StaleGraceSeconds = 300
+	}
+	return &Monitor{
+		cfg:       rc,
+		contracts: cfg.Contracts,
+		assets:    assets,
+		carries:   carries,
+		issuances: issuances,
+		reader:    reader,
+		http:      &http.Client{Timeout: 5 * time.Second},
+		now:       time.Now,
+		results:   make(map[string]models.ReconcileResult),
+	}
+}
+
+// Start checks every contract each interval until ctx is done
+func (m *Monitor) Start(ctx context.Context) {
+	if !m.cfg.Enabled {
+		return
+	}
+	ticker := time.NewTicker(time.Duration(m.cfg.IntervalSeconds) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		m.Check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check reconciles every carried asset on every contract once
+func (m *Monitor) Check(ctx context.Context) {
+	for _, ctrct := range m.contracts {
+		for _, asset := range m.assets.Assets() {
+			assetID := utils.GenerateIDForAsset(asset.InternalAssetIdentity)
+			if m.carries != nil && !m.carries(ctrct, assetID) {
+				continue
+			}
+			result, ok := m.check(ctx, ctrct, asset, assetID)
+			if ok {
+				m.record(ctx, result)
+			}
+		}
+	}
+}
+
+// check compares one asset on one contract, false when there is nothing
+// to compare against yet
+func (m *Monitor) check(ctx context.Context, ctrct config.ContractConfig, asset config.AssetConfig, assetID string) (models.ReconcileResult, bool) {
+	now := m.now()
+	grace := time.Duration(m.cfg.StaleGraceSeconds) * time.Second
+	result := models.ReconcileResult{
+		ChainID:   ctrct.ChainID,
+		Contract:  ctrct.Address,
+		AssetID:   assetID,
+		Asset:     asset.Name,
+		Status:    models.ReconcileOK,
+		CheckedAt: now,
+	}
+
+	// issuances younger than the grace may still be on their way
+	issuance, err := m.issuances.GetLastRelayedIssuanceBefore(ctx, assetID, now.Add(-grace))
+	if err != nil {
+		result.Status = models.ReconcileError
+		result.Detail = fmt.Sprintf("reading engine issuance: %v", err)
+		return result, true
+	}
+	if issuance == nil {
+		return result, false
+	}
+	result.EngineValue = issuance.PriceValue
+	result.EngineIssuance = issuance.ID
+
+	feed, err := m.reader.ReadFeed(ctx, ctrct, assetID)
+	if err != nil {
+		result.Status = models.ReconcileError
+		result.Detail = err.Error()
+		return result, true
+	}
+	if !feed.Exists {
+		result.Status = models.ReconcileMissing
+		result.Detail = "contract never received the asset"
+		return result, true
+	}
+	result.OnChainValue = feed.Value
+	updatedAt := feed.UpdatedAt
+	result.OnChainUpdatedAt = &updatedAt
+	if issuance.PriceValue != 0 {
+		result.Drift = math.Abs(feed.Value-issuance.PriceValue) / math.Abs(issuance.PriceValue)
+	}
+
+	// a contract with a deviation threshold but no heartbeat of its own
+	// never gets an unmoved asset again
+	heartbeat := ctrct.Heartbeat
+	if heartbeat <= 0 && ctrct.DeviationThreshold <= 0 {
+		heartbeat = m.assets.Setting(assetID).Heartbeat
+	}
+	allowed := ctrct.DeviationThreshold + m.cfg.MaxDrift
+
+	switch {
+	case result.Drift > allowed:
+		result.Status = models.ReconcileDrift
+		result.Detail = fmt.Sprintf("%.4f%% away from the engine, %.4f%% allowed", result.Drift*100, allowed*100)
+	case heartbeat > 0 && now.Sub(feed.UpdatedAt) > time.Duration(heartbeat)*time.Second+grace:
+		result.Status = models.ReconcileStale
+		result.Detail = fmt.Sprintf("last updated %s ago, heartbeat %ds", now.Sub(feed.UpdatedAt).Round(time.Second), heartbeat)
+	}
+	return result, true
+}
+
+// record keeps the result and alerts when the status changed
+func (m *Monitor) record(ctx context.Context, result models.ReconcileResult) {
+	k := result.ChainID + "|" + result.Contract + "|" + result.AssetID
+
+	m.mu.Lock()
+	prev, seen := m.results[k]
+	changed := !seen || prev.Status != result.Status
+	if changed {
+		result.Since = result.CheckedAt
+	} else {
+		result.Since = prev.Since
+	}
+	m.results[k] = result
+	m.mu.Unlock()
+
+	// a first clean check is not worth an alert
+	if !changed || (!seen && result.Status == models.ReconcileOK) {
+		return
+	}
+	m.alert(ctx, prev, result)
+}
+
+func (m *Monitor) alert(ctx context.Context, prev, result models.ReconcileResult) {
+	fields := []zap.Field{
+		zap.String("chainID", result.ChainID),
+		zap.String("contract", result.Contract),
+		zap.String("asset", result.Asset),
+		zap.String("status", result.Status),
+		zap.String("previous", prev.Status),
+		zap.String("detail", result.Detail),
+		zap.Float64("onChain", result.OnChainValue),
+		zap.Float64("engine", result.EngineValue),
+	}
+	if result.Status == models.ReconcileOK {
+		logging.Logger.Info("On chain feed reconciled", fields...)
+	} else {
+		logging.Logger.Error("On chain feed out of sync", fields...)
+	}
+
+	if m.cfg.AlertWebhook == "" {
+		return
+	}
+	body, err := json.Marshal(result)
+	if err != nil {
+		return
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.cfg.AlertWebhook, bytes.NewReader(body))
+	if err != nil {
+		logging.Logger.Warn("Invalid reconcile alert webhook", zap.Error(err))
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := m.http.Do(req)
+	if err != nil {
+		logging.Logger.Warn("Failed to post reconcile alert", zap.Error(err))
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		logging.Logger.Warn("Reconcile alert webhook refused the alert", zap.Int("status", resp.StatusCode))
+	}
+}
+
+// Results lists the latest check of every asset on every contract, the
+// ones out of sync first
+func (m *Monitor) Results() []models.ReconcileResult {
+	m.mu.RLock()
+	results := make([]models.ReconcileResult, 0, len(m.results))
+	for _, result := range m.results {
+		results = append(results, result)
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if (a.Status == models.ReconcileOK) != (b.Status == models.ReconcileOK) {
+			return b.Status == models.ReconcileOK
+		}
+		if a.ChainID != b.ChainID {
+			return a.ChainID < b.ChainID
+		}
+		return a.Asset < b.Asset
+	})
+	return results
+}
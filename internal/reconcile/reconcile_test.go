@@ -0,0 +1,118 @@
+package reconcile
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"oracle_engine/internal/config"
+	"oracle_engine/internal/logging"
+	"oracle_engine/internal/models"
+	"oracle_engine/internal/utils"
+
+	"go.uber.org/zap"
+)
+
+var usdtID = utils.GenerateIDForAsset("0xUSDT")
+
+type staticAssets struct{}
+
+func (staticAssets) Assets() []config.AssetConfig {
+	return []config.AssetConfig{{Name: "USDT/USD", InternalAssetIdentity: "0xUSDT"}}
+}
+
+func (staticAssets) Setting(assetID string) config.AssetSetting {
+	return config.AssetSetting{Heartbeat: 3600}
+}
+
+type lastIssuance struct{ issuance *models.Issuance }
+
+func (l lastIssuance) GetLastRelayedIssuanceBefore(ctx context.Context, assetID string, before time.Time) (*models.Issuance, error) {
+	return l.issuance, nil
+}
+
+type staticFeed struct{ feed Feed }
+
+func (s *staticFeed) ReadFeed(ctx context.Context, ctrct config.ContractConfig, assetID string) (Feed, error) {
+	return s.feed, nil
+}
+
+func newMonitor(t *testing.T, ctrct config.ContractConfig, feed *staticFeed, webhook string) *Monitor {
+	t.Helper()
+	logging.Logger = zap.NewNop()
+	cfg := &config.Config{
+		Contracts: []config.ContractConfig{ctrct},
+		Reconcile: config.ReconcileConfig{Enabled: true, MaxDrift: 0.01, StaleGraceSeconds: 300, AlertWebhook: webhook},
+	}
+	issuance := &models.Issuance{ID: "iss-1", PriceValue: 1.0}
+	return New(cfg, staticAssets{}, nil, lastIssuance{issuance}, feed)
+}
+
+func TestFlagsDriftStalenessAndMissingAssets(t *testing.T) {
+	now := time.Unix(1_750_000_000, 0)
+	feed := &staticFeed{feed: Feed{Value: 1.001, UpdatedAt: now.Add(-time.Minute), Exists: true}}
+	m := newMonitor(t, config.ContractConfig{ChainID: "8453", Address: "0x02"}, feed, "")
+	m.now = func() time.Time { return now }
+
+	cases := []struct {
+		feed Feed
+		want string
+	}{
+		{Feed{Value: 1.001, UpdatedAt: now.Add(-time.Minute), Exists: true}, models.ReconcileOK},
+		{Feed{Value: 1.05, UpdatedAt: now.Add(-time.Minute), Exists: true}, models.ReconcileDrift},
+		{Feed{Value: 1.0, UpdatedAt: now.Add(-2 * time.Hour), Exists: true}, models.ReconcileStale},
+		{Feed{}, models.ReconcileMissing},
+	}
+	for _, c := range cases {
+		feed.feed = c.feed
+		m.Check(context.Background())
+		results := m.Results()
+		if len(results) != 1 || results[0].Status != c.want {
+			t.Fatalf("feed %+v reconciled as %+v, want %s", c.feed, results, c.want)
+		}
+	}
+}
+
+func TestContractThresholdWidensAllowedDrift(t *testing.T) {
+	now := time.Unix(1_750_000_000, 0)
+	feed := &staticFeed{feed: Feed{Value: 1.03, UpdatedAt: now.Add(-time.Minute), Exists: true}}
+	m := newMonitor(t, config.ContractConfig{ChainID: "8453", Address: "0x02", DeviationThreshold: 0.05}, feed, "")
+	m.now = func() time.Time { return now }
+
+	m.Check(context.Background())
+	if status := m.Results()[0].Status; status != models.ReconcileOK {
+		t.Fatalf("3%% off on a contract publishing on 5%% moves reconciled as %s", status)
+	}
+}
+
+func TestAlertsOnStatusChangesOnly(t *testing.T) {
+	alerts := make(chan models.ReconcileResult, 8)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var result models.ReconcileResult
+		_ = json.NewDecoder(r.Body).Decode(&result)
+		alerts <- result
+	}))
+	defer srv.Close()
+
+	now := time.Unix(1_750_000_000, 0)
+	feed := &staticFeed{feed: Feed{Value: 1.0, UpdatedAt: now, Exists: true}}
+	m := newMonitor(t, config.ContractConfig{ChainID: "8453", Address: "0x02"}, feed, srv.URL)
+	m.now = func() time.Time { return now }
+
+	m.Check(context.Background()) // ok, no alert
+	feed.feed.Value = 1.2
+	m.Check(context.Background()) // drift
+	m.Check(context.Background()) // still drifting
+	feed.feed.Value = 1.0
+	m.Check(context.Background()) // recovered
+
+	if len(alerts) != 2 {
+		t.Fatalf("got %d alerts, want one for the drift and one for the recovery", len(alerts))
+	}
+	if first := <-alerts; first.Status != models.ReconcileDrift || first.AssetID != usdtID {
+		t.Fatalf("first alert %+v, want the drift of USDT", first)
+	}
+}
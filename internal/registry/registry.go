@@ -0,0 +1,329 @@
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"oracle_engine/internal/config"
+	"oracle_engine/internal/logging"
+	"oracle_engine/internal/models"
+	"oracle_engine/internal/utils"
+
+	"go.uber.org/zap"
+)
+
+/*
+Registry:
+The per asset settings keyed by hashed asset id, shared by the
+aggregator, consensus and the api. A reload on a config file change or
+SIGHUP is validated as a whole before it is swapped in, a broken file
+leaves the active version in place. Only the assets section reloads,
+feeds and everything else still need a restart.
+*/
+
+// Change is what a reload swapped in
+type Change struct {
+	Version models.RegistryVersion
+	Added   []config.AssetConfig
+	Updated []config.AssetConfig
+	Removed []string // asset ids
+}
+
+type Registry struct {
+	load func() (*config.Config, error)
+	now  func() time.Time
+
+	mu          sync.RWMutex
+	assets      map[string]config.AssetConfig // keyed by asset id, settings with defaults
+	order       []string
+	version     models.RegistryVersion
+	lastError   string
+	lastErrorAt *time.Time
+	subscribers []func(Change)
+}
+
+// New builds the registry from the loaded config, reloads read it again
+func New(cfg *config.Config) (*Registry, error) {
+	r := &Registry{load: config.Read, now: time.Now}
+	if _, err := r.swap(cfg.Assets, "startup"); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Setting returns the settings of an asset, defaults when unknown
+func (r *Registry) Setting(assetID string) config.AssetSetting {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if asset, ok := r.assets[assetID]; ok {
+		return asset.Settings
+	}
+	return config.DefaultAssetSetting
+}
+
+// Asset returns the config of an asset by id
+func (r *Registry) Asset(assetID string) (config.AssetConfig, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	asset, ok := r.assets[assetID]
+	return asset, ok
+}
+
+// Assets returns every asset in config order
+func (r *Registry) Assets() []config.AssetConfig {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	assets := make([]config.AssetConfig, 0, len(r.order))
+	for _, id := range r.order {
+		assets = append(assets, r.assets[id])
+	}
+	return assets
+}
+
+func (r *Registry) Version() models.RegistryVersion {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.version
+}
+
+func (r *Registry) Status() models.RegistryStatus {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return models.RegistryStatus{
+		Active:      r.version,
+		LastError:   r.lastError,
+		LastErrorAt: r.lastErrorAt,
+	}
+}
+
+// Subscribe calls fn with every change a reload swaps in
+func (r *Registry) Subscribe(fn func(Change)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subscribers = append(r.subscribers, fn)
+}
+
+// Reload reads the config again and swaps in its assets when they are valid
+func (r *Registry) Reload(source string) error {
+	cfg, err := r.load()
+	if err == nil {
+		var change *Change
+		change, err = r.swap(cfg.Assets, source)
+		if err == nil {
+			if change != nil {
+				r.notify(*change)
+			}
+			return nil
+		}
+	}
+
+	now := r.now()
+	r.mu.Lock()
+	r.lastError = err.Error()
+	r.lastErrorAt = &now
+	active := r.version.ID
+	r.mu.Unlock()
+
+	logging.Logger.Error("Asset registry reload refused, keeping active version",
+		zap.String("source", source),
+		zap.String("version", active),
+		zap.Error(err),
+	)
+	return err
+}
+
+// Watch reloads on SIGHUP and when the config file changes, polled every interval
+func (r *Registry) Watch(ctx context.Context, path string, interval time.Duration) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last := fileStamp(path)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			r.Reload("sighup")
+		case <-ticker.C:
+			if path == "" {
+				continue
+			}
+			stamp := fileStamp(path)
+			if stamp == last {
+				continue
+			}
+			last = stamp
+			r.Reload("file")
+		}
+	}
+}
+
+// swap validates assets and makes them active, nil change when nothing changed
+func (r *Registry) swap(assets []config.AssetConfig, source string) (*Change, error) {
+	if err := Validate(assets); err != nil {
+		return nil, err
+	}
+
+	next := make(map[string]config.AssetConfig, len(assets))
+	order := make([]string, 0, len(assets))
+	for _, asset := range assets {
+		asset.Settings = asset.Settings.WithDefaults()
+		id := utils.GenerateIDForAsset(asset.InternalAssetIdentity)
+		next[id] = asset
+		order = append(order, id)
+	}
+	versionID, err := hashAssets(assets)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if versionID == r.version.ID {
+		return nil, nil
+	}
+
+	change := &Change{}
+	for _, id := range order {
+		prev, ok := r.assets[id]
+		switch {
+		case !ok:
+			change.Added = append(change.Added, next[id])
+		case !sameAsset(prev, next[id]):
+			change.Updated = append(change.Updated, next[id])
+		}
+	}
+	for id := range r.assets {
+		if _, ok := next[id]; !ok {
+			change.Removed = append(change.Removed, id)
+		}
+	}
+
+	r.assets = next
+	r.order = order
+	r.version = models.RegistryVersion{
+		ID:       versionID,
+		LoadedAt: r.now(),
+		Source:   source,
+		Assets:   len(order),
+	}
+	r.lastError = ""
+	r.lastErrorAt = nil
+	change.Version = r.version
+
+	logging.Logger.Info("Asset registry version active",
+		zap.String("version", versionID),
+		zap.String("source", source),
+		zap.Int("assets", len(order)),
+		zap.Int("added", len(change.Added)),
+		zap.Int("updated", len(change.Updated)),
+		zap.Int("removed", len(change.Removed)),
+	)
+	return change, nil
+}
+
+func (r *Registry) notify(change Change) {
+	r.mu.RLock()
+	subscribers := append([]func(Change){}, r.subscribers...)
+	r.mu.RUnlock()
+	for _, fn := range subscribers {
+		fn(change)
+	}
+}
+
+// Validate checks a full set of assets, one bad asset refuses them all
+func Validate(assets []config.AssetConfig) error {
+	if len(assets) == 0 {
+		return fmt.Errorf("no assets configured")
+	}
+
+	seen := make(map[string]string, len(assets))
+	for i, asset := range assets {
+		if strings.TrimSpace(asset.Name) == "" {
+			return fmt.Errorf("asset %d has no name", i)
+		}
+		if strings.TrimSpace(asset.InternalAssetIdentity) == "" {
+			return fmt.Errorf("asset %s has no internalAssetIdentity", asset.Name)
+		}
+		id := utils.GenerateIDForAsset(asset.InternalAssetIdentity)
+		if other, ok := seen[id]; ok {
+			return fmt.Errorf("assets %s and %s share internalAssetIdentity %s", other, asset.Name, asset.InternalAssetIdentity)
+		}
+		seen[id] = asset.Name
+
+		if err := validateSetting(asset.Settings); err != nil {
+			return fmt.Errorf("asset %s: %w", asset.Name, err)
+		}
+	}
+	return nil
+}
+
+func validateSetting(s config.AssetSetting) error {
+	switch {
+	case s.DevPerc < 0:
+		return fmt.Errorf("devPerc %v is negative", s.DevPerc)
+	case s.TTL < 0:
+		return fmt.Errorf("TTL %v is negative", s.TTL)
+	case s.MinSources < 0:
+		return fmt.Errorf("min_sources %d is negative", s.MinSources)
+	case s.Heartbeat < 0:
+		return fmt.Errorf("heartbeat %d is negative", s.Heartbeat)
+	case s.HistoryWindow < 0:
+		return fmt.Errorf("history_window %d is negative", s.HistoryWindow)
+	case s.EWMAAlpha < 0 || s.EWMAAlpha > 1:
+		return fmt.Errorf("ewma_alpha %v outside 0..1", s.EWMAAlpha)
+	case s.BreakerMaxJump < 0:
+		return fmt.Errorf("breaker_max_jump %v is negative", s.BreakerMaxJump)
+	}
+
+	switch strings.ToLower(s.QuorumAction) {
+	case "", config.QuorumActionDegrade, config.QuorumActionWithhold:
+	default:
+		return fmt.Errorf("unknown quorum_action %q", s.QuorumAction)
+	}
+	switch strings.ToLower(s.HistoryMode) {
+	case "", config.HistoryModeLinear, config.HistoryModeEWMA, config.HistoryModeVolatility:
+	default:
+		return fmt.Errorf("unknown history_mode %q", s.HistoryMode)
+	}
+	return nil
+}
+
+func hashAssets(assets []config.AssetConfig) (string, error) {
+	data, err := json.Marshal(assets)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:12], nil
+}
+
+func sameAsset(a, b config.AssetConfig) bool {
+	x, errX := json.Marshal(a)
+	y, errY := json.Marshal(b)
+	return errX == nil && errY == nil && string(x) == string(y)
+}
+
+func fileStamp(path string) string {
+	if path == "" {
+		return ""
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%d|%d", info.ModTime().UnixNano(), info.Size())
+}
@@ -0,0 +1,105 @@
+package registry
+
+import (
+	"errors"
+	"testing"
+
+	"oracle_engine/internal/config"
+	"oracle_engine/internal/logging"
+	"oracle_engine/internal/utils"
+
+	"go.uber.org/zap"
+)
+
+func asset(name, identity string, devPerc float32) config.AssetConfig {
+	return config.AssetConfig{
+		Name:                  name,
+		InternalAssetIdentity: identity,
+		Settings:              config.AssetSetting{DevPerc: devPerc},
+	}
+}
+
+func newTestRegistry(t *testing.T, assets ...config.AssetConfig) (*Registry, *[]config.AssetConfig) {
+	t.Helper()
+	logging.Logger = zap.NewNop()
+
+	r, err := New(&config.Config{Assets: assets})
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := &[]config.AssetConfig{}
+	r.load = func() (*config.Config, error) { return &config.Config{Assets: *next}, nil }
+	return r, next
+}
+
+func TestSettingsAreKeyedByHashedAssetID(t *testing.T) {
+	r, _ := newTestRegistry(t, asset("USDT/USD", "0xUSDT", 0.05))
+
+	if got := r.Setting(utils.GenerateIDForAsset("0xUSDT")).DevPerc; got != 0.05 {
+		t.Fatalf("hashed id resolved dev_perc %v, want the configured 0.05", got)
+	}
+	if got := r.Setting("0xUSDT").DevPerc; got != config.DefaultAssetSetting.DevPerc {
+		t.Fatalf("raw identity resolved dev_perc %v, want the default", got)
+	}
+}
+
+func TestReloadSwapsAndReportsChanges(t *testing.T) {
+	r, next := newTestRegistry(t, asset("USDT/USD", "0xUSDT", 0.05), asset("USDC/USD", "0xUSDC", 0.05))
+	startup := r.Version()
+
+	var change Change
+	r.Subscribe(func(c Change) { change = c })
+	*next = []config.AssetConfig{asset("USDT/USD", "0xUSDT", 0.02), asset("CNGN/USD", "0xCNGN", 0.05)}
+	if err := r.Reload("file"); err != nil {
+		t.Fatal(err)
+	}
+
+	if r.Version().ID == startup.ID || r.Version().Source != "file" {
+		t.Fatalf("version did not move on reload: %+v", r.Version())
+	}
+	if len(change.Added) != 1 || len(change.Updated) != 1 || len(change.Removed) != 1 {
+		t.Fatalf("unexpected change %+v", change)
+	}
+	if got := r.Setting(utils.GenerateIDForAsset("0xUSDT")).DevPerc; got != 0.02 {
+		t.Fatalf("reloaded dev_perc %v, want 0.02", got)
+	}
+}
+
+func TestInvalidReloadKeepsActiveVersion(t *testing.T) {
+	r, next := newTestRegistry(t, asset("USDT/USD", "0xUSDT", 0.05))
+	active := r.Version()
+
+	bad := asset("USDT/USD", "0xUSDT", 0.05)
+	bad.Settings.HistoryMode = "median"
+	*next = []config.AssetConfig{bad}
+	if err := r.Reload("sighup"); err == nil {
+		t.Fatal("accepted an unknown history mode")
+	}
+	*next = []config.AssetConfig{asset("A", "0xA", 0.05), asset("B", "0xA", 0.05)}
+	if err := r.Reload("sighup"); err == nil {
+		t.Fatal("accepted two assets with the same identity")
+	}
+	r.load = func() (*config.Config, error) { return nil, errors.New("yaml: line 3: bad indentation") }
+	if err := r.Reload("file"); err == nil {
+		t.Fatal("accepted an unreadable config")
+	}
+
+	status := r.Status()
+	if status.Active != active || status.LastError == "" {
+		t.Fatalf("refused reloads changed the registry: %+v", status)
+	}
+}
+
+func TestZeroDeviationThresholdIsKept(t *testing.T) {
+	zero := 0.0
+	everyChange := asset("USDT/USD", "0xUSDT", 0.05)
+	everyChange.Settings.DeviationThreshold = &zero
+	r, _ := newTestRegistry(t, everyChange, asset("USDC/USD", "0xUSDC", 0.05))
+
+	if got := r.Setting(utils.GenerateIDForAsset("0xUSDT")).DeviationThreshold; got == nil || *got != 0 {
+		t.Fatalf("explicit 0 deviation_threshold resolved to %v", got)
+	}
+	if got := r.Setting(utils.GenerateIDForAsset("0xUSDC")).DeviationThreshold; got == nil || *got != *config.DefaultAssetSetting.DeviationThreshold {
+		t.Fatalf("unset deviation_threshold resolved to %v, want the default", got)
+	}
+}
@@ -0,0 +1,135 @@
+package relayer
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"oracle_engine/internal/database/timescale"
+	"oracle_engine/internal/logging"
+	"oracle_engine/internal/models"
+
+	"github.com/ethereum/go-ethereum/common"
+	"go.uber.org/zap"
+)
+
+// CostStore keeps what every mined batch tx cost, per asset
+type CostStore interface {
+	SaveRelayCosts(ctx context.Context, costs []models.RelayCost) error
+	DeleteRelayCosts(ctx context.Context, txHash string) error
+}
+
+// costStore keeps a nil db from becoming a non nil store
+func costStore(db *timescale.TimescaleDB) CostStore {
+	if db == nil {
+		return nil
+	}
+	return db
+}
+
+// batchCosts splits the gas and cost of a mined batch evenly between its
+// assets, the first ones take the remainder so the shares add up to the tx
+func batchCosts(t *trackedTx, minedAt time.Time) []models.RelayCost {
+	n := len(t.issuances)
+	if n == 0 {
+		return nil
+	}
+	total := txCost(t)
+	l1Fee := new(big.Int)
+	if t.l1Fee != nil {
+		l1Fee.Set(t.l1Fee)
+	}
+	price := new(big.Int)
+	if t.receipt.EffectiveGasPrice != nil {
+		price.Set(t.receipt.EffectiveGasPrice)
+	}
+
+	gasShare, gasRest := t.receipt.GasUsed/uint64(n), t.receipt.GasUsed%uint64(n)
+	costShare, costRest := new(big.Int).QuoRem(total, big.NewInt(int64(n)), new(big.Int))
+	rest := costRest.Int64()
+
+	costs := make([]models.RelayCost, n)
+	for i, issuance := range t.issuances {
+		gas := gasShare
+		if uint64(i) < gasRest {
+			gas++
+		}
+		cost := new(big.Int).Set(costShare)
+		if int64(i) < rest {
+			cost.Add(cost, big.NewInt(1))
+		}
+		costs[i] = models.RelayCost{
+			TxHash:            t.mined.Hash().Hex(),
+			ChainID:           t.ctrct.ChainID,
+			Contract:          t.ctrct.Address,
+			IssuanceID:        issuance.ID,
+			AssetID:           issuance.Price.AssetID,
+			Issuer:            t.from.Hex(),
+			BlockNumber:       t.receipt.BlockNumber.Uint64(),
+			BatchSize:         n,
+			GasUsed:           gas,
+			CostWei:           cost.String(),
+			EffectiveGasPrice: price.String(),
+			TxGasUsed:         t.receipt.GasUsed,
+			TxCostWei:         total.String(),
+			L1FeeWei:          l1Fee.String(),
+			Reverted:          t.receipt.Status == 0,
+			MinedAt:           minedAt,
+		}
+	}
+	return costs
+}
+
+// l1FeeReader is a client that reads the l1 data fee OP stack chains add
+// to a receipt, go-ethereum's receipt drops it
+type l1FeeReader interface {
+	L1Fee(ctx context.Context, txHash common.Hash) (*big.Int, error)
+}
+
+// readL1Fee is the l1 data fee of the mined tx of t, nil on chains without
+// one. A fee that cannot be read is left out of the cost.
+func readL1Fee(ctx context.Context, t *trackedTx) *big.Int {
+	reader, ok := t.client.(l1FeeReader)
+	if !ok {
+		return nil
+	}
+	fee, err := reader.L1Fee(ctx, t.mined.Hash())
+	if err != nil {
+		logging.Logger.Warn("Failed to read l1 fee of price feed tx",
+			zap.String("tx", t.mined.Hash().Hex()), zap.String("chainID", t.ctrct.ChainID), zap.Error(err))
+		return nil
+	}
+	return fee
+}
+
+// txCost is what the mined tx of t cost, its gas and l1 fee
+func txCost(t *trackedTx) *big.Int {
+	total := receiptCost(t.receipt)
+	if t.l1Fee != nil {
+		total.Add(total, t.l1Fee)
+	}
+	return total
+}
+
+// recordCosts books a mined batch tx against its assets
+func (r *Relayer) recordCosts(ctx context.Context, t *trackedTx) {
+	if r.costs == nil {
+		return
+	}
+	if err := r.costs.SaveRelayCosts(ctx, batchCosts(t, time.Now())); err != nil {
+		logging.Logger.Error("Failed to record price feed tx cost",
+			zap.String("tx", t.mined.Hash().Hex()), zap.String("chainID", t.ctrct.ChainID), zap.Error(err))
+	}
+}
+
+// forgetCosts drops the costs of a tx reorged out, it is booked again
+// once mined anew
+func (r *Relayer) forgetCosts(ctx context.Context, t *trackedTx) {
+	if r.costs == nil {
+		return
+	}
+	if err := r.costs.DeleteRelayCosts(ctx, t.mined.Hash().Hex()); err != nil {
+		logging.Logger.Error("Failed to drop cost of reorged price feed tx",
+			zap.String("tx", t.mined.Hash().Hex()), zap.String("chainID", t.ctrct.ChainID), zap.Error(err))
+	}
+}
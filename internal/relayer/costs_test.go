@@ -0,0 +1,105 @@
+package relayer
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"oracle_engine/internal/models"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// memoryCosts keeps the costs of every tx by hash
+type memoryCosts map[string][]models.RelayCost
+
+func (m memoryCosts) SaveRelayCosts(ctx context.Context, costs []models.RelayCost) error {
+	for _, cost := range costs {
+		m[cost.TxHash] = costs
+	}
+	return nil
+}
+
+func (m memoryCosts) DeleteRelayCosts(ctx context.Context, txHash string) error {
+	delete(m, txHash)
+	return nil
+}
+
+func TestBatchCostIsSplitBetweenItsAssets(t *testing.T) {
+	h := newTrackerHarness(t)
+	costs := memoryCosts{}
+	h.relayer.costs = costs
+	h.tracked.issuances = []*models.Issuance{
+		{ID: "a", Price: models.UnifiedPrice{AssetID: "usdt"}},
+		{ID: "b", Price: models.UnifiedPrice{AssetID: "cngn"}},
+		{ID: "c", Price: models.UnifiedPrice{AssetID: "brz"}},
+	}
+	tx := h.tracked.attempts[0]
+	h.chain.mine(tx, types.ReceiptStatusSuccessful, common.HexToHash("0xa"))
+	h.chain.receipts[tx.Hash()].EffectiveGasPrice = big.NewInt(7)
+	h.poll()
+
+	shares := costs[tx.Hash().Hex()]
+	if len(shares) != 3 {
+		t.Fatalf("booked %d shares, want one per asset", len(shares))
+	}
+	// 50000 gas at 7 wei, 350000 wei over three assets
+	gas, wei := uint64(0), new(big.Int)
+	for _, share := range shares {
+		cost, _ := new(big.Int).SetString(share.CostWei, 10)
+		gas += share.GasUsed
+		wei.Add(wei, cost)
+		if share.BatchSize != 3 || share.TxCostWei != "350000" || share.EffectiveGasPrice != "7" {
+			t.Fatalf("share %+v", share)
+		}
+	}
+	if gas != 50_000 || wei.Int64() != 350_000 || shares[0].CostWei != "116667" || shares[2].CostWei != "116666" {
+		t.Fatalf("shares add up to %d gas and %s wei, first %s", gas, wei, shares[0].CostWei)
+	}
+
+	// a reorg takes the cost back until the tx is mined again
+	h.chain.mu.Lock()
+	delete(h.chain.receipts, tx.Hash())
+	h.chain.mu.Unlock()
+	h.poll()
+	if _, ok := costs[tx.Hash().Hex()]; ok {
+		t.Fatal("kept the cost of a tx reorged out")
+	}
+	h.chain.mine(tx, types.ReceiptStatusSuccessful, common.HexToHash("0xb"))
+	h.poll()
+	if shares := costs[tx.Hash().Hex()]; len(shares) != 3 || shares[0].BlockNumber != h.chain.head {
+		t.Fatal("did not book the tx mined again")
+	}
+}
+
+// opStackChain charges an l1 data fee on top of the gas of every tx
+type opStackChain struct {
+	*fakeChain
+	l1Fee *big.Int
+}
+
+func (c opStackChain) L1Fee(ctx context.Context, txHash common.Hash) (*big.Int, error) {
+	return c.l1Fee, nil
+}
+
+func TestBatchCostIncludesTheL1Fee(t *testing.T) {
+	h := newTrackerHarness(t)
+	costs := memoryCosts{}
+	h.relayer.costs = costs
+	h.tracked.client = opStackChain{fakeChain: h.chain, l1Fee: big.NewInt(1000)}
+	h.tracked.issuances = []*models.Issuance{
+		{ID: "a", Price: models.UnifiedPrice{AssetID: "usdt"}},
+		{ID: "b", Price: models.UnifiedPrice{AssetID: "cngn"}},
+	}
+	tx := h.tracked.attempts[0]
+	h.chain.mine(tx, types.ReceiptStatusSuccessful, common.HexToHash("0xa"))
+	h.chain.receipts[tx.Hash()].EffectiveGasPrice = big.NewInt(7)
+	h.poll()
+
+	// 50000 gas at 7 wei and 1000 wei of l1 fee over two assets
+	shares := costs[tx.Hash().Hex()]
+	if len(shares) != 2 || shares[0].TxCostWei != "351000" || shares[0].L1FeeWei != "1000" || shares[0].CostWei != "175500" {
+		t.Fatalf("shares %+v", shares)
+	}
+}
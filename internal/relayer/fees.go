@@ -0,0 +1,340 @@
+package relayer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"oracle_engine/internal/config"
+	"oracle_engine/internal/logging"
+	"oracle_engine/internal/models"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrFeeAboveCap   = errors.New("network fee above the chain's max fee")
+	ErrSpendCapReach = errors.New("hourly spend cap of the chain reached")
+)
+
+// feeClient is what fee quoting needs from a chain, *ethclient.Client satisfies it
+type feeClient interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
+	FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*ethereum.FeeHistory, error)
+	EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error)
+}
+
+// feeQuote is the gas and fees of a tx, GasPrice set for legacy txs only
+type feeQuote struct {
+	GasLimit  uint64
+	GasPrice  *big.Int
+	GasTipCap *big.Int
+	GasFeeCap *big.Int
+}
+
+// maxCost is the most the tx can cost
+func (q feeQuote) maxCost() *big.Int {
+	price := q.GasFeeCap
+	if q.GasPrice != nil {
+		price = q.GasPrice
+	}
+	return new(big.Int).Mul(price, new(big.Int).SetUint64(q.GasLimit))
+}
+
+func (q feeQuote) apply(auth *bind.TransactOpts) {
+	auth.GasLimit = q.GasLimit
+	auth.GasPrice = q.GasPrice
+	auth.GasTipCap = q.GasTipCap
+	auth.GasFeeCap = q.GasFeeCap
+}
+
+// quoteFees prices msg with the chain's fee strategy, dynamic fees when
+// the chain has a base fee and legacy otherwise
+func quoteFees(ctx context.Context, client feeClient, fees config.FeeConfig, msg ethereum.CallMsg) (feeQuote, error) {
+	fees = fees.WithDefaults()
+	maxFee := gweiToWei(fees.MaxFeeGwei)
+
+	estimate, err := client.EstimateGas(ctx, msg)
+	if err != nil {
+		return feeQuote{}, fmt.Errorf("estimating gas: %w", err)
+	}
+	quote := feeQuote{GasLimit: uint64(math.Ceil(float64(estimate) * (1 + fees.GasLimitHeadroom)))}
+
+	head, err := client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return feeQuote{}, fmt.Errorf("fetching head: %w", err)
+	}
+
+	if fees.Legacy || head.BaseFee == nil {
+		gasPrice, err := client.SuggestGasPrice(ctx)
+		if err != nil {
+			return feeQuote{}, fmt.Errorf("suggesting gas price: %w", err)
+		}
+		if maxFee != nil && gasPrice.Cmp(maxFee) > 0 {
+			return feeQuote{}, fmt.Errorf("%w: gas price %s wei", ErrFeeAboveCap, gasPrice)
+		}
+		quote.GasPrice = gasPrice
+		return quote, nil
+	}
+
+	if maxFee != nil && head.BaseFee.Cmp(maxFee) >= 0 {
+		return feeQuote{}, fmt.Errorf("%w: base fee %s wei", ErrFeeAboveCap, head.BaseFee)
+	}
+	tip, err := priorityFee(ctx, client, fees)
+	if err != nil {
+		return feeQuote{}, err
+	}
+
+	feeCap, _ := new(big.Float).Mul(
+		new(big.Float).SetInt(head.BaseFee),
+		big.NewFloat(fees.BaseFeeMultiplier),
+	).Int(nil)
+	feeCap.Add(feeCap, tip)
+	if maxFee != nil && feeCap.Cmp(maxFee) > 0 {
+		feeCap = maxFee
+	}
+	if tip.Cmp(feeCap) > 0 {
+		tip = new(big.Int).Set(feeCap)
+	}
+	quote.GasTipCap = tip
+	quote.GasFeeCap = feeCap
+	return quote, nil
+}
+
+// priorityFee is the tip of the chain's strategy, never below priority_fee_gwei
+func priorityFee(ctx context.Context, client feeClient, fees config.FeeConfig) (*big.Int, error) {
+	floor := gweiToWei(fees.PriorityFeeGwei)
+	if floor == nil {
+		floor = new(big.Int)
+	}
+
+	var tip *big.Int
+	switch strings.ToLower(fees.PriorityStrategy) {
+	case config.PriorityFeeFixed:
+		return floor, nil
+	case config.PriorityFeePercentile:
+		history, err := client.FeeHistory(ctx, uint64(fees.FeeHistoryBlocks), nil, []float64{fees.PriorityPercentile})
+		if err == nil {
+			tip = medianReward(history)
+		}
+	}
+	if tip == nil {
+		suggested, err := client.SuggestGasTipCap(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("suggesting priority fee: %w", err)
+		}
+		tip = suggested
+	}
+	if tip.Cmp(floor) < 0 {
+		tip = floor
+	}
+	return tip, nil
+}
+
+// medianReward is the median over blocks of the requested tip percentile
+func medianReward(history *ethereum.FeeHistory) *big.Int {
+	if history == nil {
+		return nil
+	}
+	rewards := make([]*big.Int, 0, len(history.Reward))
+	for _, block := range history.Reward {
+		if len(block) > 0 && block[0] != nil {
+			rewards = append(rewards, block[0])
+		}
+	}
+	if len(rewards) == 0 {
+		return nil
+	}
+	sort.Slice(rewards, func(i, j int) bool { return rewards[i].Cmp(rewards[j]) < 0 })
+	return new(big.Int).Set(rewards[len(rewards)/2])
+}
+
+// gweiToWei converts a configured gwei amount, nil when unset
+func gweiToWei(gwei float64) *big.Int {
+	return toWei(gwei, params.GWei)
+}
+
+// etherToWei converts a configured native token amount, nil when unset
+func etherToWei(ether float64) *big.Int {
+	return toWei(ether, params.Ether)
+}
+
+func toWei(amount float64, unit float64) *big.Int {
+	if amount <= 0 {
+		return nil
+	}
+	wei, _ := new(big.Float).Mul(big.NewFloat(amount), big.NewFloat(unit)).Int(nil)
+	return wei
+}
+
+// SpendStore keeps the spend entries of every chain across restarts
+type SpendStore interface {
+	GetRelaySpend(ctx context.Context, chainID string, since time.Time) ([]models.RelaySpend, error)
+	SaveRelaySpend(ctx context.Context, spend models.RelaySpend) error
+	DeleteRelaySpend(ctx context.Context, ids []string) error
+}
+
+// spendWindow holds what was spent on a chain in the last hour, txs in
+// flight count at their max cost until their receipt settles them. The
+// entries are kept in the store, so a restart does not reset the cap.
+type spendWindow struct {
+	mu      sync.Mutex
+	chainID string
+	limit   *big.Int   // nil is no cap
+	store   SpendStore // nil keeps the spend in memory
+	loaded  bool
+	entries map[string]*spendEntry
+	now     func() time.Time
+}
+
+type spendEntry struct {
+	at  time.Time
+	wei *big.Int
+}
+
+func newSpendWindow(chainID string, limit *big.Int, store SpendStore) *spendWindow {
+	return &spendWindow{
+		chainID: chainID,
+		limit:   limit,
+		store:   store,
+		entries: make(map[string]*spendEntry),
+		now:     time.Now,
+	}
+}
+
+// load reads the last hour from the store once, caller holds the lock
+func (w *spendWindow) load(ctx context.Context) error {
+	if w.loaded || w.store == nil {
+		return nil
+	}
+	spends, err := w.store.GetRelaySpend(ctx, w.chainID, w.now().Add(-time.Hour))
+	if err != nil {
+		return fmt.Errorf("loading spend: %w", err)
+	}
+	for _, spend := range spends {
+		wei, ok := new(big.Int).SetString(spend.Wei, 10)
+		if !ok {
+			continue
+		}
+		if _, ok := w.entries[spend.ID]; !ok {
+			w.entries[spend.ID] = &spendEntry{at: spend.At, wei: wei}
+		}
+	}
+	w.loaded = true
+	return nil
+}
+
+// spent sums the last hour, caller holds the lock
+func (w *spendWindow) spent(ctx context.Context) *big.Int {
+	cutoff := w.now().Add(-time.Hour)
+	total := new(big.Int)
+	var expired []string
+	for id, entry := range w.entries {
+		if entry.at.Before(cutoff) {
+			delete(w.entries, id)
+			expired = append(expired, id)
+			continue
+		}
+		total.Add(total, entry.wei)
+	}
+	if len(expired) > 0 && w.store != nil {
+		if err := w.store.DeleteRelaySpend(ctx, expired); err != nil {
+			logging.Logger.Warn("Failed to drop expired spend", zap.String("chainID", w.chainID), zap.Error(err))
+		}
+	}
+	return total
+}
+
+// save writes an entry through to the store, caller holds the lock
+func (w *spendWindow) save(ctx context.Context, id string, entry *spendEntry) {
+	if w.store == nil {
+		return
+	}
+	spend := models.RelaySpend{ID: id, ChainID: w.chainID, Wei: entry.wei.String(), At: entry.at}
+	if err := w.store.SaveRelaySpend(ctx, spend); err != nil {
+		logging.Logger.Error("Failed to save spend", zap.String("chainID", w.chainID), zap.Error(err))
+	}
+}
+
+// Reserve books wei against the cap
+func (w *spendWindow) Reserve(ctx context.Context, wei *big.Int) (string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.load(ctx); err != nil {
+		return "", err
+	}
+	if w.limit != nil {
+		if total := new(big.Int).Add(w.spent(ctx), wei); total.Cmp(w.limit) > 0 {
+			return "", fmt.Errorf("%w: %s of %s wei", ErrSpendCapReach, total, w.limit)
+		}
+	}
+	id := uuid.NewString()
+	entry := &spendEntry{at: w.now(), wei: new(big.Int).Set(wei)}
+	w.entries[id] = entry
+	w.save(ctx, id, entry)
+	return id, nil
+}
+
+// Raise moves a reservation up to wei, for a replacement at a higher fee
+func (w *spendWindow) Raise(ctx context.Context, id string, wei *big.Int) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	entry, ok := w.entries[id]
+	if !ok {
+		entry = &spendEntry{at: w.now(), wei: new(big.Int)}
+	}
+	if w.limit != nil {
+		total := new(big.Int).Add(w.spent(ctx), new(big.Int).Sub(wei, entry.wei))
+		if total.Cmp(w.limit) > 0 {
+			return fmt.Errorf("%w: %s of %s wei", ErrSpendCapReach, total, w.limit)
+		}
+	}
+	entry.wei = new(big.Int).Set(wei)
+	w.entries[id] = entry
+	w.save(ctx, id, entry)
+	return nil
+}
+
+// Settle replaces a reservation with what the tx actually cost
+func (w *spendWindow) Settle(ctx context.Context, id string, wei *big.Int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if entry, ok := w.entries[id]; ok {
+		entry.wei = new(big.Int).Set(wei)
+		w.save(ctx, id, entry)
+	}
+}
+
+// Release drops the reservation of a tx that was never sent. A sent tx
+// keeps its reservation even when given up on, it can still be mined.
+func (w *spendWindow) Release(ctx context.Context, id string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.entries, id)
+	if w.store != nil {
+		if err := w.store.DeleteRelaySpend(ctx, []string{id}); err != nil {
+			logging.Logger.Warn("Failed to release spend", zap.String("chainID", w.chainID), zap.Error(err))
+		}
+	}
+}
+
+// receiptCost is what a mined tx cost
+func receiptCost(receipt *types.Receipt) *big.Int {
+	if receipt.EffectiveGasPrice == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Mul(receipt.EffectiveGasPrice, new(big.Int).SetUint64(receipt.GasUsed))
+}
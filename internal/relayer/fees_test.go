@@ -0,0 +1,168 @@
+package relayer
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"oracle_engine/internal/config"
+	"oracle_engine/internal/models"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// fakeFees answers fee queries with fixed values
+type fakeFees struct {
+	baseFee  *big.Int // nil for a chain without EIP-1559
+	gasPrice *big.Int
+	tip      *big.Int
+	rewards  []int64
+}
+
+func (f *fakeFees) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return &types.Header{Number: big.NewInt(100), BaseFee: f.baseFee}, nil
+}
+
+func (f *fakeFees) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return f.gasPrice, nil
+}
+
+func (f *fakeFees) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return f.tip, nil
+}
+
+func (f *fakeFees) FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*ethereum.FeeHistory, error) {
+	history := &ethereum.FeeHistory{}
+	for _, reward := range f.rewards {
+		history.Reward = append(history.Reward, []*big.Int{big.NewInt(reward)})
+	}
+	return history, nil
+}
+
+func (f *fakeFees) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error) {
+	return 100_000, nil
+}
+
+func gwei(n int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(n), big.NewInt(params.GWei))
+}
+
+func TestQuoteFeesFallsBackToLegacyWithoutBaseFee(t *testing.T) {
+	client := &fakeFees{gasPrice: gwei(3)}
+
+	quote, err := quoteFees(context.Background(), client, config.FeeConfig{}, ethereum.CallMsg{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if quote.GasPrice.Cmp(gwei(3)) != 0 || quote.GasFeeCap != nil {
+		t.Fatalf("got %+v, want a legacy quote at 3 gwei", quote)
+	}
+	if quote.GasLimit != 120_000 {
+		t.Fatalf("gas limit %d, want the estimate with 20%% headroom", quote.GasLimit)
+	}
+}
+
+func TestQuoteFeesUsesPercentileTipAndCapsFeeCap(t *testing.T) {
+	client := &fakeFees{baseFee: gwei(4), tip: gwei(1), rewards: []int64{1e9, 3e9, 2e9}}
+	fees := config.FeeConfig{PriorityStrategy: config.PriorityFeePercentile, MaxFeeGwei: 9}
+
+	quote, err := quoteFees(context.Background(), client, fees, ethereum.CallMsg{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if quote.GasTipCap.Cmp(gwei(2)) != 0 {
+		t.Fatalf("tip %s, want the median reward of 2 gwei", quote.GasTipCap)
+	}
+	// 2 * 4 + 2 = 10 gwei, capped at 9
+	if quote.GasFeeCap.Cmp(gwei(9)) != 0 {
+		t.Fatalf("fee cap %s, want the 9 gwei cap", quote.GasFeeCap)
+	}
+}
+
+func TestQuoteFeesRefusesBaseFeeAboveCap(t *testing.T) {
+	client := &fakeFees{baseFee: gwei(50), tip: gwei(1)}
+
+	_, err := quoteFees(context.Background(), client, config.FeeConfig{MaxFeeGwei: 20}, ethereum.CallMsg{})
+	if !errors.Is(err, ErrFeeAboveCap) {
+		t.Fatalf("got %v, want ErrFeeAboveCap", err)
+	}
+}
+
+func TestSpendWindowCapsHourlySpend(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1_750_000_000, 0)
+	window := newSpendWindow("1", big.NewInt(100), nil)
+	window.now = func() time.Time { return now }
+
+	first, err := window.Reserve(ctx, big.NewInt(80))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := window.Reserve(ctx, big.NewInt(30)); !errors.Is(err, ErrSpendCapReach) {
+		t.Fatalf("got %v, want the cap to refuse a second tx", err)
+	}
+
+	// the mined tx cost less than its reservation
+	window.Settle(ctx, first, big.NewInt(50))
+	if _, err := window.Reserve(ctx, big.NewInt(30)); err != nil {
+		t.Fatalf("settled spend still counted at max cost: %v", err)
+	}
+
+	now = now.Add(61 * time.Minute)
+	if _, err := window.Reserve(ctx, big.NewInt(100)); err != nil {
+		t.Fatalf("spend older than an hour still counted: %v", err)
+	}
+}
+
+// memorySpend is a spend store shared by the windows of several runs
+type memorySpend map[string]models.RelaySpend
+
+func (m memorySpend) GetRelaySpend(ctx context.Context, chainID string, since time.Time) ([]models.RelaySpend, error) {
+	var out []models.RelaySpend
+	for _, spend := range m {
+		if spend.ChainID == chainID && !spend.At.Before(since) {
+			out = append(out, spend)
+		}
+	}
+	return out, nil
+}
+
+func (m memorySpend) SaveRelaySpend(ctx context.Context, spend models.RelaySpend) error {
+	m[spend.ID] = spend
+	return nil
+}
+
+func (m memorySpend) DeleteRelaySpend(ctx context.Context, ids []string) error {
+	for _, id := range ids {
+		delete(m, id)
+	}
+	return nil
+}
+
+func TestSpendWindowSurvivesARestart(t *testing.T) {
+	ctx := context.Background()
+	store := memorySpend{}
+	now := time.Unix(1_750_000_000, 0)
+	window := newSpendWindow("1", big.NewInt(100), store)
+	window.now = func() time.Time { return now }
+
+	id, err := window.Reserve(ctx, big.NewInt(90))
+	if err != nil {
+		t.Fatal(err)
+	}
+	window.Settle(ctx, id, big.NewInt(70))
+
+	restarted := newSpendWindow("1", big.NewInt(100), store)
+	restarted.now = func() time.Time { return now.Add(time.Minute) }
+	if _, err := restarted.Reserve(ctx, big.NewInt(40)); !errors.Is(err, ErrSpendCapReach) {
+		t.Fatalf("got %v, want the spend before the restart to count", err)
+	}
+	other := newSpendWindow("2", big.NewInt(100), store)
+	if _, err := other.Reserve(ctx, big.NewInt(40)); err != nil {
+		t.Fatalf("spend of another chain counted: %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+package relayer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"oracle_engine/internal/config"
+	"oracle_engine/internal/logging"
+	"oracle_engine/internal/models"
+	importVerifier "oracle_engine/pkg/abi"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"go.uber.org/zap"
+)
+
+/*
+Chain health:
+Before a batch is flushed the chain of its contract has to look alive.
+Its head block is no older than max_block_age_seconds and, on L2s with a
+sequencer uptime feed, the feed reports the sequencer up for at least
+sequencer_grace_seconds. While a chain is unhealthy its contracts hold
+their batches back and keep only the latest issuance of every asset, the
+rest are replaced. Once the chain recovers the held batch goes out, so
+the contract catches up with the latest values in one tx. A chain is
+checked at most once per flush interval, its contracts share the result.
+*/
+
+var sequencerUptimeABI, _ = abi.JSON(strings.NewReader(importVerifier.SequencerUptimeFeedABI))
+
+// healthClient is what the health check needs from a chain
+type healthClient interface {
+	bind.ContractCaller
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+// checkChainHealth is nil while the chain is fit to publish to, else the
+// reason it is not
+func checkChainHealth(ctx context.Context, client healthClient, health config.ChainHealthSettings, now time.Time) error {
+	head, err := client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("fetching head: %w", err)
+	}
+	maxAge := time.Duration(health.MaxBlockAgeSeconds) * time.Second
+	if age := now.Sub(time.Unix(int64(head.Time), 0)); maxAge > 0 && age > maxAge {
+		return fmt.Errorf("head block %s is %s old, max %s", head.Number, age.Truncate(time.Second), maxAge)
+	}
+
+	if health.SequencerUptimeFeed == "" {
+		return nil
+	}
+	feed := bind.NewBoundContract(common.HexToAddress(health.SequencerUptimeFeed), sequencerUptimeABI, client, nil, nil)
+	var out []interface{}
+	if err := feed.Call(&bind.CallOpts{Context: ctx}, &out, "latestRoundData"); err != nil {
+		return fmt.Errorf("reading sequencer uptime feed: %w", err)
+	}
+	if len(out) < 3 {
+		return errors.New("reading sequencer uptime feed: short answer")
+	}
+	answer, _ := out[1].(*big.Int)
+	startedAt, _ := out[2].(*big.Int)
+	if answer == nil || startedAt == nil {
+		return errors.New("reading sequencer uptime feed: malformed answer")
+	}
+	since := time.Unix(startedAt.Int64(), 0)
+	if answer.Sign() != 0 {
+		return fmt.Errorf("sequencer down since %s", since.UTC().Format(time.RFC3339))
+	}
+	grace := time.Duration(health.SequencerGraceSeconds) * time.Second
+	if up := now.Sub(since); up < grace {
+		return fmt.Errorf("sequencer up for %s, grace %s", up.Truncate(time.Second), grace)
+	}
+	return nil
+}
+
+// healthCheck is the outcome of a chain's last health check
+type healthCheck struct {
+	err error
+	at  time.Time
+}
+
+// chainHealth is the health of a chain, checked at most once per flush
+// interval of ctrct however many contracts the chain carries
+func (r *Relayer) chainHealth(ctx context.Context, ctrct config.ContractConfig, now time.Time) error {
+	r.healthMu.Lock()
+	last, ok := r.healthChecks[ctrct.ChainID]
+	r.healthMu.Unlock()
+	if ok && now.Sub(last.at) < flushInterval(ctrct.BatchConfig(r.cfg.RelayerBatch)) {
+		return last.err
+	}
+
+	client, err := r.clients(ctrct.ChainID)
+	if err == nil {
+		err = checkChainHealth(ctx, client, r.cfg.ChainHealth.ForChain(ctrct.ChainID), now)
+	}
+	r.healthMu.Lock()
+	r.healthChecks[ctrct.ChainID] = healthCheck{err: err, at: now}
+	r.healthMu.Unlock()
+	return err
+}
+
+// chainHealthy checks the chain of ctrct before a flush and logs when it
+// pauses and resumes publishing
+func (r *Relayer) chainHealthy(ctx context.Context, ctrct config.ContractConfig) bool {
+	if !r.cfg.ChainHealth.Enabled {
+		return true
+	}
+	err := r.chainHealth(ctx, ctrct, time.Now())
+
+	r.healthMu.Lock()
+	defer r.healthMu.Unlock()
+	key := contractKey(ctrct)
+	_, paused := r.paused[key]
+	switch {
+	case err != nil && !paused:
+		r.paused[key] = struct{}{}
+		logging.Logger.Warn("Chain unhealthy, pausing publishing",
+			zap.String("contract", key), zap.Error(err))
+	case err != nil:
+		logging.Logger.Debug("Chain still unhealthy", zap.String("contract", key), zap.Error(err))
+	case paused:
+		delete(r.paused, key)
+		logging.Logger.Info("Chain healthy again, resuming publishing", zap.String("contract", key))
+	}
+	return err == nil
+}
+
+// holdBatch keeps the latest issuance per asset of a batch held back from
+// an unhealthy chain and replaces the others
+func (r *Relayer) holdBatch(ctx context.Context, batch []*models.Issuance, ctrct config.ContractConfig) []*models.Issuance {
+	latestByAsset := r.latestIssuancesByAsset(batch)
+	r.markSuperseded(ctx, batch, latestByAsset, ctrct)
+	held := batch[:0]
+	for _, issuance := range batch {
+		if issuance != nil && latestByAsset[issuance.Price.AssetID] == issuance {
+			held = append(held, issuance)
+		}
+	}
+	return held
+}
+
+// supersedeOutbox replaces the pending outbox entries of ctrct that a
+// newer pending entry of the same asset made stale
+func (r *Relayer) supersedeOutbox(ctx context.Context, ctrct config.ContractConfig) {
+	entries, err := r.outbox.SupersedeRelays(ctx, ctrct.ChainID, ctrct.Address)
+	if err != nil {
+		logging.Logger.Error("Failed to supersede outbox entries", zap.String("contract", contractKey(ctrct)), zap.Error(err))
+		return
+	}
+	for i := range entries {
+		r.transition(ctx, &entries[i].Issuance, ctrct, models.Replaced, func(tr *models.IssuanceTransition) {
+			tr.Error = "superseded by a newer issuance in the outbox"
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package relayer
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"oracle_engine/internal/config"
+	"oracle_engine/internal/logging"
+	"oracle_engine/internal/models"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"go.uber.org/zap"
+)
+
+// fakeHealth is a chain with a head block and a sequencer uptime feed
+type fakeHealth struct {
+	headTime  time.Time
+	down      bool
+	startedAt time.Time
+}
+
+func (f *fakeHealth) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return &types.Header{Number: big.NewInt(100), Time: uint64(f.headTime.Unix())}, nil
+}
+
+func (f *fakeHealth) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	return []byte{1}, nil
+}
+
+func (f *fakeHealth) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	answer := big.NewInt(0)
+	if f.down {
+		answer = big.NewInt(1)
+	}
+	started := big.NewInt(f.startedAt.Unix())
+	return sequencerUptimeABI.Methods["latestRoundData"].Outputs.Pack(big.NewInt(1), answer, started, started, big.NewInt(1))
+}
+
+func TestChainHealthChecksBlockAgeAndSequencer(t *testing.T) {
+	now := time.Unix(1_750_000_000, 0)
+	health := config.ChainHealthSettings{
+		SequencerUptimeFeed:   "0x0000000000000000000000000000000000000f33",
+		MaxBlockAgeSeconds:    120,
+		SequencerGraceSeconds: 600,
+	}
+	cases := []struct {
+		name    string
+		chain   fakeHealth
+		healthy bool
+	}{
+		{"fresh head, sequencer up", fakeHealth{headTime: now.Add(-10 * time.Second), startedAt: now.Add(-time.Hour)}, true},
+		{"stalled head", fakeHealth{headTime: now.Add(-5 * time.Minute), startedAt: now.Add(-time.Hour)}, false},
+		{"sequencer down", fakeHealth{headTime: now, down: true, startedAt: now.Add(-time.Minute)}, false},
+		{"sequencer within grace", fakeHealth{headTime: now, startedAt: now.Add(-time.Minute)}, false},
+	}
+	for _, c := range cases {
+		err := checkChainHealth(context.Background(), &c.chain, health, now)
+		if (err == nil) != c.healthy {
+			t.Fatalf("%s: got %v", c.name, err)
+		}
+	}
+}
+
+func TestHeldBatchKeepsTheLatestIssuancePerAsset(t *testing.T) {
+	logging.Logger = zap.NewNop()
+	ctrct := config.ContractConfig{ChainID: "8453", Address: "0x02"}
+	r := New(&config.Config{Contracts: []config.ContractConfig{ctrct}}, nil, nil)
+
+	now := time.Unix(1_750_000_000, 0)
+	usdt := routingIssuance("0xUSDT", 1, now)
+	newerUSDT := routingIssuance("0xUSDT", 1.01, now.Add(time.Second))
+	cngn := routingIssuance("0xCNGN", 0.0006, now)
+
+	held := r.holdBatch(context.Background(), []*models.Issuance{usdt, cngn, newerUSDT}, ctrct)
+	if len(held) != 2 || held[0] != cngn || held[1] != newerUSDT {
+		t.Fatalf("held %d issuances, want the latest per asset", len(held))
+	}
+}
+
+func TestChainHealthIsCheckedOncePerFlushInterval(t *testing.T) {
+	logging.Logger = zap.NewNop()
+	feed := config.ContractConfig{ChainID: "8453", Address: "0x02"}
+	verifier := config.ContractConfig{ChainID: "8453", Address: "0x03"}
+	checks := 0
+	r := New(&config.Config{
+		Contracts:    []config.ContractConfig{feed, verifier},
+		RelayerBatch: config.RelayerBatchConfig{FlushIntervalSeconds: 5},
+		ChainHealth:  config.ChainHealthConfig{Enabled: true},
+	}, nil, func(chainID string) (ChainClient, error) {
+		checks++
+		return nil, errors.New("chain is down")
+	})
+
+	now := time.Unix(1_750_000_000, 0)
+	for _, at := range []time.Time{now, now.Add(time.Second), now.Add(4 * time.Second)} {
+		for _, ctrct := range []config.ContractConfig{feed, verifier} {
+			if r.chainHealth(context.Background(), ctrct, at) == nil {
+				t.Fatal("cached a down chain as healthy")
+			}
+		}
+	}
+	if checks != 1 {
+		t.Fatalf("checked the chain %d times within one flush interval", checks)
+	}
+	r.chainHealth(context.Background(), feed, now.Add(5*time.Second))
+	if checks != 2 {
+		t.Fatal("did not check the chain again after the flush interval")
+	}
+}
@@ -0,0 +1,104 @@
+package relayer
+
+import (
+	"fmt"
+
+	"oracle_engine/internal/config"
+	"oracle_engine/internal/logging"
+	"oracle_engine/internal/signer"
+
+	"github.com/ethereum/go-ethereum/common"
+	"go.uber.org/zap"
+)
+
+// relayerKey is one signer of a chain's pool, every address has to be a
+// relayer node of the chain's verifier
+type relayerKey struct {
+	signer  signer.Signer
+	address common.Address
+}
+
+func loadKeys(chainID string, cfgs []config.SignerConfig) ([]relayerKey, error) {
+	keys := make([]relayerKey, 0, len(cfgs))
+	seen := make(map[common.Address]struct{}, len(cfgs))
+	for i, cfg := range cfgs {
+		s, err := signer.New(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("relayer signer %d: %w", i, err)
+		}
+		address := s.Address()
+		if _, ok := seen[address]; ok {
+			continue
+		}
+		seen[address] = struct{}{}
+		if cfg.Type == config.SignerRaw || cfg.Type == "" {
+			logging.Logger.Warn("Relayer signs with a raw key, use a keystore or remote signer outside development",
+				zap.String("chainID", chainID), zap.String("address", address.Hex()))
+		}
+		keys = append(keys, relayerKey{signer: s, address: address})
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no relayer key configured")
+	}
+	return keys, nil
+}
+
+// LoadKeys loads and checks the key pool of every chain with a contract,
+// a chain whose signers fail to load keeps the relayer from starting
+func (r *Relayer) LoadKeys() error {
+	r.keysMu.Lock()
+	defer r.keysMu.Unlock()
+	for _, ctrct := range r.cfg.Contracts {
+		if _, ok := r.keys[ctrct.ChainID]; ok {
+			continue
+		}
+		keys, err := loadKeys(ctrct.ChainID, r.cfg.SignersFor(ctrct.ChainID))
+		if err != nil {
+			return fmt.Errorf("chain %s: %w", ctrct.ChainID, err)
+		}
+		r.keys[ctrct.ChainID] = keys
+	}
+	return nil
+}
+
+// keyFor picks the key of the chain with the fewest txs in flight, so a
+// stuck tx only holds up the batches behind it on the same key
+func (r *Relayer) keyFor(chainID string) (relayerKey, error) {
+	keys, err := r.keysOf(chainID)
+	if err != nil {
+		return relayerKey{}, err
+	}
+
+	best := keys[0]
+	bestLoad := r.nonces.InFlight(chainID, best.address)
+	for _, k := range keys[1:] {
+		if load := r.nonces.InFlight(chainID, k.address); load < bestLoad {
+			best, bestLoad = k, load
+		}
+	}
+	return best, nil
+}
+
+// keysOf is the key pool of a chain LoadKeys loaded
+func (r *Relayer) keysOf(chainID string) ([]relayerKey, error) {
+	r.keysMu.Lock()
+	defer r.keysMu.Unlock()
+	keys, ok := r.keys[chainID]
+	if !ok {
+		return nil, fmt.Errorf("no relayer keys loaded for chain %s", chainID)
+	}
+	return keys, nil
+}
+
+// Wallets lists the addresses the relayer sends from on a chain
+func (r *Relayer) Wallets(chainID string) ([]common.Address, error) {
+	keys, err := r.keysOf(chainID)
+	if err != nil {
+		return nil, err
+	}
+	addresses := make([]common.Address, len(keys))
+	for i, k := range keys {
+		addresses[i] = k.address
+	}
+	return addresses, nil
+}
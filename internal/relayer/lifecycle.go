@@ -0,0 +1,97 @@
+package relayer
+
+import (
+	"context"
+	"time"
+
+	"oracle_engine/internal/config"
+	"oracle_engine/internal/logging"
+	"oracle_engine/internal/models"
+
+	"go.uber.org/zap"
+)
+
+// transition persists a lifecycle step of an issuance on a contract and
+// streams it, fill sets the step details like the tx hash
+func (r *Relayer) transition(
+	ctx context.Context,
+	issuance *models.Issuance,
+	ctrct config.ContractConfig,
+	to models.IssuanceState,
+	fill func(*models.IssuanceTransition),
+) {
+	tr := &models.IssuanceTransition{
+		IssuanceID: issuance.ID,
+		ChainID:    ctrct.ChainID,
+		Contract:   ctrct.Address,
+		To:         to,
+		CreatedAt:  time.Now(),
+	}
+	if fill != nil {
+		fill(tr)
+	}
+
+	if r.db != nil {
+		if err := r.db.RecordIssuanceTransition(ctx, tr); err != nil {
+			logging.Logger.Error("Failed to record issuance transition",
+				zap.String("issuance", issuance.ID),
+				zap.String("contract", contractKey(ctrct)),
+				zap.String("to", to.String()),
+				zap.Error(err),
+			)
+			return
+		}
+	}
+	logging.Logger.Debug("Issuance transition",
+		zap.String("issuance", issuance.ID),
+		zap.String("contract", contractKey(ctrct)),
+		zap.String("from", tr.From.String()),
+		zap.String("to", to.String()),
+		zap.String("tx", tr.TxHash),
+	)
+
+	if r.events == nil {
+		return
+	}
+	update := *issuance
+	update.State = to
+	if tr.Issuer != "" {
+		update.IssuerAddress = tr.Issuer
+	}
+	update.UpdatedAt = tr.CreatedAt
+	update.Contracts = []models.IssuanceContractState{{
+		ChainID:     tr.ChainID,
+		Contract:    tr.Contract,
+		State:       to,
+		StateName:   to.String(),
+		TxHash:      tr.TxHash,
+		BlockNumber: tr.BlockNumber,
+		GasUsed:     tr.GasUsed,
+		Error:       tr.Error,
+		UpdatedAt:   tr.CreatedAt,
+	}}
+	select {
+	case r.events <- update:
+	default:
+		logging.Logger.Debug("Issuance stream full, dropping transition", zap.String("issuance", issuance.ID))
+	}
+}
+
+// markSuperseded replaces the issuances of a batch that lost to a newer
+// issuance of the same asset and never go on chain
+func (r *Relayer) markSuperseded(
+	ctx context.Context,
+	issuances []*models.Issuance,
+	latestByAsset map[string]*models.Issuance,
+	ctrct config.ContractConfig,
+) {
+	for _, issuance := range issuances {
+		if issuance == nil || latestByAsset[issuance.Price.AssetID] == issuance {
+			continue
+		}
+		latestID := latestByAsset[issuance.Price.AssetID].ID
+		r.transition(ctx, issuance, ctrct, models.Replaced, func(tr *models.IssuanceTransition) {
+			tr.Error = "superseded by issuance " + latestID
+		})
+	}
+}
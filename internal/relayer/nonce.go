@@ -0,0 +1,151 @@
+package relayer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"oracle_engine/internal/logging"
+
+	"github.com/ethereum/go-ethereum/common"
+	"go.uber.org/zap"
+)
+
+type nonceClient interface {
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+}
+
+// NonceStore persists the next local nonce of every relayer key
+type NonceStore interface {
+	GetRelayerNonce(ctx context.Context, chainID, address string) (uint64, bool, error)
+	SaveRelayerNonce(ctx context.Context, chainID, address string, next uint64) error
+}
+
+/*
+NonceManager:
+Hands out nonces per chain and key without asking the node for every
+tx, so one key can have several txs in flight. The next nonce is kept
+in the store across restarts. A nonce below it that is neither in
+flight nor counted by the node's pending nonce was dropped, the tx
+never made it or failed for good, and is handed out again before a new
+one so the key never stalls behind a gap.
+*/
+type NonceManager struct {
+	store NonceStore // nil keeps nonces in memory
+
+	mu       sync.Mutex
+	accounts map[string]*nonceAccount
+}
+
+type nonceAccount struct {
+	mu       sync.Mutex
+	loaded   bool
+	next     uint64
+	inflight map[uint64]struct{}
+}
+
+func NewNonceManager(store NonceStore) *NonceManager {
+	return &NonceManager{store: store, accounts: make(map[string]*nonceAccount)}
+}
+
+func (m *NonceManager) account(chainID string, address common.Address) *nonceAccount {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	k := chainID + "|" + strings.ToLower(address.Hex())
+	acc, ok := m.accounts[k]
+	if !ok {
+		acc = &nonceAccount{inflight: make(map[uint64]struct{})}
+		m.accounts[k] = acc
+	}
+	return acc
+}
+
+// Next reserves the nonce of the key's next tx, Release or Done has to
+// follow once the tx is known to be sent or not
+func (m *NonceManager) Next(ctx context.Context, client nonceClient, chainID string, address common.Address) (uint64, error) {
+	acc := m.account(chainID, address)
+	acc.mu.Lock()
+	defer acc.mu.Unlock()
+
+	if !acc.loaded && m.store != nil {
+		next, ok, err := m.store.GetRelayerNonce(ctx, chainID, address.Hex())
+		if err != nil {
+			return 0, fmt.Errorf("loading nonce: %w", err)
+		}
+		if ok {
+			acc.next = next
+		}
+	}
+	acc.loaded = true
+
+	pending, err := client.PendingNonceAt(ctx, address)
+	if err != nil {
+		return 0, fmt.Errorf("fetching pending nonce: %w", err)
+	}
+	// the key sent elsewhere or txs got mined past what we knew
+	if pending > acc.next {
+		acc.next = pending
+	}
+
+	for nonce := pending; nonce < acc.next; nonce++ {
+		if _, ok := acc.inflight[nonce]; ok {
+			continue
+		}
+		logging.Logger.Warn("Filling relayer nonce gap",
+			zap.String("chainID", chainID),
+			zap.String("address", address.Hex()),
+			zap.Uint64("nonce", nonce),
+			zap.Uint64("next", acc.next),
+		)
+		acc.inflight[nonce] = struct{}{}
+		return nonce, nil
+	}
+
+	nonce := acc.next
+	acc.next++
+	acc.inflight[nonce] = struct{}{}
+	m.save(ctx, chainID, address, acc.next)
+	return nonce, nil
+}
+
+// Release gives back a nonce whose tx was never sent
+func (m *NonceManager) Release(ctx context.Context, chainID string, address common.Address, nonce uint64) {
+	acc := m.account(chainID, address)
+	acc.mu.Lock()
+	defer acc.mu.Unlock()
+
+	delete(acc.inflight, nonce)
+	if nonce+1 == acc.next {
+		acc.next = nonce
+		m.save(ctx, chainID, address, acc.next)
+	}
+}
+
+// Done ends tracking of a sent nonce, mined or given up on
+func (m *NonceManager) Done(chainID string, address common.Address, nonce uint64) {
+	acc := m.account(chainID, address)
+	acc.mu.Lock()
+	defer acc.mu.Unlock()
+	delete(acc.inflight, nonce)
+}
+
+// InFlight counts the txs of a key that are not done yet
+func (m *NonceManager) InFlight(chainID string, address common.Address) int {
+	acc := m.account(chainID, address)
+	acc.mu.Lock()
+	defer acc.mu.Unlock()
+	return len(acc.inflight)
+}
+
+// save persists the next nonce, the caller holds the account lock. A
+// failed save is harmless, the node's pending nonce catches up.
+func (m *NonceManager) save(ctx context.Context, chainID string, address common.Address, next uint64) {
+	if m.store == nil {
+		return
+	}
+	if err := m.store.SaveRelayerNonce(ctx, chainID, address.Hex(), next); err != nil {
+		logging.Logger.Warn("Failed to persist relayer nonce",
+			zap.String("chainID", chainID), zap.String("address", address.Hex()), zap.Error(err))
+	}
+}
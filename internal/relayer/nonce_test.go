@@ -0,0 +1,162 @@
+package relayer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"testing"
+
+	"oracle_engine/internal/config"
+	"oracle_engine/internal/logging"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"go.uber.org/zap"
+)
+
+type fakePending struct{ nonce uint64 }
+
+func (f *fakePending) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	return f.nonce, nil
+}
+
+type memoryNonces map[string]uint64
+
+func (m memoryNonces) GetRelayerNonce(ctx context.Context, chainID, address string) (uint64, bool, error) {
+	next, ok := m[chainID+address]
+	return next, ok, nil
+}
+
+func (m memoryNonces) SaveRelayerNonce(ctx context.Context, chainID, address string, next uint64) error {
+	m[chainID+address] = next
+	return nil
+}
+
+var nonceAddress = common.HexToAddress("0x00000000000000000000000000000000000000aa")
+
+func TestNonceManagerHandsOutNoncesAheadOfTheNode(t *testing.T) {
+	logging.Logger = zap.NewNop()
+	ctx := context.Background()
+	node := &fakePending{nonce: 7}
+	m := NewNonceManager(nil)
+
+	for want := uint64(7); want < 10; want++ {
+		got, err := m.Next(ctx, node, "1", nonceAddress)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Fatalf("got nonce %d, want %d", got, want)
+		}
+	}
+
+	// the tx of 9 never went out
+	m.Release(ctx, "1", nonceAddress, 9)
+	if got, _ := m.Next(ctx, node, "1", nonceAddress); got != 9 {
+		t.Fatalf("got nonce %d, want the released 9", got)
+	}
+}
+
+func TestNonceManagerFillsGapsOfDroppedTxs(t *testing.T) {
+	logging.Logger = zap.NewNop()
+	ctx := context.Background()
+	node := &fakePending{nonce: 3}
+	m := NewNonceManager(nil)
+
+	for i := 0; i < 3; i++ {
+		if _, err := m.Next(ctx, node, "1", nonceAddress); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 3 got mined, 4 was dropped by the node and given up on, 5 is pending
+	node.nonce = 4
+	m.Done("1", nonceAddress, 3)
+	m.Done("1", nonceAddress, 4)
+
+	if got, _ := m.Next(ctx, node, "1", nonceAddress); got != 4 {
+		t.Fatalf("got nonce %d, want the gap at 4", got)
+	}
+	if got, _ := m.Next(ctx, node, "1", nonceAddress); got != 6 {
+		t.Fatalf("got nonce %d, want 6 after the gap", got)
+	}
+}
+
+func TestNonceManagerResumesFromStoreAfterRestart(t *testing.T) {
+	logging.Logger = zap.NewNop()
+	ctx := context.Background()
+	store := memoryNonces{}
+	node := &fakePending{nonce: 0}
+
+	m := NewNonceManager(store)
+	for i := 0; i < 2; i++ {
+		if _, err := m.Next(ctx, node, "1", nonceAddress); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// both txs sit in the mempool when the process restarts
+	node.nonce = 2
+
+	restarted := NewNonceManager(store)
+	if got, _ := restarted.Next(ctx, node, "1", nonceAddress); got != 2 {
+		t.Fatalf("got nonce %d after restart, want 2", got)
+	}
+}
+
+func TestKeyForSpreadsBatchesOverThePool(t *testing.T) {
+	logging.Logger = zap.NewNop()
+	first, _ := crypto.GenerateKey()
+	second, _ := crypto.GenerateKey()
+	r := New(&config.Config{
+		Contracts: []config.ContractConfig{{ChainID: "1", Address: "0x01"}},
+		RelayerKeys: map[string][]string{
+			"1": {
+				common.Bytes2Hex(crypto.FromECDSA(first)),
+				common.Bytes2Hex(crypto.FromECDSA(second)),
+			},
+		},
+	}, nil, nil)
+	if err := r.LoadKeys(); err != nil {
+		t.Fatal(err)
+	}
+
+	busy, err := r.keyFor("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.nonces.Next(context.Background(), &fakePending{}, "1", busy.address); err != nil {
+		t.Fatal(err)
+	}
+	next, err := r.keyFor("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.address == busy.address {
+		t.Fatal("picked the key with a tx in flight over an idle one")
+	}
+}
+
+func TestLoadKeysRefusesChainsWithoutWorkingSigners(t *testing.T) {
+	logging.Logger = zap.NewNop()
+	contracts := []config.ContractConfig{{ChainID: "1", Address: "0x01"}}
+	for name, keys := range map[string]map[string][]string{
+		"no keys":     nil,
+		"broken key":  {"1": {"not a key"}},
+		"one of many": {"1": {common.Bytes2Hex(crypto.FromECDSA(mustKey(t))), "not a key"}},
+	} {
+		r := New(&config.Config{Contracts: contracts, RelayerKeys: keys}, nil, nil)
+		if err := r.LoadKeys(); err == nil {
+			t.Fatalf("%s: loaded", name)
+		}
+		if err := r.Start(context.Background()); err == nil {
+			t.Fatalf("%s: started", name)
+		}
+	}
+}
+
+func mustKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
@@ -0,0 +1,227 @@
+package relayer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"oracle_engine/internal/config"
+	"oracle_engine/internal/logging"
+	"oracle_engine/internal/models"
+
+	"go.uber.org/zap"
+)
+
+/*
+Outbox:
+With a database the relayer works off the relay_outbox table instead of
+in memory channels, so a crash loses nothing that was queued. Consensus
+writes an approved issuance to the outbox of every contract relaying it
+in the same tx as the issuance itself, in multi node mode the leader does
+with the report's value. Every flush interval the worker
+of a contract claims a batch with FOR UPDATE SKIP LOCKED under a lease,
+engines sharing the database never claim the same rows. Rows of assets
+the contract refused or a newer issuance superseded are done right away,
+the tracker keeps the lease of the rows that went out and marks them
+done once their tx is confirmed. A failed batch is claimable again after
+a backoff, until max_attempts. An engine dying mid batch leaves its lease
+to run out and the batch is claimed and sent again: delivery is at least
+once, once per issuance and contract.
+*/
+
+// OutboxStore persists the issuances waiting for each contract
+type OutboxStore interface {
+	EnqueueRelays(ctx context.Context, entries []models.RelayOutboxEntry) error
+	ClaimRelays(ctx context.Context, chainID, contract string, limit int, lease time.Duration) ([]models.RelayOutboxEntry, error)
+	CompleteRelays(ctx context.Context, ids []int64) error
+	RetryRelays(ctx context.Context, ids []int64, backoff time.Duration) error
+	ExtendRelays(ctx context.Context, ids []int64, lease time.Duration) error
+	SupersedeRelays(ctx context.Context, chainID, contract string) ([]models.RelayOutboxEntry, error)
+}
+
+// Outbox lists the outbox entries of an issuance, one per contract it
+// goes to, none when it is not approved
+func (r *Relayer) Outbox(issuance *models.Issuance) []models.RelayOutboxEntry {
+	if issuance.State != models.Approved {
+		return nil
+	}
+	var entries []models.RelayOutboxEntry
+	for _, ctrct := range r.cfg.Contracts {
+		if !r.routeIssuance(ctrct, issuance) {
+			continue
+		}
+		entries = append(entries, models.RelayOutboxEntry{
+			ChainID:  ctrct.ChainID,
+			Contract: ctrct.Address,
+			Issuance: *issuance,
+		})
+	}
+	return entries
+}
+
+// enqueue writes an issuance to the outbox, entries consensus already
+// wrote with the issuance stay as they are
+func (r *Relayer) enqueue(issuance *models.Issuance) error {
+	ctx := context.Background()
+	entries := r.Outbox(issuance)
+	if err := r.outbox.EnqueueRelays(ctx, entries); err != nil {
+		return fmt.Errorf("queueing issuance %s: %w", issuance.ID, err)
+	}
+	r.queued(ctx, issuance, entries)
+	return nil
+}
+
+// AcceptQueued takes an issuance stored together with its outbox entries,
+// they are not written again. Without an outbox it is relayed like any
+// other issuance.
+func (r *Relayer) AcceptQueued(issuance *models.Issuance, entries []models.RelayOutboxEntry) error {
+	if r.outbox == nil || issuance.State != models.Approved {
+		return r.AcceptIssuance(issuance)
+	}
+	r.queued(context.Background(), issuance, entries)
+	return nil
+}
+
+func (r *Relayer) queued(ctx context.Context, issuance *models.Issuance, entries []models.RelayOutboxEntry) {
+	for _, entry := range entries {
+		ctrct := config.ContractConfig{ChainID: entry.ChainID, Address: entry.Contract}
+		r.transition(ctx, issuance, ctrct, models.Queued, nil)
+		logging.Logger.Info(
+			"Queued issuance in contract outbox",
+			zap.String("assetID", issuance.Price.AssetID),
+			zap.String("contract", contractKey(ctrct)),
+		)
+	}
+}
+
+func (r *Relayer) startOutboxRoutine(ctx context.Context, ctrct config.ContractConfig) {
+	batchCfg := ctrct.BatchConfig(r.cfg.RelayerBatch)
+	maxBatch := batchCfg.MaxIssuances
+	if maxBatch <= 0 {
+		maxBatch = 20
+	}
+
+	flushEvery := flushInterval(batchCfg)
+
+	ticker := time.NewTicker(flushEvery)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.drainOutbox(ctx, ctrct, maxBatch)
+		}
+	}
+}
+
+// drainOutbox conveys claimed batches until the contract's outbox is empty,
+// nothing is claimed while the chain is unhealthy
+func (r *Relayer) drainOutbox(ctx context.Context, ctrct config.ContractConfig, maxBatch int) {
+	r.supersedeOutbox(ctx, ctrct)
+	if !r.chainHealthy(ctx, ctrct) {
+		return
+	}
+	lease := r.outboxLease()
+	for ctx.Err() == nil {
+		entries, err := r.outbox.ClaimRelays(ctx, ctrct.ChainID, ctrct.Address, maxBatch, lease)
+		if err != nil {
+			logging.Logger.Error("Failed to claim outbox entries", zap.String("contract", contractKey(ctrct)), zap.Error(err))
+			return
+		}
+		if len(entries) == 0 {
+			return
+		}
+
+		batch := make([]*models.Issuance, len(entries))
+		for i := range entries {
+			batch[i] = &entries[i].Issuance
+		}
+		inFlight, err := r.conveyBatch(ctx, batch, ctrct, entries)
+		if err != nil {
+			logging.Logger.Error("Failed to convey issuance batch", zap.Error(err), zap.String("contract", contractKey(ctrct)))
+		}
+		// a shutdown mid batch still settles what it claimed, the tracker
+		// settles what went out
+		r.settleRelays(context.WithoutCancel(ctx), ctrct, withoutRelays(entries, inFlight), err)
+		if len(entries) < maxBatch {
+			return
+		}
+	}
+}
+
+func (r *Relayer) outboxLease() time.Duration {
+	lease := time.Duration(r.cfg.RelayOutbox.LeaseSeconds) * time.Second
+	if lease <= 0 {
+		lease = 2 * time.Minute
+	}
+	return lease
+}
+
+// withoutRelays is entries but the ones in drop
+func withoutRelays(entries, drop []models.RelayOutboxEntry) []models.RelayOutboxEntry {
+	if len(drop) == 0 {
+		return entries
+	}
+	dropped := make(map[int64]struct{}, len(drop))
+	for _, entry := range drop {
+		dropped[entry.ID] = struct{}{}
+	}
+	kept := make([]models.RelayOutboxEntry, 0, len(entries))
+	for _, entry := range entries {
+		if _, ok := dropped[entry.ID]; !ok {
+			kept = append(kept, entry)
+		}
+	}
+	return kept
+}
+
+// settleRelays marks a conveyed batch done. A failed batch goes back to
+// the outbox, but for the issuances it superseded and the ones out of
+// attempts.
+func (r *Relayer) settleRelays(ctx context.Context, ctrct config.ContractConfig, entries []models.RelayOutboxEntry, err error) {
+	maxAttempts := r.cfg.RelayOutbox.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 5
+	}
+	backoff := time.Duration(r.cfg.RelayOutbox.RetryBackoffSeconds) * time.Second
+
+	var latestByAsset map[string]*models.Issuance
+	if err != nil {
+		batch := make([]*models.Issuance, len(entries))
+		for i := range entries {
+			batch[i] = &entries[i].Issuance
+		}
+		latestByAsset = r.latestIssuancesByAsset(batch)
+	}
+
+	done := make([]int64, 0, len(entries))
+	retry := make([]int64, 0, len(entries))
+	for i := range entries {
+		entry := &entries[i]
+		if err == nil || latestByAsset[entry.Issuance.Price.AssetID] != &entry.Issuance {
+			done = append(done, entry.ID)
+			continue
+		}
+		if entry.Attempts >= maxAttempts {
+			logging.Logger.Error("Giving up relaying issuance",
+				zap.String("issuance", entry.Issuance.ID),
+				zap.String("contract", contractKey(ctrct)),
+				zap.Int("attempts", entry.Attempts),
+			)
+			done = append(done, entry.ID)
+			continue
+		}
+		retry = append(retry, entry.ID)
+		r.transition(ctx, &entry.Issuance, ctrct, models.Queued, nil)
+	}
+
+	if err := r.outbox.CompleteRelays(ctx, done); err != nil {
+		// the lease runs out and the batch is sent again
+		logging.Logger.Error("Failed to complete outbox entries", zap.String("contract", contractKey(ctrct)), zap.Error(err))
+	}
+	if err := r.outbox.RetryRelays(ctx, retry, backoff); err != nil {
+		logging.Logger.Error("Failed to requeue outbox entries", zap.String("contract", contractKey(ctrct)), zap.Error(err))
+	}
+}
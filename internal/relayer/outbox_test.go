@@ -0,0 +1,150 @@
+package relayer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"oracle_engine/internal/config"
+	"oracle_engine/internal/logging"
+	"oracle_engine/internal/models"
+
+	"go.uber.org/zap"
+)
+
+// memoryOutbox is the relay_outbox table without leases running out
+type memoryOutbox struct {
+	rows     []*memoryRelay
+	retried  []int64
+	extended []int64
+}
+
+type memoryRelay struct {
+	entry   models.RelayOutboxEntry
+	claimed bool
+	done    bool
+}
+
+func (m *memoryOutbox) EnqueueRelays(ctx context.Context, entries []models.RelayOutboxEntry) error {
+	for _, entry := range entries {
+		queued := false
+		for _, row := range m.rows {
+			queued = queued || (row.entry.Issuance.ID == entry.Issuance.ID && row.entry.ChainID == entry.ChainID && row.entry.Contract == entry.Contract)
+		}
+		if !queued {
+			entry.ID = int64(len(m.rows) + 1)
+			m.rows = append(m.rows, &memoryRelay{entry: entry})
+		}
+	}
+	return nil
+}
+
+func (m *memoryOutbox) ClaimRelays(ctx context.Context, chainID, contract string, limit int, lease time.Duration) ([]models.RelayOutboxEntry, error) {
+	var entries []models.RelayOutboxEntry
+	for _, row := range m.rows {
+		if len(entries) == limit {
+			break
+		}
+		if row.done || row.claimed || row.entry.ChainID != chainID || row.entry.Contract != contract {
+			continue
+		}
+		row.claimed = true
+		row.entry.Attempts++
+		entries = append(entries, row.entry)
+	}
+	return entries, nil
+}
+
+func (m *memoryOutbox) CompleteRelays(ctx context.Context, ids []int64) error {
+	for _, id := range ids {
+		m.rows[id-1].done = true
+	}
+	return nil
+}
+
+func (m *memoryOutbox) RetryRelays(ctx context.Context, ids []int64, backoff time.Duration) error {
+	for _, id := range ids {
+		m.rows[id-1].claimed = false
+	}
+	m.retried = append(m.retried, ids...)
+	return nil
+}
+
+func (m *memoryOutbox) ExtendRelays(ctx context.Context, ids []int64, lease time.Duration) error {
+	m.extended = append(m.extended, ids...)
+	return nil
+}
+
+func (m *memoryOutbox) SupersedeRelays(ctx context.Context, chainID, contract string) ([]models.RelayOutboxEntry, error) {
+	var entries []models.RelayOutboxEntry
+	for i, row := range m.rows {
+		if row.done || row.claimed || row.entry.ChainID != chainID || row.entry.Contract != contract {
+			continue
+		}
+		for _, newer := range m.rows[i+1:] {
+			if !newer.done && newer.entry.ChainID == chainID && newer.entry.Contract == contract &&
+				newer.entry.Issuance.Price.AssetID == row.entry.Issuance.Price.AssetID {
+				row.done = true
+				entries = append(entries, row.entry)
+				break
+			}
+		}
+	}
+	return entries, nil
+}
+
+func (m *memoryOutbox) pending() int {
+	n := 0
+	for _, row := range m.rows {
+		if !row.done {
+			n++
+		}
+	}
+	return n
+}
+
+func TestOutboxRetriesAFailedBatchUntilMaxAttempts(t *testing.T) {
+	logging.Logger = zap.NewNop()
+	ctrct := config.ContractConfig{ChainID: "8453", Address: "0x02"}
+	r := New(&config.Config{
+		Contracts:   []config.ContractConfig{ctrct},
+		RelayOutbox: config.RelayOutboxConfig{MaxAttempts: 3},
+	}, nil, func(chainID string) (ChainClient, error) {
+		return nil, errors.New("chain is down")
+	})
+	outbox := &memoryOutbox{}
+	r.outbox = outbox
+
+	now := time.Unix(1_750_000_000, 0)
+	older := routingIssuance("0xUSDT", 1, now)
+	newer := routingIssuance("0xUSDT", 1.01, now.Add(time.Second))
+	for _, issuance := range []*models.Issuance{older, newer, older} {
+		if err := r.AcceptIssuance(issuance); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(outbox.rows) != 2 {
+		t.Fatalf("outbox holds %d entries, want one per issuance", len(outbox.rows))
+	}
+
+	// the superseded issuance is settled with the first batch, the newer
+	// one is retried until it runs out of attempts
+	r.drainOutbox(context.Background(), ctrct, 20)
+	if outbox.pending() != 1 || len(outbox.retried) != 1 || outbox.retried[0] != 2 {
+		t.Fatalf("after one failed batch %d pending, retried %v", outbox.pending(), outbox.retried)
+	}
+	r.drainOutbox(context.Background(), ctrct, 20)
+	r.drainOutbox(context.Background(), ctrct, 20)
+	if outbox.pending() != 0 || len(outbox.retried) != 2 {
+		t.Fatalf("after max attempts %d pending, retried %d times", outbox.pending(), len(outbox.retried))
+	}
+
+	// a conveyed batch is done for good
+	outbox.rows = append(outbox.rows, &memoryRelay{entry: models.RelayOutboxEntry{ID: 3, ChainID: ctrct.ChainID, Contract: ctrct.Address, Issuance: *older}})
+	entries, _ := outbox.ClaimRelays(context.Background(), ctrct.ChainID, ctrct.Address, 20, time.Minute)
+	r.settleRelays(context.Background(), ctrct, entries, nil)
+	if outbox.pending() != 0 {
+		t.Fatal("a conveyed batch stayed in the outbox")
+	}
+}
@@ -0,0 +1,81 @@
+package relayer
+
+import (
+	"context"
+
+	"oracle_engine/internal/models"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+
+	importVerifier "oracle_engine/pkg/abi"
+)
+
+/*
+Preflight:
+Every batch is simulated with eth_call before it is priced and signed.
+InvalidAssePrice is about one of the assets but does not say which, so
+the batch is split in halves until the offending assets are isolated.
+Those are dropped with their decoded error and the rest is submitted.
+Any other revert, an unknown error, a panic or one without data, fails
+the batch as a whole.
+*/
+
+// feedEntry is one asset of a batch as it goes to the contract
+type feedEntry struct {
+	index    [32]byte
+	price    importVerifier.IIfaPriceFeedPriceFeed
+	issuance *models.Issuance
+}
+
+// rejectedEntry is an asset the contract refused, with why
+type rejectedEntry struct {
+	entry feedEntry
+	err   error
+}
+
+func packSubmission(entries []feedEntry) ([]byte, error) {
+	assetIndex := make([][32]byte, len(entries))
+	prices := make([]importVerifier.IIfaPriceFeedPriceFeed, len(entries))
+	for i, entry := range entries {
+		assetIndex[i] = entry.index
+		prices[i] = entry.price
+	}
+	return verifierABI.Pack("submitPriceFeed", assetIndex, prices)
+}
+
+// preflight simulates the submission of entries from from, it returns
+// the entries the contract accepts and the ones it refuses
+func preflight(ctx context.Context, caller ethereum.ContractCaller, from, to common.Address, entries []feedEntry) ([]feedEntry, []rejectedEntry, error) {
+	calldata, err := packSubmission(entries)
+	if err != nil {
+		return nil, nil, err
+	}
+	_, err = caller.CallContract(ctx, ethereum.CallMsg{From: from, To: &to, Data: calldata}, nil)
+	if err == nil {
+		return entries, nil, nil
+	}
+	err = decodeRevert(err)
+	if !perAsset(err) {
+		return nil, nil, err
+	}
+	if len(entries) == 1 {
+		return nil, []rejectedEntry{{entry: entries[0], err: err}}, nil
+	}
+
+	mid := len(entries) / 2
+	okLeft, badLeft, leftErr := preflight(ctx, caller, from, to, entries[:mid])
+	if leftErr != nil {
+		return nil, nil, leftErr
+	}
+	okRight, badRight, rightErr := preflight(ctx, caller, from, to, entries[mid:])
+	if rightErr != nil {
+		return nil, nil, rightErr
+	}
+	rejected := append(badLeft, badRight...)
+	// both halves pass on their own, the batch fails as a whole
+	if len(rejected) == 0 {
+		return nil, nil, err
+	}
+	return append(okLeft, okRight...), rejected, nil
+}
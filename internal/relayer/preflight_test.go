@@ -0,0 +1,146 @@
+package relayer
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"oracle_engine/internal/models"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	importVerifier "oracle_engine/pkg/abi"
+)
+
+// callRevert is how a node answers a reverted eth_call
+type callRevert struct{ data []byte }
+
+func (e callRevert) Error() string          { return "execution reverted" }
+func (e callRevert) ErrorData() interface{} { return hexutil.Encode(e.data) }
+
+func customError(t *testing.T, name string, args ...interface{}) callRevert {
+	t.Helper()
+	customErr := oracleErrorsABI.Errors[name]
+	packed, err := customErr.Inputs.Pack(args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return callRevert{data: append(append([]byte{}, customErr.ID[:4]...), packed...)}
+}
+
+// fakeVerifier refuses prices below one and callers other than relayer
+type fakeVerifier struct {
+	t       *testing.T
+	relayer common.Address
+	calls   int
+}
+
+func (f *fakeVerifier) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	f.calls++
+	if msg.From != f.relayer {
+		return nil, customError(f.t, "OnlyRelayerNode", msg.From)
+	}
+	args, err := verifierABI.Methods["submitPriceFeed"].Inputs.Unpack(msg.Data[4:])
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	for _, price := range args[1].([]struct {
+		Price          *big.Int `json:"price"`
+		Decimal        int8     `json:"decimal"`
+		LastUpdateTime uint64   `json:"lastUpdateTime"`
+	}) {
+		if price.Price.Sign() <= 0 {
+			return nil, customError(f.t, "InvalidAssePrice")
+		}
+	}
+	return nil, nil
+}
+
+func entriesOf(prices ...int64) []feedEntry {
+	entries := make([]feedEntry, len(prices))
+	for i, price := range prices {
+		entries[i] = feedEntry{
+			index:    [32]byte{byte(i + 1)},
+			price:    importVerifier.IIfaPriceFeedPriceFeed{Price: big.NewInt(price), Decimal: -8},
+			issuance: &models.Issuance{ID: string(rune('a' + i))},
+		}
+	}
+	return entries
+}
+
+func TestPreflightDropsOnlyTheAssetsTheContractRefuses(t *testing.T) {
+	relayerNode := common.HexToAddress("0x1")
+	verifier := &fakeVerifier{t: t, relayer: relayerNode}
+
+	ok, rejected, err := preflight(context.Background(), verifier, relayerNode, common.HexToAddress("0xfeed"), entriesOf(5, 0, 7, 9, -1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ok) != 3 || ok[0].issuance.ID != "a" || ok[1].issuance.ID != "c" || ok[2].issuance.ID != "d" {
+		t.Fatalf("kept %d entries, want a, c and d", len(ok))
+	}
+	if len(rejected) != 2 || rejected[0].entry.issuance.ID != "b" || rejected[1].entry.issuance.ID != "e" {
+		t.Fatalf("rejected %+v, want b and e", rejected)
+	}
+	if !errors.Is(rejected[0].err, ErrInvalidAssetPrice) || !errors.Is(rejected[0].err, ErrReverted) {
+		t.Fatalf("rejected with %v, want a typed InvalidAssePrice", rejected[0].err)
+	}
+
+	// a clean batch takes one call
+	verifier.calls = 0
+	if ok, _, err := preflight(context.Background(), verifier, relayerNode, common.HexToAddress("0xfeed"), entriesOf(1, 2, 3)); err != nil || len(ok) != 3 || verifier.calls != 1 {
+		t.Fatalf("clean batch: kept %d after %d calls, %v", len(ok), verifier.calls, err)
+	}
+}
+
+func TestPreflightFailsTheBatchOnCallerErrors(t *testing.T) {
+	verifier := &fakeVerifier{t: t, relayer: common.HexToAddress("0x1")}
+	stranger := common.HexToAddress("0x2")
+
+	_, _, err := preflight(context.Background(), verifier, stranger, common.HexToAddress("0xfeed"), entriesOf(5, 0, 7))
+	var revert *RevertError
+	if !errors.Is(err, ErrOnlyRelayerNode) || !errors.As(err, &revert) {
+		t.Fatalf("got %v, want OnlyRelayerNode", err)
+	}
+	if len(revert.Args) != 1 || revert.Args[0] != stranger || verifier.calls != 1 {
+		t.Fatalf("decoded %v after %d calls, want the caller after one", revert.Args, verifier.calls)
+	}
+}
+
+// revertingVerifier reverts every call with the same data
+type revertingVerifier struct {
+	err   error
+	calls int
+}
+
+func (f *revertingVerifier) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	f.calls++
+	return nil, f.err
+}
+
+func TestPreflightOnlySplitsOnAssetErrors(t *testing.T) {
+	panicked, err := abi.NewType("uint256", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	panicData, err := abi.Arguments{{Type: panicked}}.Pack(big.NewInt(0x11))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, revert := range map[string]error{
+		"unknown selector": callRevert{data: []byte{0xde, 0xad, 0xbe, 0xef}},
+		"panic":            callRevert{data: append(append([]byte{}, panicSelector...), panicData...)},
+		"no data":          errors.New("execution reverted"),
+		"lengths":          customError(t, "InvalidAssetIndexorPriceLength"),
+	} {
+		verifier := &revertingVerifier{err: revert}
+		ok, rejected, err := preflight(context.Background(), verifier, common.HexToAddress("0x1"), common.HexToAddress("0xfeed"), entriesOf(5, 6, 7, 8))
+		if err == nil || len(ok) != 0 || len(rejected) != 0 || verifier.calls != 1 {
+			t.Fatalf("%s: kept %d, rejected %d after %d calls, %v, want the batch failed after one", name, len(ok), len(rejected), verifier.calls, err)
+		}
+	}
+}
@@ -3,10 +3,8 @@ package relayer
 
 import (
 	"context"
-	"crypto/ecdsa"
 	"fmt"
 	"math/big"
-	"os"
 	"sort"
 	"strconv"
 	"sync"
@@ -15,19 +13,46 @@ import (
 	"oracle_engine/internal/config"
 	"oracle_engine/internal/logging"
 	"oracle_engine/internal/models"
+	"oracle_engine/internal/rpcpool"
+	"oracle_engine/internal/signer"
 	"oracle_engine/internal/utils"
 
 	"oracle_engine/internal/database/timescale"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/core/types"
 	"go.uber.org/zap"
 
 	importVerifier "oracle_engine/pkg/abi"
 )
 
+var verifierABI, _ = importVerifier.VerifierMetaData.GetAbi()
+
+// ChainClient is what the relayer needs from a chain, *rpcpool.Chain and
+// the simulated chains satisfy it
+type ChainClient interface {
+	bind.ContractBackend
+	BlockNumber(ctx context.Context) (uint64, error)
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+	FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*ethereum.FeeHistory, error)
+}
+
+// Clients resolves the client of a chain
+type Clients func(chainID string) (ChainClient, error)
+
+// PoolClients relays through the rpc endpoints of pool
+func PoolClients(pool *rpcpool.Pool) Clients {
+	return func(chainID string) (ChainClient, error) {
+		chain, err := pool.Chain(chainID)
+		if err != nil {
+			return nil, err
+		}
+		return chain, nil
+	}
+}
+
 // / Relayer is a service that takes issuances requests
 // / and sends to the contract
 // / It also updates the status of the issuance request
@@ -35,50 +60,152 @@ import (
 type Relayer struct {
 	cfg                    *config.Config
 	contractToRoutineChMap map[string]chan *models.Issuance
-	chainLocks             map[string]*sync.Mutex
+	keysMu                 sync.Mutex
+	keys                   map[string][]relayerKey // key pool per chain
+	nonces                 *NonceManager
+	clients                Clients
+	budgetsMu              sync.Mutex
+	budgets                map[string]*spendWindow // hourly spend per chain
+	routesMu               sync.RWMutex
+	routes                 map[string]map[string]struct{} // asset ids per contract, absent carries all
+	publishedMu            sync.Mutex
+	published              map[string]*models.Issuance // last published per contract and asset
 	db                     *timescale.TimescaleDB
+	outbox                 OutboxStore // nil relays through contractToRoutineChMap
+	costs                  CostStore   // nil does not account tx costs
+	spend                  SpendStore  // nil keeps the hourly spend in memory
+	healthMu               sync.Mutex
+	paused                 map[string]struct{}    // contracts held back from an unhealthy chain
+	healthChecks           map[string]healthCheck // last check per chain
+	// lifecycle updates for the issuance stream, nil drops them
+	events   chan<- models.Issuance
+	ready    chan struct{}  // closed once the contract routines run
+	routines sync.WaitGroup // contract routines and trackers, Start waits for them
 }
 
-func New(config *config.Config, db *timescale.TimescaleDB) *Relayer {
-	return &Relayer{
+// New relays through clients, nil relays through a pool built from the
+// contracts' rpc endpoints
+func New(config *config.Config, db *timescale.TimescaleDB, clients Clients) *Relayer {
+	if clients == nil {
+		clients = PoolClients(rpcpool.New(config.RPCPool, config.Contracts))
+	}
+	r := &Relayer{
 		cfg:                    config,
 		contractToRoutineChMap: make(map[string]chan *models.Issuance),
-		chainLocks:             make(map[string]*sync.Mutex),
+		keys:                   make(map[string][]relayerKey),
+		nonces:                 NewNonceManager(nonceStore(db)),
+		clients:                clients,
+		budgets:                make(map[string]*spendWindow),
+		routes:                 routesFor(config.Contracts, config.Assets),
+		published:              make(map[string]*models.Issuance),
 		db:                     db,
+		outbox:                 outboxStore(db),
+		costs:                  costStore(db),
+		spend:                  spendStore(db),
+		paused:                 make(map[string]struct{}),
+		healthChecks:           make(map[string]healthCheck),
+		ready:                  make(chan struct{}),
+	}
+	// the channels are there before Start, issuances accepted early wait
+	// in them and the map is only read afterwards
+	if r.outbox == nil {
+		for _, ctrct := range config.Contracts {
+			bufferSize := ctrct.BatchConfig(config.RelayerBatch).ChannelBuffer
+			if bufferSize <= 0 {
+				bufferSize = 256
+			}
+			r.contractToRoutineChMap[contractKey(ctrct)] = make(chan *models.Issuance, bufferSize)
+		}
+	}
+	return r
+}
+
+// nonceStore keeps a nil db from becoming a non nil store
+func nonceStore(db *timescale.TimescaleDB) NonceStore {
+	if db == nil {
+		return nil
+	}
+	return db
+}
+
+// spendStore keeps a nil db from becoming a non nil store
+func spendStore(db *timescale.TimescaleDB) SpendStore {
+	if db == nil {
+		return nil
+	}
+	return db
+}
+
+// outboxStore keeps a nil db from becoming a non nil store
+func outboxStore(db *timescale.TimescaleDB) OutboxStore {
+	if db == nil {
+		return nil
 	}
+	return db
 }
 
 // / Start treat latest issuance with utmost priority
 // / Start a go routine for each issuance
 // / Each contract has its own go routine
 func (r *Relayer) Start(ctx context.Context) error {
-	bufferSize := r.cfg.RelayerBatch.ChannelBuffer
-	if bufferSize <= 0 {
-		bufferSize = 256
+	if err := r.LoadKeys(); err != nil {
+		logging.Logger.Error("Relayer not started, failed to load signers", zap.Error(err))
+		return err
 	}
-
 	for _, ctrct := range r.cfg.Contracts {
-		contractKey := r.contractKey(ctrct)
-		r.contractToRoutineChMap[contractKey] = make(chan *models.Issuance, bufferSize)
-		if _, ok := r.chainLocks[ctrct.ChainID]; !ok {
-			r.chainLocks[ctrct.ChainID] = &sync.Mutex{}
+		r.routines.Add(1)
+		if r.outbox != nil {
+			go func() {
+				defer r.routines.Done()
+				r.startOutboxRoutine(ctx, ctrct)
+			}()
+			continue
 		}
-		go r.startRoutine(ctx, ctrct, r.contractToRoutineChMap[contractKey])
+		ch := r.contractToRoutineChMap[contractKey(ctrct)]
+		go func() {
+			defer r.routines.Done()
+			r.startRoutine(ctx, ctrct, ch)
+		}()
 	}
+	close(r.ready)
 
 	<-ctx.Done()
+	r.routines.Wait()
 	logging.Logger.Info("Relayer routine stopped", zap.Error(ctx.Err()))
 	return ctx.Err()
+}
 
+// Ready is closed once Start runs the contract routines
+func (r *Relayer) Ready() <-chan struct{} {
+	return r.ready
+}
+
+// StreamTransitions sends every lifecycle transition to ch
+func (r *Relayer) StreamTransitions(ch chan<- models.Issuance) {
+	r.events = ch
 }
 
 func (r *Relayer) AcceptIssuance(issuance *models.Issuance) error {
 	logging.Logger.Debug("Issuance accepted", zap.String("assetID", issuance.Price.AssetID))
+	if issuance.State != models.Approved {
+		logging.Logger.Debug("Not relaying issuance",
+			zap.String("issuance", issuance.ID), zap.String("state", issuance.State.String()))
+		return nil
+	}
+	if r.outbox != nil {
+		return r.enqueue(issuance)
+	}
 	if len(r.contractToRoutineChMap) == 0 {
-		return fmt.Errorf("no relayer contract routines are active")
+		return fmt.Errorf("no relayer contracts are configured")
 	}
 
-	for contractKey, ch := range r.contractToRoutineChMap {
+	for _, ctrct := range r.cfg.Contracts {
+		contractKey := contractKey(ctrct)
+		ch, ok := r.contractToRoutineChMap[contractKey]
+		if !ok || !r.routeIssuance(ctrct, issuance) {
+			continue
+		}
+		r.transition(context.Background(), issuance, ctrct, models.Queued, nil)
 		logging.Logger.Info(
 			"Sending issuance to contract channel",
 			zap.String("assetID", issuance.Price.AssetID),
@@ -89,16 +216,22 @@ func (r *Relayer) AcceptIssuance(issuance *models.Issuance) error {
 	return nil
 }
 
+// flushInterval is how often a contract's batch is flushed
+func flushInterval(batchCfg config.RelayerBatchConfig) time.Duration {
+	if batchCfg.FlushIntervalSeconds <= 0 {
+		return 3 * time.Second
+	}
+	return time.Duration(batchCfg.FlushIntervalSeconds) * time.Second
+}
+
 func (r *Relayer) startRoutine(ctx context.Context, ctrct config.ContractConfig, ch <-chan *models.Issuance) {
-	maxBatch := r.cfg.RelayerBatch.MaxIssuances
+	batchCfg := ctrct.BatchConfig(r.cfg.RelayerBatch)
+	maxBatch := batchCfg.MaxIssuances
 	if maxBatch <= 0 {
 		maxBatch = 20
 	}
 
-	flushEvery := time.Duration(r.cfg.RelayerBatch.FlushIntervalSeconds) * time.Second
-	if flushEvery <= 0 {
-		flushEvery = 3 * time.Second
-	}
+	flushEvery := flushInterval(batchCfg)
 
 	ticker := time.NewTicker(flushEvery)
 	defer ticker.Stop()
@@ -108,8 +241,12 @@ func (r *Relayer) startRoutine(ctx context.Context, ctrct config.ContractConfig,
 		if len(batch) == 0 {
 			return
 		}
+		if !r.chainHealthy(ctx, ctrct) {
+			batch = r.holdBatch(ctx, batch, ctrct)
+			return
+		}
 		if err := r.ConveyBatchIssuancesToContract(ctx, batch, ctrct); err != nil {
-			logging.Logger.Error("Failed to convey issuance batch", zap.Error(err), zap.String("contract", r.contractKey(ctrct)))
+			logging.Logger.Error("Failed to convey issuance batch", zap.Error(err), zap.String("contract", contractKey(ctrct)))
 		}
 		batch = batch[:0]
 	}
@@ -142,109 +279,157 @@ func (r *Relayer) ConveyIssuanceToContract(ctx context.Context, issuance *models
 }
 
 func (r *Relayer) ConveyBatchIssuancesToContract(ctx context.Context, issuances []*models.Issuance, ctrct config.ContractConfig) error {
+	_, err := r.conveyBatch(ctx, issuances, ctrct, nil)
+	return err
+}
+
+// conveyBatch submits a batch and hands it to the tracker. The outbox
+// entries of the issuances that went out are settled by the tracker and
+// returned, the caller settles the rest.
+func (r *Relayer) conveyBatch(ctx context.Context, issuances []*models.Issuance, ctrct config.ContractConfig, relays []models.RelayOutboxEntry) (inFlight []models.RelayOutboxEntry, err error) {
 	if len(issuances) == 0 {
-		return nil
+		return nil, nil
 	}
 
+	latestByAsset := r.latestIssuancesByAsset(issuances)
+	r.markSuperseded(ctx, issuances, latestByAsset, ctrct)
+	defer func() {
+		if err == nil {
+			return
+		}
+		for _, issuance := range latestByAsset {
+			r.transition(ctx, issuance, ctrct, models.Failed, func(tr *models.IssuanceTransition) {
+				tr.Error = err.Error()
+			})
+		}
+	}()
+
 	logging.Logger.Debug(
 		"conveying issuance batch to contract",
 		zap.Int("batchSize", len(issuances)),
 		zap.String("chainId", ctrct.ChainID),
 	)
 
-	rpcUrl := ctrct.RPC
-	if rpcUrl == "" {
-		rpcUrl = os.Getenv("ALCHEMY_URL")
-		if rpcUrl == "" {
-			logging.Logger.Error("RPC URL not set")
-			return fmt.Errorf("RPC URL not set")
-		}
-	}
-	client, err := ethclient.Dial(rpcUrl)
-	if err != nil {
-		logging.Logger.Error("Failed to connect to Ethereum client", zap.Error(err))
-		return err
-	}
-	privateKey, err := crypto.HexToECDSA(r.cfg.PrivateKey)
-	if err != nil {
-		logging.Logger.Error("Failed to load private key", zap.Error(err))
-		return err
-	}
-
-	publicKey := privateKey.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		logging.Logger.Error("Failed to assert public key type", zap.Error(err))
-		return fmt.Errorf("cannot assert type: publicKey is not of type *ecdsa.PublicKey")
-	}
-
-	fromAddress := crypto.PubkeyToAddress(*publicKeyECDSA)
-	chainLock := r.chainLocks[ctrct.ChainID]
-	if chainLock == nil {
-		chainLock = &sync.Mutex{}
-		r.chainLocks[ctrct.ChainID] = chainLock
-	}
-
-	chainLock.Lock()
-	defer chainLock.Unlock()
-
-	nonce, err := client.PendingNonceAt(ctx, fromAddress)
+	client, err := r.clients(ctrct.ChainID)
 	if err != nil {
-		logging.Logger.Error("Failed to get nonce", zap.Error(err), zap.String("chainId", ctrct.ChainID))
-		return err
+		logging.Logger.Error("No RPC client for chain", zap.String("chainId", ctrct.ChainID), zap.Error(err))
+		return nil, err
 	}
-
-	gasPrice, err := client.SuggestGasPrice(ctx)
+	key, err := r.keyFor(ctrct.ChainID)
 	if err != nil {
-		logging.Logger.Error("Failed to suggest gas price", zap.Error(err))
-		return err
+		logging.Logger.Error("Failed to load relayer key", zap.String("chainId", ctrct.ChainID), zap.Error(err))
+		return nil, err
 	}
+	fromAddress := key.address
 
 	chainID, err := strconv.ParseInt(ctrct.ChainID, 10, 64)
 	if err != nil {
 		logging.Logger.Error("Failed to parse chain ID", zap.Error(err))
-		return err
-	}
-	auth, err := bind.NewKeyedTransactorWithChainID(privateKey, big.NewInt(chainID))
-	if err != nil {
-		logging.Logger.Error("Failed to create new keyed transactor", zap.Error(err))
-		return err
+		return nil, err
 	}
+	auth := signer.TransactOpts(ctx, key.signer, big.NewInt(chainID))
 
-	auth.Nonce = big.NewInt(int64(nonce))
 	auth.Value = big.NewInt(0) // in wei
-	auth.GasPrice = gasPrice
 
 	address := common.HexToAddress(ctrct.Address)
 	// Load the verifier contract
 	contract, err := importVerifier.NewVerifier(address, client)
 	if err != nil {
 		logging.Logger.Error("Failed to load verifier contract", zap.Error(err))
-		return fmt.Errorf("failed to load verifier contract: %w", err)
+		return nil, fmt.Errorf("failed to load verifier contract: %w", err)
 	}
 
 	// Prepare inputs
-	latestByAsset := r.latestIssuancesByAsset(issuances)
 	assetIDs := make([]string, 0, len(latestByAsset))
 	for assetID := range latestByAsset {
 		assetIDs = append(assetIDs, assetID)
 	}
 	sort.Strings(assetIDs)
 
-	assetIndex := make([][32]byte, 0, len(assetIDs))
-	prices := make([]importVerifier.IIfaPriceFeedPriceFeed, 0, len(assetIDs))
+	entries := make([]feedEntry, 0, len(assetIDs))
 	for _, assetID := range assetIDs {
 		issuance := latestByAsset[assetID]
-		assetIndex = append(assetIndex, utils.HexToBytes32(assetID))
-		prices = append(prices, importVerifier.IIfaPriceFeedPriceFeed{
-			Price:          utils.Float64ToBigInt(issuance.Price.Value),
-			Decimal:        int8(issuance.Price.Expo),
-			LastUpdateTime: uint64(issuance.Price.Timestamp.Unix()),
+		value, decimal := onChainPrice(issuance.Price, ctrct.Decimals)
+		entries = append(entries, feedEntry{
+			index: utils.HexToBytes32(assetID),
+			price: importVerifier.IIfaPriceFeedPriceFeed{
+				Price:          value,
+				Decimal:        decimal,
+				LastUpdateTime: uint64(issuance.Price.Timestamp.Unix()),
+			},
+			issuance: issuance,
+		})
+	}
+
+	entries, rejected, err := preflight(ctx, client, fromAddress, address, entries)
+	if err != nil {
+		logging.Logger.Error("Price feed batch fails simulation", zap.String("chainId", ctrct.ChainID), zap.Error(err))
+		return nil, err
+	}
+	for _, rej := range rejected {
+		issuance := rej.entry.issuance
+		logging.Logger.Warn("Dropping asset the contract refuses from the batch",
+			zap.String("chainId", ctrct.ChainID),
+			zap.String("assetID", issuance.Price.AssetID),
+			zap.Error(rej.err),
+		)
+		// failed here, not again by a batch error
+		delete(latestByAsset, issuance.Price.AssetID)
+		r.transition(ctx, issuance, ctrct, models.Failed, func(tr *models.IssuanceTransition) {
+			tr.Error = rej.err.Error()
 		})
 	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	assetIndex := make([][32]byte, 0, len(entries))
+	prices := make([]importVerifier.IIfaPriceFeedPriceFeed, 0, len(entries))
+	submitted := make([]*models.Issuance, 0, len(entries))
+	// the verifier has no entrypoint taking the node signatures yet, a
+	// multi node report is submitted as its quorum value and the signatures
+	// stay in consensus_reports for anyone verifying the submission
+	signatures := 0
+	for _, entry := range entries {
+		if entry.issuance.Report != nil {
+			signatures += len(entry.issuance.Report.Observations)
+		}
+		assetIndex = append(assetIndex, entry.index)
+		prices = append(prices, entry.price)
+		submitted = append(submitted, entry.issuance)
+	}
+
+	calldata, err := packSubmission(entries)
+	if err != nil {
+		return nil, fmt.Errorf("packing price feed: %w", err)
+	}
+	quote, err := quoteFees(ctx, client, ctrct.Fees, ethereum.CallMsg{From: fromAddress, To: &address, Data: calldata})
+	if err != nil {
+		err = decodeRevert(err)
+		logging.Logger.Error("Failed to price price feed tx", zap.String("chainId", ctrct.ChainID), zap.Error(err))
+		return nil, err
+	}
+	budget := r.spendWindow(ctrct)
+	spendID, err := budget.Reserve(ctx, quote.maxCost())
+	if err != nil {
+		logging.Logger.Error("Not sending price feed tx", zap.String("chainId", ctrct.ChainID), zap.Error(err))
+		return nil, err
+	}
+	quote.apply(auth)
+
+	nonce, err := r.nonces.Next(ctx, client, ctrct.ChainID, fromAddress)
+	if err != nil {
+		budget.Release(ctx, spendID)
+		logging.Logger.Error("Failed to get nonce", zap.Error(err), zap.String("chainId", ctrct.ChainID))
+		return nil, err
+	}
+	auth.Nonce = new(big.Int).SetUint64(nonce)
 
 	tx, err := contract.SubmitPriceFeed(auth, assetIndex, prices)
 	if err != nil {
+		err = decodeRevert(err)
+		budget.Release(ctx, spendID)
+		r.nonces.Release(ctx, ctrct.ChainID, fromAddress, nonce)
 		logging.Logger.Error(
 			"Failed to submit price feed",
 			zap.Int64("chainID", chainID),
@@ -252,7 +437,7 @@ func (r *Relayer) ConveyBatchIssuancesToContract(ctx context.Context, issuances
 			zap.String("Contract", address.String()),
 			zap.Error(err),
 		)
-		return fmt.Errorf("failed to submit price feed: %w", err)
+		return nil, fmt.Errorf("failed to submit price feed: %w", err)
 	}
 
 	logging.Logger.Info(
@@ -261,9 +446,44 @@ func (r *Relayer) ConveyBatchIssuancesToContract(ctx context.Context, issuances
 		zap.String("chainID", ctrct.ChainID),
 		zap.Int("requestedIssuances", len(issuances)),
 		zap.Int("submittedFeeds", len(prices)),
+		zap.String("from", fromAddress.Hex()),
+		zap.Uint64("nonce", nonce),
+		zap.Int("reportSignatures", signatures),
+		zap.Uint64("gasLimit", quote.GasLimit),
+		zap.Stringer("maxCost", quote.maxCost()),
 	)
 
-	return nil
+	for _, issuance := range submitted {
+		r.transition(ctx, issuance, ctrct, models.Submitted, func(tr *models.IssuanceTransition) {
+			tr.TxHash = tx.Hash().Hex()
+			tr.Issuer = fromAddress.Hex()
+		})
+	}
+	// refused assets are out of latestByAsset, so are superseded issuances
+	for _, relay := range relays {
+		if latest := latestByAsset[relay.Issuance.Price.AssetID]; latest != nil && latest.ID == relay.Issuance.ID {
+			inFlight = append(inFlight, relay)
+		}
+	}
+	tracked := &trackedTx{
+		ctrct:     ctrct,
+		client:    client,
+		from:      fromAddress,
+		sign:      auth.Signer,
+		issuances: submitted,
+		relays:    inFlight,
+		attempts:  []*types.Transaction{tx},
+		sentAt:    time.Now(),
+		budget:    budget,
+		spendID:   spendID,
+	}
+	r.routines.Add(1)
+	go func() {
+		defer r.routines.Done()
+		r.track(ctx, tracked)
+	}()
+
+	return inFlight, nil
 }
 
 func (r *Relayer) latestIssuancesByAsset(issuances []*models.Issuance) map[string]*models.Issuance {
@@ -280,7 +500,30 @@ func (r *Relayer) latestIssuancesByAsset(issuances []*models.Issuance) map[strin
 	return latestByAsset
 }
 
-func (r *Relayer) contractKey(ctrct config.ContractConfig) string {
+// spendWindow returns the hourly spend of the contract's chain, contracts
+// on one chain share it and the strictest cap
+func (r *Relayer) spendWindow(ctrct config.ContractConfig) *spendWindow {
+	r.budgetsMu.Lock()
+	defer r.budgetsMu.Unlock()
+	if w, ok := r.budgets[ctrct.ChainID]; ok {
+		return w
+	}
+
+	var limit *big.Int
+	for _, c := range r.cfg.Contracts {
+		if c.ChainID != ctrct.ChainID {
+			continue
+		}
+		if cap := etherToWei(c.Fees.MaxSpendPerHour); cap != nil && (limit == nil || cap.Cmp(limit) < 0) {
+			limit = cap
+		}
+	}
+	w := newSpendWindow(ctrct.ChainID, limit, r.spend)
+	r.budgets[ctrct.ChainID] = w
+	return w
+}
+
+func contractKey(ctrct config.ContractConfig) string {
 	return fmt.Sprintf("%s:%s", ctrct.ChainID, ctrct.Address)
 }
 
@@ -0,0 +1,129 @@
+package relayer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	importVerifier "oracle_engine/pkg/abi"
+)
+
+var oracleErrorsABI, _ = abi.JSON(strings.NewReader(importVerifier.OracleErrorsABI))
+
+// the custom errors of the oracle contracts the relayer acts on, every
+// decoded revert also matches ErrReverted
+var (
+	ErrReverted                       = errors.New("execution reverted")
+	ErrInvalidAssetPrice              = errors.New("invalid asset price")
+	ErrInvalidAssetIndexOrPriceLength = errors.New("asset index and price lengths differ")
+	ErrOnlyRelayerNode                = errors.New("caller is not the relayer node")
+	ErrInvalidSender                  = errors.New("invalid sender")
+	ErrUnauthorized                   = errors.New("unauthorized")
+)
+
+var panicSelector = crypto.Keccak256([]byte("Panic(uint256)"))[:4]
+
+var customErrors = map[string]error{
+	"InvalidAssePrice":               ErrInvalidAssetPrice,
+	"InvalidAssetIndexorPriceLength": ErrInvalidAssetIndexOrPriceLength,
+	"OnlyRelayerNode":                ErrOnlyRelayerNode,
+	"InvalidSender":                  ErrInvalidSender,
+	"Unauthorized":                   ErrUnauthorized,
+}
+
+// RevertError is a reverted call with its decoded revert data
+type RevertError struct {
+	Name string        // custom error name, "Error" for a reason string, "Panic" for a solidity panic
+	Args []interface{} // arguments of the custom error, or the reason
+	Data []byte
+	kind error
+}
+
+func (e *RevertError) Error() string {
+	return "execution reverted: " + e.Reason()
+}
+
+// Reason is the reason string, or the custom error with its arguments
+func (e *RevertError) Reason() string {
+	switch e.Name {
+	case "":
+		return hexutil.Encode(e.Data)
+	case "Error", "Panic":
+		return fmt.Sprint(e.Args...)
+	}
+	args := make([]string, len(e.Args))
+	for i, arg := range e.Args {
+		args[i] = fmt.Sprint(arg)
+	}
+	return fmt.Sprintf("%s(%s)", e.Name, strings.Join(args, ", "))
+}
+
+func (e *RevertError) Unwrap() []error {
+	if e.kind == nil {
+		return []error{ErrReverted}
+	}
+	return []error{e.kind, ErrReverted}
+}
+
+// decodeRevert turns the revert data of a failed call into a RevertError,
+// errors without revert data come back unchanged
+func decodeRevert(err error) error {
+	if err == nil {
+		return nil
+	}
+	var revert *RevertError
+	if errors.As(err, &revert) {
+		return err
+	}
+	var dataErr rpc.DataError
+	if !errors.As(err, &dataErr) {
+		return err
+	}
+	encoded, ok := dataErr.ErrorData().(string)
+	if !ok {
+		return err
+	}
+	data, decodeErr := hexutil.Decode(encoded)
+	if decodeErr != nil || len(data) < 4 {
+		return err
+	}
+	return parseRevert(data)
+}
+
+func parseRevert(data []byte) *RevertError {
+	revert := &RevertError{Data: data}
+	if reason, err := abi.UnpackRevert(data); err == nil {
+		revert.Name = "Error"
+		if bytes.Equal(data[:4], panicSelector) {
+			revert.Name = "Panic"
+		}
+		revert.Args = []interface{}{reason}
+		return revert
+	}
+	for name, customErr := range oracleErrorsABI.Errors {
+		if !bytes.Equal(customErr.ID[:4], data[:4]) {
+			continue
+		}
+		revert.Name = name
+		revert.kind = customErrors[name]
+		if args, err := customErr.Unpack(data); err == nil {
+			if values, ok := args.([]interface{}); ok {
+				revert.Args = values
+			}
+		}
+		break
+	}
+	return revert
+}
+
+// perAsset reports whether a revert is about one of the assets in the
+// call rather than the call as a whole
+func perAsset(err error) bool {
+	return errors.Is(err, ErrInvalidAssetPrice)
+}
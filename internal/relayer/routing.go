@@ -0,0 +1,167 @@
+package relayer
+
+import (
+	"math"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+
+	"oracle_engine/internal/config"
+	"oracle_engine/internal/consensus/policy"
+	"oracle_engine/internal/logging"
+	"oracle_engine/internal/models"
+	"oracle_engine/internal/registry"
+	"oracle_engine/internal/utils"
+
+	"go.uber.org/zap"
+)
+
+/*
+Routing:
+A contract carries the assets it lists, or every asset when it lists
+none. On top of the asset's own push policy each contract can set its
+own deviation threshold and heartbeat, judged against what was last
+confirmed on that contract, so a mainnet can update less often than the
+testnet next to it. Asset names resolve against the registry and follow
+its reloads.
+*/
+
+var assetIDPattern = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+// routesFor resolves the assets of every contract to asset ids, names
+// resolve against assets
+func routesFor(contracts []config.ContractConfig, assets []config.AssetConfig) map[string]map[string]struct{} {
+	byName := make(map[string]string, len(assets))
+	known := make(map[string]struct{}, len(assets))
+	for _, asset := range assets {
+		byName[strings.ToLower(asset.Name)] = asset.InternalAssetIdentity
+		known[utils.GenerateIDForAsset(asset.InternalAssetIdentity)] = struct{}{}
+	}
+
+	routes := make(map[string]map[string]struct{})
+	for _, ctrct := range contracts {
+		if len(ctrct.Assets) == 0 {
+			continue
+		}
+		ids := make(map[string]struct{}, len(ctrct.Assets))
+		for _, entry := range ctrct.Assets {
+			id := resolveAsset(entry, byName)
+			if _, ok := known[id]; !ok {
+				logging.Logger.Warn("Contract lists an asset that is not configured",
+					zap.String("contract", contractKey(ctrct)), zap.String("asset", entry))
+			}
+			ids[id] = struct{}{}
+		}
+		routes[contractKey(ctrct)] = ids
+	}
+	return routes
+}
+
+// UseRegistry resolves the asset names contracts list against the
+// registry, again on every reload of it
+func (r *Relayer) UseRegistry(reg *registry.Registry) {
+	r.setRoutes(reg.Assets())
+	reg.Subscribe(func(registry.Change) {
+		r.setRoutes(reg.Assets())
+	})
+}
+
+func (r *Relayer) setRoutes(assets []config.AssetConfig) {
+	routes := routesFor(r.cfg.Contracts, assets)
+	r.routesMu.Lock()
+	r.routes = routes
+	r.routesMu.Unlock()
+}
+
+func resolveAsset(entry string, byName map[string]string) string {
+	entry = strings.TrimSpace(entry)
+	if assetIDPattern.MatchString(entry) {
+		return strings.ToLower(entry)
+	}
+	if identity, ok := byName[strings.ToLower(entry)]; ok {
+		return utils.GenerateIDForAsset(identity)
+	}
+	return utils.GenerateIDForAsset(entry)
+}
+
+// Carries reports whether the contract publishes the asset
+func (r *Relayer) Carries(ctrct config.ContractConfig, assetID string) bool {
+	r.routesMu.RLock()
+	ids, ok := r.routes[contractKey(ctrct)]
+	r.routesMu.RUnlock()
+	if !ok {
+		return true
+	}
+	_, ok = ids[strings.ToLower(assetID)]
+	return ok
+}
+
+// due applies the contract's push policy to an issuance
+func (r *Relayer) due(ctrct config.ContractConfig, issuance *models.Issuance) policy.Decision {
+	if ctrct.DeviationThreshold <= 0 && ctrct.Heartbeat <= 0 {
+		return policy.Decision{Issue: true}
+	}
+
+	r.publishedMu.Lock()
+	last := r.published[contractKey(ctrct)+"|"+issuance.Price.AssetID]
+	r.publishedMu.Unlock()
+
+	threshold := ctrct.DeviationThreshold
+	setting := config.AssetSetting{DeviationThreshold: &threshold, Heartbeat: ctrct.Heartbeat}
+	return policy.Evaluate(setting, issuance.PriceValue, last, issuance.CreatedAt)
+}
+
+// markPublished remembers what went out to a contract, for its policy
+func (r *Relayer) markPublished(ctrct config.ContractConfig, issuances []*models.Issuance, at time.Time) {
+	r.publishedMu.Lock()
+	defer r.publishedMu.Unlock()
+	for _, issuance := range issuances {
+		last := *issuance
+		last.CreatedAt = at
+		r.published[contractKey(ctrct)+"|"+issuance.Price.AssetID] = &last
+	}
+}
+
+// routeIssuance reports whether an issuance goes to the contract
+func (r *Relayer) routeIssuance(ctrct config.ContractConfig, issuance *models.Issuance) bool {
+	if !r.Carries(ctrct, issuance.Price.AssetID) {
+		return false
+	}
+	decision := r.due(ctrct, issuance)
+	if !decision.Issue {
+		logging.Logger.Debug("Contract policy skipped issuance",
+			zap.String("contract", contractKey(ctrct)),
+			zap.String("assetID", issuance.Price.AssetID),
+			zap.String("reason", decision.Reason),
+			zap.Float64("deviation", decision.Deviation),
+		)
+	}
+	return decision.Issue
+}
+
+// onChainPrice scales a price to the contract's decimals, the aggregate's
+// value and exponent when it sets none
+func onChainPrice(price models.UnifiedPrice, decimals int) (*big.Int, int8) {
+	if decimals <= 0 {
+		return utils.Float64ToBigInt(price.Value), price.Expo
+	}
+
+	shift := int(price.Expo) + decimals
+	scaled := new(big.Float).SetFloat64(price.Value)
+	factor := new(big.Float).SetFloat64(math.Pow10(abs(shift)))
+	if shift >= 0 {
+		scaled.Mul(scaled, factor)
+	} else {
+		scaled.Quo(scaled, factor)
+	}
+	value, _ := scaled.Int(nil)
+	return value, int8(-decimals)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
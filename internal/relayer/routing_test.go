@@ -0,0 +1,117 @@
+package relayer
+
+import (
+	"testing"
+	"time"
+
+	"oracle_engine/internal/config"
+	"oracle_engine/internal/logging"
+	"oracle_engine/internal/models"
+	"oracle_engine/internal/registry"
+	"oracle_engine/internal/utils"
+
+	"go.uber.org/zap"
+)
+
+func routingIssuance(identity string, value float64, at time.Time) *models.Issuance {
+	id := utils.GenerateIDForAsset(identity)
+	return &models.Issuance{
+		ID:         identity + at.String(),
+		State:      models.Approved,
+		CreatedAt:  at,
+		PriceValue: value,
+		Price:      models.UnifiedPrice{AssetID: id, Value: value * 1e18, Expo: -18, Timestamp: at},
+	}
+}
+
+func TestAcceptIssuanceRoutesAssetsToTheContractsCarryingThem(t *testing.T) {
+	logging.Logger = zap.NewNop()
+	testnet := config.ContractConfig{ChainID: "84532", Address: "0x01"}
+	mainnet := config.ContractConfig{ChainID: "8453", Address: "0x02", Assets: []string{"USDT/USD"}}
+	r := New(&config.Config{
+		Assets: []config.AssetConfig{
+			{Name: "USDT/USD", InternalAssetIdentity: "0xUSDT"},
+			{Name: "CNGN/USD", InternalAssetIdentity: "0xCNGN"},
+		},
+		Contracts: []config.ContractConfig{testnet, mainnet},
+	}, nil, nil)
+	testnetCh := make(chan *models.Issuance, 4)
+	mainnetCh := make(chan *models.Issuance, 4)
+	r.contractToRoutineChMap[contractKey(testnet)] = testnetCh
+	r.contractToRoutineChMap[contractKey(mainnet)] = mainnetCh
+
+	now := time.Unix(1_750_000_000, 0)
+	if err := r.AcceptIssuance(routingIssuance("0xUSDT", 1, now)); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.AcceptIssuance(routingIssuance("0xCNGN", 0.0006, now)); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(testnetCh) != 2 {
+		t.Fatalf("testnet got %d issuances, want every asset", len(testnetCh))
+	}
+	if len(mainnetCh) != 1 || (<-mainnetCh).Price.AssetID != utils.GenerateIDForAsset("0xUSDT") {
+		t.Fatal("mainnet should only carry USDT")
+	}
+}
+
+func TestRoutesResolveNamesAgainstTheRegistry(t *testing.T) {
+	logging.Logger = zap.NewNop()
+	mainnet := config.ContractConfig{ChainID: "8453", Address: "0x02", Assets: []string{"CNGN/USD"}}
+	// started before the asset was configured
+	r := New(&config.Config{Contracts: []config.ContractConfig{mainnet}}, nil, nil)
+	cngn := utils.GenerateIDForAsset("0xCNGN")
+	if r.Carries(mainnet, cngn) {
+		t.Fatal("an unknown name resolved to the asset")
+	}
+
+	reg, err := registry.New(&config.Config{Assets: []config.AssetConfig{{
+		Name: "CNGN/USD", InternalAssetIdentity: "0xCNGN",
+		Feeds: []config.FeedConfig{{Name: "pyth"}},
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.UseRegistry(reg)
+	if !r.Carries(mainnet, cngn) {
+		t.Fatal("the contract does not carry the asset the registry names")
+	}
+}
+
+func TestContractPolicyWaitsForDeviationOrHeartbeat(t *testing.T) {
+	logging.Logger = zap.NewNop()
+	ctrct := config.ContractConfig{ChainID: "8453", Address: "0x02", DeviationThreshold: 0.01, Heartbeat: 3600}
+	r := New(&config.Config{Contracts: []config.ContractConfig{ctrct}}, nil, nil)
+
+	now := time.Unix(1_750_000_000, 0)
+	first := routingIssuance("0xUSDT", 1, now)
+	if !r.routeIssuance(ctrct, first) {
+		t.Fatal("first issuance of an asset was skipped")
+	}
+	r.markPublished(ctrct, []*models.Issuance{first}, now)
+
+	if r.routeIssuance(ctrct, routingIssuance("0xUSDT", 1.005, now.Add(time.Minute))) {
+		t.Fatal("published a 0.5% move on a contract with a 1% threshold")
+	}
+	if !r.routeIssuance(ctrct, routingIssuance("0xUSDT", 1.02, now.Add(time.Minute))) {
+		t.Fatal("skipped a 2% move")
+	}
+	if !r.routeIssuance(ctrct, routingIssuance("0xUSDT", 1, now.Add(2*time.Hour))) {
+		t.Fatal("skipped an unmoved asset past the heartbeat")
+	}
+}
+
+func TestOnChainPriceScalesToContractDecimals(t *testing.T) {
+	price := models.UnifiedPrice{Value: 1.2345e18, Expo: -18}
+
+	value, decimal := onChainPrice(price, 8)
+	if value.Int64() != 123450000 || decimal != -8 {
+		t.Fatalf("got %s with decimal %d, want 123450000 with -8", value, decimal)
+	}
+
+	value, decimal = onChainPrice(price, 0)
+	if value.String() != "1234500000000000000" || decimal != -18 {
+		t.Fatalf("got %s with decimal %d, want the aggregate unchanged", value, decimal)
+	}
+}
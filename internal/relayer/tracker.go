@@ -0,0 +1,425 @@
+package relayer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"oracle_engine/internal/config"
+	"oracle_engine/internal/logging"
+	"oracle_engine/internal/models"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"go.uber.org/zap"
+)
+
+/*
+Tracker:
+Every submitted batch is followed until it is final. Receipts are polled
+for every tx sent with the batch's nonce, a revert is replayed to decode
+its reason. A tx unmined after stuck_timeout is replaced with the same
+nonce at a bumped fee, up to max_replacements. A mined tx is watched for
+finality_blocks, when a reorg drops it the issuances go back to submitted
+and the tx is sent again. The outbox entries of a batch stay claimed
+while it is tracked, they are done once it is confirmed and go back to
+the outbox when it fails.
+*/
+
+// trackerClient is what the tracker needs from a chain, a ChainClient satisfies it
+type trackerClient interface {
+	BlockNumber(ctx context.Context) (uint64, error)
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+	SendTransaction(ctx context.Context, tx *types.Transaction) error
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+	CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+}
+
+// trackedTx is a submitted batch and every tx sent for its nonce
+type trackedTx struct {
+	ctrct        config.ContractConfig
+	client       trackerClient
+	from         common.Address
+	sign         bind.SignerFn
+	issuances    []*models.Issuance
+	relays       []models.RelayOutboxEntry // outbox entries of the issuances, nil without an outbox
+	attempts     []*types.Transaction      // newest last
+	sentAt       time.Time                 // of the newest attempt
+	replacements int
+	mined        *types.Transaction
+	receipt      *types.Receipt
+	l1Fee        *big.Int // of the mined tx on OP stack chains
+	confirmed    bool
+	budget       *spendWindow // hourly spend of the chain, nil when untracked
+	spendID      string
+}
+
+func (r *Relayer) trackerConfig() config.TxTrackerConfig {
+	cfg := r.cfg.TxTracker
+	if cfg.PollIntervalSeconds <= 0 {
+		cfg.PollIntervalSeconds = 5
+	}
+	if cfg.StuckTimeoutSeconds <= 0 {
+		cfg.StuckTimeoutSeconds = 120
+	}
+	if cfg.FeeBumpPercent < 10 {
+		cfg.FeeBumpPercent = 10
+	}
+	if cfg.Confirmations == 0 {
+		cfg.Confirmations = 1
+	}
+	if cfg.FinalityBlocks < cfg.Confirmations {
+		cfg.FinalityBlocks = cfg.Confirmations
+	}
+	return cfg
+}
+
+// track follows a submitted batch until it is final or failed
+func (r *Relayer) track(ctx context.Context, t *trackedTx) {
+	// replacements reuse the nonce, so the key is free once the batch is done
+	defer r.nonces.Done(t.ctrct.ChainID, t.from, t.attempts[0].Nonce())
+
+	cfg := r.trackerConfig()
+	ticker := time.NewTicker(time.Duration(cfg.PollIntervalSeconds) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		if r.poll(ctx, t, cfg) {
+			return
+		}
+		r.holdRelays(ctx, t)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll advances a tracked batch by one step, true once it is done
+func (r *Relayer) poll(ctx context.Context, t *trackedTx, cfg config.TxTrackerConfig) bool {
+	head, err := t.client.BlockNumber(ctx)
+	if err != nil {
+		logging.Logger.Warn("Tracker failed to fetch head block",
+			zap.String("contract", contractKey(t.ctrct)), zap.Error(err))
+		return false
+	}
+	if t.receipt == nil {
+		return r.pollPending(ctx, t, cfg, head)
+	}
+	return r.pollMined(ctx, t, cfg, head)
+}
+
+func (r *Relayer) pollPending(ctx context.Context, t *trackedTx, cfg config.TxTrackerConfig, head uint64) bool {
+	for i := len(t.attempts) - 1; i >= 0; i-- {
+		receipt, err := t.client.TransactionReceipt(ctx, t.attempts[i].Hash())
+		if err != nil || receipt == nil {
+			continue
+		}
+		t.mined = t.attempts[i]
+		t.receipt = receipt
+		t.l1Fee = readL1Fee(ctx, t)
+		if t.budget != nil {
+			t.budget.Settle(ctx, t.spendID, txCost(t))
+		}
+		r.recordCosts(ctx, t)
+		logging.Logger.Info("Price feed batch mined",
+			zap.String("tx", t.mined.Hash().Hex()),
+			zap.String("chainID", t.ctrct.ChainID),
+			zap.Uint64("block", receipt.BlockNumber.Uint64()),
+			zap.Uint64("gasUsed", receipt.GasUsed),
+			zap.Uint64("status", receipt.Status),
+		)
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			r.finish(ctx, t, models.Failed, "transaction reverted: "+r.revertReason(ctx, t))
+			return true
+		}
+		return r.pollMined(ctx, t, cfg, head)
+	}
+
+	stuckFor := time.Since(t.sentAt)
+	if stuckFor < time.Duration(cfg.StuckTimeoutSeconds)*time.Second {
+		return false
+	}
+	if t.replacements >= cfg.MaxReplacements {
+		r.finish(ctx, t, models.Failed, fmt.Sprintf("not mined after %d replacements", t.replacements))
+		return true
+	}
+	r.replace(ctx, t, cfg, stuckFor)
+	return false
+}
+
+func (r *Relayer) pollMined(ctx context.Context, t *trackedTx, cfg config.TxTrackerConfig, head uint64) bool {
+	// the node's own receipt, header hashes computed here differ on some L2s
+	block := t.receipt.BlockNumber
+	current, err := t.client.TransactionReceipt(ctx, t.mined.Hash())
+	if errors.Is(err, ethereum.NotFound) || (err == nil && current.BlockHash != t.receipt.BlockHash) {
+		r.reorged(ctx, t)
+		return false
+	}
+	if err != nil {
+		return false
+	}
+
+	if head < block.Uint64() {
+		return false
+	}
+	depth := head - block.Uint64() + 1
+	if !t.confirmed && depth >= cfg.Confirmations {
+		t.confirmed = true
+		r.markPublished(t.ctrct, t.issuances, time.Now())
+		r.completeRelays(ctx, t)
+		for _, issuance := range t.issuances {
+			r.transition(ctx, issuance, t.ctrct, models.Confirmed, func(tr *models.IssuanceTransition) {
+				tr.TxHash = t.mined.Hash().Hex()
+				tr.Issuer = t.from.Hex()
+				tr.BlockNumber = block.Uint64()
+				tr.GasUsed = t.receipt.GasUsed
+			})
+		}
+	}
+	return t.confirmed && depth >= cfg.FinalityBlocks
+}
+
+// reorged puts a batch whose tx was dropped from the chain back in flight
+func (r *Relayer) reorged(ctx context.Context, t *trackedTx) {
+	logging.Logger.Warn("Mined price feed tx reorged out, resubmitting",
+		zap.String("tx", t.mined.Hash().Hex()),
+		zap.String("chainID", t.ctrct.ChainID),
+		zap.Uint64("block", t.receipt.BlockNumber.Uint64()),
+	)
+	if t.confirmed {
+		block := t.receipt.BlockNumber.Uint64()
+		for _, issuance := range t.issuances {
+			r.transition(ctx, issuance, t.ctrct, models.Submitted, func(tr *models.IssuanceTransition) {
+				tr.TxHash = t.mined.Hash().Hex()
+				tr.Issuer = t.from.Hex()
+				tr.Error = fmt.Sprintf("reorged out of block %d, resubmitted", block)
+			})
+		}
+	}
+	r.forgetCosts(ctx, t)
+	if err := t.client.SendTransaction(ctx, t.mined); err != nil && !isKnownTx(err) {
+		logging.Logger.Warn("Failed to resubmit reorged tx", zap.String("tx", t.mined.Hash().Hex()), zap.Error(err))
+	}
+	if t.budget != nil {
+		t.budget.Settle(ctx, t.spendID, txMaxCost(t.mined))
+	}
+	t.receipt = nil
+	t.l1Fee = nil
+	t.mined = nil
+	t.confirmed = false
+	t.sentAt = time.Now()
+}
+
+// replace sends the newest attempt again with the same nonce at a bumped
+// fee, never above the chain's max fee
+func (r *Relayer) replace(ctx context.Context, t *trackedTx, cfg config.TxTrackerConfig, stuckFor time.Duration) {
+	t.replacements++
+	t.sentAt = time.Now()
+	last := t.attempts[len(t.attempts)-1]
+
+	unsigned, err := bumpedTx(ctx, t.client, last, cfg.FeeBumpPercent, gweiToWei(t.ctrct.Fees.MaxFeeGwei))
+	if err != nil {
+		logging.Logger.Warn("Not replacing stuck price feed tx",
+			zap.String("chainID", t.ctrct.ChainID),
+			zap.String("tx", last.Hash().Hex()),
+			zap.Duration("stuckFor", stuckFor),
+			zap.Error(err),
+		)
+		return
+	}
+	if t.budget != nil {
+		if err := t.budget.Raise(ctx, t.spendID, txMaxCost(unsigned)); err != nil {
+			logging.Logger.Warn("Not replacing stuck price feed tx",
+				zap.String("chainID", t.ctrct.ChainID), zap.String("tx", last.Hash().Hex()), zap.Error(err))
+			return
+		}
+	}
+	replacement, err := t.sign(t.from, unsigned)
+	if err != nil {
+		logging.Logger.Error("Failed to sign replacement tx", zap.Error(err))
+		return
+	}
+	if err := t.client.SendTransaction(ctx, replacement); err != nil {
+		// nonce too low, one of the attempts got mined meanwhile
+		logging.Logger.Warn("Failed to send replacement tx",
+			zap.String("tx", last.Hash().Hex()), zap.Error(err))
+		return
+	}
+	t.attempts = append(t.attempts, replacement)
+
+	logging.Logger.Warn("Replaced stuck price feed tx",
+		zap.String("chainID", t.ctrct.ChainID),
+		zap.String("stuckTx", last.Hash().Hex()),
+		zap.String("replacement", replacement.Hash().Hex()),
+		zap.Uint64("nonce", last.Nonce()),
+		zap.Stringer("maxFee", replacement.GasFeeCap()),
+		zap.Duration("stuckFor", stuckFor),
+	)
+	for _, issuance := range t.issuances {
+		r.transition(ctx, issuance, t.ctrct, models.Submitted, func(tr *models.IssuanceTransition) {
+			tr.TxHash = replacement.Hash().Hex()
+			tr.Issuer = t.from.Hex()
+			tr.Error = "replaced stuck tx " + last.Hash().Hex()
+		})
+	}
+}
+
+// bumpedTx is last with the same nonce and fees raised by percent, capped
+// at maxFee when set
+func bumpedTx(ctx context.Context, client trackerClient, last *types.Transaction, percent int, maxFee *big.Int) (*types.Transaction, error) {
+	capped := func(fee *big.Int) *big.Int {
+		if maxFee != nil && fee.Cmp(maxFee) > 0 {
+			return new(big.Int).Set(maxFee)
+		}
+		return fee
+	}
+
+	if last.Type() == types.DynamicFeeTxType {
+		feeCap := capped(bumpFee(last.GasFeeCap(), percent))
+		tip := capped(bumpFee(last.GasTipCap(), percent))
+		// nodes only take a replacement that raises both
+		if feeCap.Cmp(last.GasFeeCap()) <= 0 || tip.Cmp(last.GasTipCap()) <= 0 {
+			return nil, fmt.Errorf("%w: already bidding %s wei", ErrFeeAboveCap, last.GasFeeCap())
+		}
+		if tip.Cmp(feeCap) > 0 {
+			tip = feeCap
+		}
+		return types.NewTx(&types.DynamicFeeTx{
+			ChainID:   last.ChainId(),
+			Nonce:     last.Nonce(),
+			GasTipCap: tip,
+			GasFeeCap: feeCap,
+			Gas:       last.Gas(),
+			To:        last.To(),
+			Value:     last.Value(),
+			Data:      last.Data(),
+		}), nil
+	}
+
+	gasPrice := bumpFee(last.GasPrice(), percent)
+	if suggested, err := client.SuggestGasPrice(ctx); err == nil && suggested.Cmp(gasPrice) > 0 {
+		gasPrice = suggested
+	}
+	gasPrice = capped(gasPrice)
+	if gasPrice.Cmp(last.GasPrice()) <= 0 {
+		return nil, fmt.Errorf("%w: already bidding %s wei", ErrFeeAboveCap, last.GasPrice())
+	}
+	return types.NewTx(&types.LegacyTx{
+		Nonce:    last.Nonce(),
+		GasPrice: gasPrice,
+		Gas:      last.Gas(),
+		To:       last.To(),
+		Value:    last.Value(),
+		Data:     last.Data(),
+	}), nil
+}
+
+// txMaxCost is the most a tx can cost, its fee cap times its gas
+func txMaxCost(tx *types.Transaction) *big.Int {
+	return new(big.Int).Mul(tx.GasFeeCap(), new(big.Int).SetUint64(tx.Gas()))
+}
+
+// finish records the end state of a batch on all its issuances
+func (r *Relayer) finish(ctx context.Context, t *trackedTx, to models.IssuanceState, reason string) {
+	// a tx given up on keeps its reservation, it can still be mined
+	tx := t.mined
+	if tx == nil {
+		tx = t.attempts[len(t.attempts)-1]
+	}
+	logging.Logger.Error("Price feed batch failed",
+		zap.String("tx", tx.Hash().Hex()),
+		zap.String("chainID", t.ctrct.ChainID),
+		zap.String("reason", reason),
+	)
+	for _, issuance := range t.issuances {
+		r.transition(ctx, issuance, t.ctrct, to, func(tr *models.IssuanceTransition) {
+			tr.TxHash = tx.Hash().Hex()
+			tr.Issuer = t.from.Hex()
+			tr.Error = reason
+			if t.receipt != nil {
+				tr.BlockNumber = t.receipt.BlockNumber.Uint64()
+				tr.GasUsed = t.receipt.GasUsed
+			}
+		})
+	}
+	if len(t.relays) > 0 {
+		// a shutdown mid poll still hands the entries back
+		r.settleRelays(context.WithoutCancel(ctx), t.ctrct, t.relays, errors.New(reason))
+	}
+}
+
+// holdRelays renews the lease of the entries of a batch still in flight,
+// an engine dying mid batch lets it run out
+func (r *Relayer) holdRelays(ctx context.Context, t *trackedTx) {
+	if len(t.relays) == 0 || t.confirmed {
+		return
+	}
+	if err := r.outbox.ExtendRelays(ctx, relayIDs(t.relays), r.outboxLease()); err != nil {
+		logging.Logger.Warn("Failed to renew the lease of outbox entries in flight",
+			zap.String("contract", contractKey(t.ctrct)), zap.Error(err))
+	}
+}
+
+// completeRelays marks the entries of a confirmed batch done
+func (r *Relayer) completeRelays(ctx context.Context, t *trackedTx) {
+	if len(t.relays) == 0 {
+		return
+	}
+	if err := r.outbox.CompleteRelays(ctx, relayIDs(t.relays)); err != nil {
+		// the lease runs out and the batch is sent again
+		logging.Logger.Error("Failed to complete outbox entries", zap.String("contract", contractKey(t.ctrct)), zap.Error(err))
+	}
+}
+
+func relayIDs(relays []models.RelayOutboxEntry) []int64 {
+	ids := make([]int64, len(relays))
+	for i, relay := range relays {
+		ids[i] = relay.ID
+	}
+	return ids
+}
+
+// revertReason replays a reverted tx on the state before its block
+func (r *Relayer) revertReason(ctx context.Context, t *trackedTx) string {
+	at := new(big.Int).Sub(t.receipt.BlockNumber, big.NewInt(1))
+	_, err := t.client.CallContract(ctx, ethereum.CallMsg{
+		From:     t.from,
+		To:       t.mined.To(),
+		Gas:      t.mined.Gas(),
+		GasPrice: t.mined.GasPrice(),
+		Value:    t.mined.Value(),
+		Data:     t.mined.Data(),
+	}, at)
+	if err == nil {
+		return "unknown, the replay did not revert"
+	}
+	var revert *RevertError
+	if errors.As(decodeRevert(err), &revert) {
+		return revert.Reason()
+	}
+	return err.Error()
+}
+
+func bumpFee(fee *big.Int, percent int) *big.Int {
+	bumped := new(big.Int).Mul(fee, big.NewInt(int64(100+percent)))
+	bumped.Div(bumped, big.NewInt(100))
+	// integer rounding must not eat the bump of tiny fees
+	if bumped.Cmp(fee) <= 0 {
+		bumped.Add(fee, big.NewInt(1))
+	}
+	return bumped
+}
+
+func isKnownTx(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "already known") ||
+		strings.Contains(msg, "known transaction") ||
+		strings.Contains(msg, "nonce too low")
+}
@@ -0,0 +1,298 @@
+package relayer
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"oracle_engine/internal/config"
+	"oracle_engine/internal/logging"
+	"oracle_engine/internal/models"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"go.uber.org/zap"
+)
+
+// fakeChain mines what the test tells it to
+type fakeChain struct {
+	mu       sync.Mutex
+	head     uint64
+	receipts map[common.Hash]*types.Receipt
+	sent     []*types.Transaction
+	callErr  error
+}
+
+func newFakeChain() *fakeChain {
+	return &fakeChain{head: 100, receipts: make(map[common.Hash]*types.Receipt)}
+}
+
+func (f *fakeChain) BlockNumber(ctx context.Context) (uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.head, nil
+}
+
+func (f *fakeChain) TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if receipt, ok := f.receipts[hash]; ok {
+		return receipt, nil
+	}
+	return nil, ethereum.NotFound
+}
+
+func (f *fakeChain) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = append(f.sent, tx)
+	return nil
+}
+
+func (f *fakeChain) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(1), nil
+}
+
+func (f *fakeChain) CallContract(ctx context.Context, call ethereum.CallMsg, block *big.Int) ([]byte, error) {
+	return nil, f.callErr
+}
+
+func (f *fakeChain) mine(tx *types.Transaction, status uint64, blockHash common.Hash) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.head++
+	f.receipts[tx.Hash()] = &types.Receipt{
+		Status:      status,
+		BlockNumber: new(big.Int).SetUint64(f.head),
+		BlockHash:   blockHash,
+		GasUsed:     50_000,
+	}
+}
+
+type trackerHarness struct {
+	t       *testing.T
+	relayer *Relayer
+	chain   *fakeChain
+	tracked *trackedTx
+	events  chan models.Issuance
+	cfg     config.TxTrackerConfig
+}
+
+func newTrackerHarness(t *testing.T) *trackerHarness {
+	t.Helper()
+	logging.Logger = zap.NewNop()
+
+	key, _ := crypto.GenerateKey()
+	auth, err := bind.NewKeyedTransactorWithChainID(key, big.NewInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	to := common.HexToAddress("0x1")
+	tx, err := auth.Signer(auth.From, types.NewTx(&types.LegacyTx{
+		Nonce: 7, GasPrice: big.NewInt(1_000_000_000), Gas: 100_000, To: &to, Data: []byte{1},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{TxTracker: config.TxTrackerConfig{
+		StuckTimeoutSeconds: 60, FeeBumpPercent: 20, MaxReplacements: 2, Confirmations: 2, FinalityBlocks: 4,
+	}}
+	r := New(cfg, nil, nil)
+	events := make(chan models.Issuance, 32)
+	r.StreamTransitions(events)
+
+	chain := newFakeChain()
+	return &trackerHarness{
+		t:       t,
+		relayer: r,
+		chain:   chain,
+		events:  events,
+		cfg:     r.trackerConfig(),
+		tracked: &trackedTx{
+			ctrct:     config.ContractConfig{ChainID: "1", Address: "0xfeed"},
+			client:    chain,
+			from:      auth.From,
+			sign:      auth.Signer,
+			issuances: []*models.Issuance{{ID: "issuance"}},
+			attempts:  []*types.Transaction{tx},
+			sentAt:    time.Now(),
+		},
+	}
+}
+
+func (h *trackerHarness) poll() bool {
+	return h.relayer.poll(context.Background(), h.tracked, h.cfg)
+}
+
+// states drains the transitions streamed so far
+func (h *trackerHarness) states() []models.Issuance {
+	var out []models.Issuance
+	for {
+		select {
+		case update := <-h.events:
+			out = append(out, update)
+		default:
+			return out
+		}
+	}
+}
+
+func TestReplacesStuckTxWithSameNonceAndHigherFee(t *testing.T) {
+	h := newTrackerHarness(t)
+	if h.poll() {
+		t.Fatal("done before anything was mined")
+	}
+	if len(h.chain.sent) != 0 {
+		t.Fatal("replaced a tx before it was stuck")
+	}
+
+	h.tracked.sentAt = time.Now().Add(-2 * time.Minute)
+	h.poll()
+	if len(h.chain.sent) != 1 {
+		t.Fatalf("sent %d replacements, want 1", len(h.chain.sent))
+	}
+	original, replacement := h.tracked.attempts[0], h.chain.sent[0]
+	if replacement.Nonce() != original.Nonce() {
+		t.Fatalf("replacement nonce %d, want %d", replacement.Nonce(), original.Nonce())
+	}
+	if want := big.NewInt(1_200_000_000); replacement.GasPrice().Cmp(want) != 0 {
+		t.Fatalf("replacement gas price %v, want %v", replacement.GasPrice(), want)
+	}
+
+	// the original still gets mined, the batch confirms on it
+	h.chain.mine(original, types.ReceiptStatusSuccessful, common.HexToHash("0xa"))
+	h.poll()
+	if len(h.relayer.published) != 0 {
+		t.Fatal("a batch short of its confirmations counts as published")
+	}
+	h.chain.head++
+	h.poll()
+	states := h.states()
+	last := states[len(states)-1]
+	if last.State != models.Confirmed || last.Contracts[0].TxHash != original.Hash().Hex() {
+		t.Fatalf("last transition %v on %s, want confirmed on the original", last.State, last.Contracts[0].TxHash)
+	}
+	if len(h.relayer.published) != 1 {
+		t.Fatal("the confirmed batch does not count as published")
+	}
+}
+
+func TestGivesUpAfterMaxReplacements(t *testing.T) {
+	h := newTrackerHarness(t)
+	h.tracked.budget = newSpendWindow("1", nil, nil)
+	spendID, err := h.tracked.budget.Reserve(context.Background(), txMaxCost(h.tracked.attempts[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.tracked.spendID = spendID
+	for i := 0; i < 3; i++ {
+		h.tracked.sentAt = time.Now().Add(-2 * time.Minute)
+		if h.poll() {
+			break
+		}
+	}
+	states := h.states()
+	last := states[len(states)-1]
+	if last.State != models.Failed || !strings.Contains(last.Contracts[0].Error, "2 replacements") {
+		t.Fatalf("last transition %v %q, want failed after 2 replacements", last.State, last.Contracts[0].Error)
+	}
+	// any of the attempts can still be mined
+	if _, ok := h.tracked.budget.entries[spendID]; !ok {
+		t.Fatal("the given up tx released its spend")
+	}
+}
+
+func TestReorgPutsIssuanceBackInFlight(t *testing.T) {
+	h := newTrackerHarness(t)
+	tx := h.tracked.attempts[0]
+	h.chain.mine(tx, types.ReceiptStatusSuccessful, common.HexToHash("0xa"))
+	h.chain.head++
+	h.poll()
+	if !h.tracked.confirmed {
+		t.Fatal("not confirmed after 2 blocks")
+	}
+
+	// reorged out, the node no longer knows the receipt
+	h.chain.mu.Lock()
+	delete(h.chain.receipts, tx.Hash())
+	h.chain.mu.Unlock()
+	h.states()
+	if h.poll() {
+		t.Fatal("done although the tx was reorged out")
+	}
+	states := h.states()
+	if len(states) == 0 || states[0].State != models.Submitted {
+		t.Fatalf("transitions after reorg %v, want back to submitted", states)
+	}
+	if len(h.chain.sent) != 1 || h.chain.sent[0].Hash() != tx.Hash() {
+		t.Fatal("reorged tx was not resubmitted")
+	}
+
+	// included again and final
+	h.chain.mine(tx, types.ReceiptStatusSuccessful, common.HexToHash("0xb"))
+	h.chain.head += 4
+	if !h.poll() {
+		t.Fatal("not final after finality blocks")
+	}
+}
+
+func TestRevertIsDecoded(t *testing.T) {
+	h := newTrackerHarness(t)
+	h.chain.callErr = errors.New("execution reverted: stale price")
+	h.chain.mine(h.tracked.attempts[0], types.ReceiptStatusFailed, common.HexToHash("0xa"))
+	if !h.poll() {
+		t.Fatal("reverted batch not done")
+	}
+	states := h.states()
+	last := states[len(states)-1]
+	if last.State != models.Failed || !strings.Contains(last.Contracts[0].Error, "stale price") {
+		t.Fatalf("last transition %v %q, want failed with the revert reason", last.State, last.Contracts[0].Error)
+	}
+}
+
+func TestTrackerSettlesTheOutboxEntriesOfItsBatch(t *testing.T) {
+	queue := func(h *trackerHarness) *memoryOutbox {
+		outbox := &memoryOutbox{}
+		h.relayer.outbox = outbox
+		entry := models.RelayOutboxEntry{ChainID: "1", Contract: "0xfeed", Issuance: *h.tracked.issuances[0]}
+		if err := outbox.EnqueueRelays(context.Background(), []models.RelayOutboxEntry{entry}); err != nil {
+			t.Fatal(err)
+		}
+		h.tracked.relays, _ = outbox.ClaimRelays(context.Background(), "1", "0xfeed", 20, time.Minute)
+		return outbox
+	}
+
+	// in flight the entry stays claimed, done once the batch is confirmed
+	h := newTrackerHarness(t)
+	outbox := queue(h)
+	h.relayer.holdRelays(context.Background(), h.tracked)
+	h.chain.mine(h.tracked.attempts[0], types.ReceiptStatusSuccessful, common.HexToHash("0xa"))
+	h.poll()
+	if outbox.pending() != 1 || len(outbox.extended) != 1 {
+		t.Fatalf("mined batch left %d pending, extended %v", outbox.pending(), outbox.extended)
+	}
+	h.chain.head++
+	h.poll()
+	if outbox.pending() != 0 {
+		t.Fatal("the confirmed batch stayed in the outbox")
+	}
+
+	// a reverted batch goes back to the outbox
+	h = newTrackerHarness(t)
+	outbox = queue(h)
+	h.chain.mine(h.tracked.attempts[0], types.ReceiptStatusFailed, common.HexToHash("0xa"))
+	if !h.poll() {
+		t.Fatal("reverted batch not done")
+	}
+	if outbox.pending() != 1 || len(outbox.retried) != 1 {
+		t.Fatalf("reverted batch left %d pending, retried %v", outbox.pending(), outbox.retried)
+	}
+}
@@ -0,0 +1,284 @@
+package reputation
+
+import (
+	"context"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"oracle_engine/internal/config"
+	"oracle_engine/internal/database/timescale"
+	"oracle_engine/internal/logging"
+	"oracle_engine/internal/models"
+	"oracle_engine/internal/registry"
+	"oracle_engine/internal/utils"
+
+	"go.uber.org/zap"
+)
+
+/*
+Reputation:
+Tracks how every source behaves per asset, relative to the aggregate
+it ends up in. Deviation, uptime and staleness are kept as time decayed
+averages so old behaviour fades out, and folded into one score that
+the aggregator uses as the source weight.
+*/
+
+type Tracker struct {
+	cfg      config.ReputationConfig
+	db       *timescale.TimescaleDB
+	mu       sync.RWMutex
+	scores   map[string]*models.SourceReputation // keyed by asset|source
+	dirty    map[string]struct{}
+	expected map[string][]string // asset id -> configured feed names
+}
+
+func New(cfg *config.Config, db *timescale.TimescaleDB) *Tracker {
+	return &Tracker{
+		cfg:      withDefaults(cfg.Reputation),
+		db:       db,
+		scores:   make(map[string]*models.SourceReputation),
+		dirty:    make(map[string]struct{}),
+		expected: expectedFeeds(cfg.Assets),
+	}
+}
+
+// UseRegistry takes the feeds every asset expects from the registry,
+// again on every reload of it
+func (t *Tracker) UseRegistry(reg *registry.Registry) {
+	t.setExpected(reg.Assets())
+	reg.Subscribe(func(registry.Change) {
+		t.setExpected(reg.Assets())
+	})
+}
+
+func (t *Tracker) setExpected(assets []config.AssetConfig) {
+	expected := expectedFeeds(assets)
+	t.mu.Lock()
+	t.expected = expected
+	t.mu.Unlock()
+}
+
+// expectedFeeds maps every asset id to the names of its configured feeds
+func expectedFeeds(assets []config.AssetConfig) map[string][]string {
+	expected := make(map[string][]string)
+	for _, asset := range assets {
+		assetID := utils.GenerateIDForAsset(asset.InternalAssetIdentity)
+		for _, feed := range asset.Feeds {
+			expected[assetID] = append(expected[assetID], strings.ToLower(feed.Name))
+		}
+	}
+	return expected
+}
+
+func withDefaults(cfg config.ReputationConfig) config.ReputationConfig {
+	if cfg.HalfLifeSeconds <= 0 {
+		cfg.HalfLifeSeconds = 3600
+	}
+	if cfg.DeviationScale <= 0 {
+		cfg.DeviationScale = 0.01
+	}
+	if cfg.StalenessScaleSeconds <= 0 {
+		cfg.StalenessScaleSeconds = 300
+	}
+	if cfg.MinWeight <= 0 {
+		cfg.MinWeight = 0.05
+	}
+	if cfg.PersistIntervalSeconds <= 0 {
+		cfg.PersistIntervalSeconds = 30
+	}
+	return cfg
+}
+
+// Start loads the persisted scores and flushes changes periodically
+func (t *Tracker) Start(ctx context.Context) {
+	if err := t.load(ctx); err != nil {
+		logging.Logger.Error("Failed to load source reputations", zap.Error(err))
+	}
+
+	ticker := time.NewTicker(time.Duration(t.cfg.PersistIntervalSeconds) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			t.persist(context.Background())
+			return
+		case <-ticker.C:
+			t.persist(ctx)
+		}
+	}
+}
+
+// Weight returns the aggregation weight of a source, neutral when unknown
+func (t *Tracker) Weight(assetID, source string) float64 {
+	if !t.cfg.Enabled {
+		return 1
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	rep, ok := t.scores[key(assetID, source)]
+	if !ok {
+		return 1
+	}
+	return math.Max(rep.Score, t.cfg.MinWeight)
+}
+
+// Observe folds one aggregation window into the scores of its sources.
+// The whole window is passed, including prices that got excluded,
+// so a drifting source is penalised for the drift that excluded it.
+func (t *Tracker) Observe(assetID string, aggregate float64, at time.Time, window []models.UnifiedPrice) {
+	if aggregate == 0 {
+		return
+	}
+
+	type sample struct {
+		sum   float64
+		age   float64
+		count int
+	}
+	bySource := make(map[string]*sample)
+	for _, p := range window {
+		if p.ID == "" || p.Source == "" {
+			continue
+		}
+		source := strings.ToLower(p.Source)
+		s, ok := bySource[source]
+		if !ok {
+			s = &sample{}
+			bySource[source] = s
+		}
+		s.sum += p.Value
+		s.age += math.Max(at.Sub(p.Timestamp).Seconds(), 0)
+		s.count++
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for source, s := range bySource {
+		mean := s.sum / float64(s.count)
+		deviation := math.Abs(mean-aggregate) / math.Abs(aggregate)
+		t.update(assetID, source, at, 1, deviation, s.age/float64(s.count))
+	}
+
+	// configured sources that did not show up lose uptime only
+	for _, source := range t.expected[assetID] {
+		if _, ok := bySource[source]; ok {
+			continue
+		}
+		t.update(assetID, source, at, 0, -1, -1)
+	}
+}
+
+// update applies one observation, negative deviation/staleness means not observed
+func (t *Tracker) update(assetID, source string, at time.Time, present, deviation, staleness float64) {
+	k := key(assetID, source)
+	rep, ok := t.scores[k]
+	if !ok {
+		rep = &models.SourceReputation{
+			AssetID:   assetID,
+			Source:    source,
+			Uptime:    present,
+			Deviation: math.Max(deviation, 0),
+			Staleness: math.Max(staleness, 0),
+			UpdatedAt: at,
+		}
+		t.scores[k] = rep
+	} else {
+		alpha := t.alpha(at.Sub(rep.UpdatedAt))
+		rep.Uptime += alpha * (present - rep.Uptime)
+		if deviation >= 0 {
+			rep.Deviation += alpha * (deviation - rep.Deviation)
+		}
+		if staleness >= 0 {
+			rep.Staleness += alpha * (staleness - rep.Staleness)
+		}
+		rep.UpdatedAt = at
+	}
+	if present > 0 {
+		rep.Samples++
+	}
+	rep.Score = t.score(rep)
+	t.dirty[k] = struct{}{}
+}
+
+// alpha is the smoothing factor for an observation that arrives
+// elapsed after the previous one, with the configured half life
+func (t *Tracker) alpha(elapsed time.Duration) float64 {
+	halfLife := time.Duration(t.cfg.HalfLifeSeconds) * time.Second
+	if elapsed <= 0 {
+		elapsed = time.Second
+	}
+	a := 1 - math.Pow(0.5, elapsed.Seconds()/halfLife.Seconds())
+	return math.Min(math.Max(a, 0.01), 1)
+}
+
+func (t *Tracker) score(rep *models.SourceReputation) float64 {
+	deviationFactor := 1 / (1 + rep.Deviation/t.cfg.DeviationScale)
+	stalenessFactor := 1 / (1 + rep.Staleness/float64(t.cfg.StalenessScaleSeconds))
+	return math.Min(math.Max(rep.Uptime*deviationFactor*stalenessFactor, 0), 1)
+}
+
+// Snapshot returns the current in-memory scores
+func (t *Tracker) Snapshot() []models.SourceReputation {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	out := make([]models.SourceReputation, 0, len(t.scores))
+	for _, rep := range t.scores {
+		out = append(out, *rep)
+	}
+	return out
+}
+
+func (t *Tracker) load(ctx context.Context) error {
+	if t.db == nil {
+		return nil
+	}
+	reps, err := t.db.GetSourceReputations(ctx, "")
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i := range reps {
+		rep := reps[i]
+		t.scores[key(rep.AssetID, rep.Source)] = &rep
+	}
+	logging.Logger.Info("Loaded source reputations", zap.Int("count", len(reps)))
+	return nil
+}
+
+func (t *Tracker) persist(ctx context.Context) {
+	if t.db == nil {
+		return
+	}
+	t.mu.Lock()
+	changed := make([]models.SourceReputation, 0, len(t.dirty))
+	for k := range t.dirty {
+		changed = append(changed, *t.scores[k])
+	}
+	t.dirty = make(map[string]struct{})
+	t.mu.Unlock()
+
+	if len(changed) == 0 {
+		return
+	}
+	if err := t.db.UpsertSourceReputations(ctx, changed); err != nil {
+		logging.Logger.Error("Failed to persist source reputations", zap.Error(err))
+		// keep them dirty for the next round
+		t.mu.Lock()
+		for _, rep := range changed {
+			t.dirty[key(rep.AssetID, rep.Source)] = struct{}{}
+		}
+		t.mu.Unlock()
+	}
+}
+
+func key(assetID, source string) string {
+	return assetID + "|" + strings.ToLower(source)
+}
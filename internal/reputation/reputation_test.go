@@ -0,0 +1,117 @@
+package reputation
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"oracle_engine/internal/config"
+	"oracle_engine/internal/logging"
+	"oracle_engine/internal/models"
+	"oracle_engine/internal/utils"
+
+	"go.uber.org/zap"
+)
+
+func newTestTracker() (*Tracker, string) {
+	logging.Logger = zap.NewNop()
+	cfg := &config.Config{
+		Reputation: config.ReputationConfig{Enabled: true, HalfLifeSeconds: 60, DeviationScale: 0.01, MinWeight: 0.05},
+		Assets: []config.AssetConfig{{
+			Name:                  "USDT/USD",
+			InternalAssetIdentity: "0xUSDT",
+			Feeds:                 []config.FeedConfig{{Name: "pyth"}, {Name: "fixer"}, {Name: "moralis"}},
+		}},
+	}
+	return New(cfg, nil), utils.GenerateIDForAsset("0xUSDT")
+}
+
+func window(at time.Time, prices map[string]float64) []models.UnifiedPrice {
+	out := make([]models.UnifiedPrice, 0, len(prices))
+	for source, value := range prices {
+		out = append(out, models.UnifiedPrice{ID: source + at.String(), Source: source, Value: value, Timestamp: at})
+	}
+	return out
+}
+
+func TestObserveScoresSourcesAgainstTheAggregate(t *testing.T) {
+	tracker, assetID := newTestTracker()
+	at := time.Unix(1_750_000_000, 0)
+
+	// pyth sits on the aggregate, fixer is 5% off, moralis never shows up
+	tracker.Observe(assetID, 1, at, window(at, map[string]float64{"pyth": 1, "fixer": 1.05}))
+
+	if w := tracker.Weight(assetID, "pyth"); w != 1 {
+		t.Fatalf("accurate source weighs %v, want 1", w)
+	}
+	// 5% off at a 1% scale divides the score by six
+	if w := tracker.Weight(assetID, "fixer"); math.Abs(w-1.0/6) > 1e-9 {
+		t.Fatalf("drifting source weighs %v, want 1/6", w)
+	}
+	// absent sources drop to the floor but stay observable
+	if w := tracker.Weight(assetID, "moralis"); w != 0.05 {
+		t.Fatalf("absent source weighs %v, want the min weight", w)
+	}
+	if w := tracker.Weight(assetID, "unknown"); w != 1 {
+		t.Fatalf("unknown source weighs %v, want neutral", w)
+	}
+
+	tracker.cfg.Enabled = false
+	if w := tracker.Weight(assetID, "fixer"); w != 1 {
+		t.Fatalf("disabled reputation weighs %v, want neutral", w)
+	}
+}
+
+func TestScoresDecayTowardsRecentBehaviour(t *testing.T) {
+	tracker, assetID := newTestTracker()
+	at := time.Unix(1_750_000_000, 0)
+	tracker.Observe(assetID, 1, at, window(at, map[string]float64{"fixer": 1.04}))
+
+	// one half life later an accurate window halves the deviation
+	later := at.Add(time.Minute)
+	tracker.Observe(assetID, 1, later, window(later, map[string]float64{"fixer": 1}))
+	rep := tracker.scores[key(assetID, "fixer")]
+	if math.Abs(rep.Deviation-0.02) > 1e-9 || rep.Samples != 2 {
+		t.Fatalf("deviation %v after %d samples, want 0.02 after 2", rep.Deviation, rep.Samples)
+	}
+
+	// a window right after moves it far less than one a long time after
+	soon := later.Add(time.Second)
+	tracker.Observe(assetID, 1, soon, window(soon, map[string]float64{"fixer": 1}))
+	if rep.Deviation < 0.019 {
+		t.Fatalf("a window a second later moved the deviation to %v", rep.Deviation)
+	}
+	muchLater := soon.Add(10 * time.Minute)
+	tracker.Observe(assetID, 1, muchLater, window(muchLater, map[string]float64{"fixer": 1}))
+	if rep.Deviation > 0.0001 {
+		t.Fatalf("ten half lives later the deviation is still %v", rep.Deviation)
+	}
+	if w := tracker.Weight(assetID, "fixer"); w < 0.99 {
+		t.Fatalf("recovered source weighs %v", w)
+	}
+
+	// uptime decays the same way while the source stays away
+	tracker.Observe(assetID, 1, muchLater.Add(time.Minute), nil)
+	if up := rep.Uptime; math.Abs(up-0.5) > 1e-9 {
+		t.Fatalf("uptime %v one half life into an outage, want 0.5", up)
+	}
+}
+
+func TestReloadedFeedsAreExpected(t *testing.T) {
+	tracker, assetID := newTestTracker()
+	tracker.setExpected([]config.AssetConfig{{
+		Name:                  "USDT/USD",
+		InternalAssetIdentity: "0xUSDT",
+		Feeds:                 []config.FeedConfig{{Name: "pyth"}, {Name: "Chainlink"}},
+	}})
+	at := time.Unix(1_750_000_000, 0)
+	tracker.Observe(assetID, 1, at, window(at, map[string]float64{"pyth": 1}))
+
+	// the feed added by the reload is missed, the dropped ones are not
+	if w := tracker.Weight(assetID, "chainlink"); w != 0.05 {
+		t.Fatalf("added feed weighs %v, want the min weight", w)
+	}
+	if _, ok := tracker.scores[key(assetID, "moralis")]; ok {
+		t.Fatal("still scored a feed the reload dropped")
+	}
+}
@@ -0,0 +1,191 @@
+package rpcpool
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// Chain is used wherever an ethclient would be
+var _ bind.ContractBackend = (*Chain)(nil)
+
+func (c *Chain) ChainID() string {
+	return c.id
+}
+
+func (c *Chain) BlockNumber(ctx context.Context) (uint64, error) {
+	var head uint64
+	e, err := c.do(ctx, "eth_blockNumber", func(ctx context.Context, client *ethclient.Client) (err error) {
+		head, err = client.BlockNumber(ctx)
+		return err
+	})
+	if err == nil {
+		e.observeHead(head)
+	}
+	return head, err
+}
+
+func (c *Chain) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	var header *types.Header
+	_, err := c.do(ctx, "eth_getBlockByNumber", func(ctx context.Context, client *ethclient.Client) (err error) {
+		header, err = client.HeaderByNumber(ctx, number)
+		return err
+	})
+	return header, err
+}
+
+func (c *Chain) TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	var receipt *types.Receipt
+	_, err := c.do(ctx, "eth_getTransactionReceipt", func(ctx context.Context, client *ethclient.Client) (err error) {
+		receipt, err = client.TransactionReceipt(ctx, hash)
+		return err
+	})
+	return receipt, err
+}
+
+// L1Fee is the l1 data fee an OP stack chain charged for a tx, read from
+// the raw receipt as go-ethereum's receipt drops it. It is nil on chains
+// without one.
+func (c *Chain) L1Fee(ctx context.Context, hash common.Hash) (*big.Int, error) {
+	var receipt struct {
+		L1Fee *hexutil.Big `json:"l1Fee"`
+	}
+	_, err := c.do(ctx, "eth_getTransactionReceipt", func(ctx context.Context, client *ethclient.Client) error {
+		return client.Client().CallContext(ctx, &receipt, "eth_getTransactionReceipt", hash)
+	})
+	if err != nil || receipt.L1Fee == nil {
+		return nil, err
+	}
+	return receipt.L1Fee.ToInt(), nil
+}
+
+// SendTransaction broadcasts tx, an endpoint that already knows it after
+// a failover counts as sent
+func (c *Chain) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	failedOver := false
+	_, err := c.do(ctx, "eth_sendRawTransaction", func(ctx context.Context, client *ethclient.Client) error {
+		err := client.SendTransaction(ctx, tx)
+		if err != nil && failedOver && isKnownTx(err) {
+			return nil
+		}
+		failedOver = true
+		return err
+	})
+	return err
+}
+
+func (c *Chain) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	var out []byte
+	_, err := c.do(ctx, "eth_call", func(ctx context.Context, client *ethclient.Client) (err error) {
+		out, err = client.CallContract(ctx, msg, blockNumber)
+		return err
+	})
+	return out, err
+}
+
+func (c *Chain) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error) {
+	var code []byte
+	_, err := c.do(ctx, "eth_getCode", func(ctx context.Context, client *ethclient.Client) (err error) {
+		code, err = client.CodeAt(ctx, account, blockNumber)
+		return err
+	})
+	return code, err
+}
+
+func (c *Chain) PendingCodeAt(ctx context.Context, account common.Address) ([]byte, error) {
+	var code []byte
+	_, err := c.do(ctx, "eth_getCode", func(ctx context.Context, client *ethclient.Client) (err error) {
+		code, err = client.PendingCodeAt(ctx, account)
+		return err
+	})
+	return code, err
+}
+
+func (c *Chain) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	var nonce uint64
+	_, err := c.do(ctx, "eth_getTransactionCount", func(ctx context.Context, client *ethclient.Client) (err error) {
+		nonce, err = client.PendingNonceAt(ctx, account)
+		return err
+	})
+	return nonce, err
+}
+
+func (c *Chain) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+	var nonce uint64
+	_, err := c.do(ctx, "eth_getTransactionCount", func(ctx context.Context, client *ethclient.Client) (err error) {
+		nonce, err = client.NonceAt(ctx, account, blockNumber)
+		return err
+	})
+	return nonce, err
+}
+
+func (c *Chain) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	var balance *big.Int
+	_, err := c.do(ctx, "eth_getBalance", func(ctx context.Context, client *ethclient.Client) (err error) {
+		balance, err = client.BalanceAt(ctx, account, blockNumber)
+		return err
+	})
+	return balance, err
+}
+
+func (c *Chain) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	var price *big.Int
+	_, err := c.do(ctx, "eth_gasPrice", func(ctx context.Context, client *ethclient.Client) (err error) {
+		price, err = client.SuggestGasPrice(ctx)
+		return err
+	})
+	return price, err
+}
+
+func (c *Chain) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	var tip *big.Int
+	_, err := c.do(ctx, "eth_maxPriorityFeePerGas", func(ctx context.Context, client *ethclient.Client) (err error) {
+		tip, err = client.SuggestGasTipCap(ctx)
+		return err
+	})
+	return tip, err
+}
+
+func (c *Chain) FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*ethereum.FeeHistory, error) {
+	var history *ethereum.FeeHistory
+	_, err := c.do(ctx, "eth_feeHistory", func(ctx context.Context, client *ethclient.Client) (err error) {
+		history, err = client.FeeHistory(ctx, blockCount, lastBlock, rewardPercentiles)
+		return err
+	})
+	return history, err
+}
+
+func (c *Chain) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error) {
+	var gas uint64
+	_, err := c.do(ctx, "eth_estimateGas", func(ctx context.Context, client *ethclient.Client) (err error) {
+		gas, err = client.EstimateGas(ctx, msg)
+		return err
+	})
+	return gas, err
+}
+
+func (c *Chain) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	var logs []types.Log
+	_, err := c.do(ctx, "eth_getLogs", func(ctx context.Context, client *ethclient.Client) (err error) {
+		logs, err = client.FilterLogs(ctx, query)
+		return err
+	})
+	return logs, err
+}
+
+// SubscribeFilterLogs subscribes on the best endpoint, a subscription
+// that drops does not move to another one
+func (c *Chain) SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	var sub ethereum.Subscription
+	_, err := c.do(ctx, "eth_subscribe", func(_ context.Context, client *ethclient.Client) (err error) {
+		sub, err = client.SubscribeFilterLogs(ctx, query, ch)
+		return err
+	})
+	return sub, err
+}
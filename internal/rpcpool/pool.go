@@ -0,0 +1,409 @@
+package rpcpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"oracle_engine/internal/config"
+	"oracle_engine/internal/logging"
+	"oracle_engine/internal/models"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"go.uber.org/zap"
+)
+
+/*
+RPC pool:
+One long lived client per rpc endpoint, grouped by chain. Requests go
+to the best endpoint first, ranked by whether it failed recently,
+whether its head is close to the best head seen on the chain, and its
+latency. A request that fails because of the endpoint, not because of
+what was asked, is retried on the next one, so publishing continues as
+long as any endpoint of the chain is up. A health check refreshes head
+and latency of every endpoint in the background.
+*/
+
+var ErrNoEndpoints = errors.New("no rpc endpoint configured")
+
+// latency samples are smoothed with this weight for the newest one
+const latencyAlpha = 0.3
+
+type Pool struct {
+	cfg    config.RPCPoolConfig
+	chains map[string]*Chain
+}
+
+// New groups the rpc endpoints of the contracts by chain
+func New(cfg config.RPCPoolConfig, contracts []config.ContractConfig) *Pool {
+	if cfg.HealthIntervalSeconds <= 0 {
+		cfg.HealthIntervalSeconds = 15
+	}
+	if cfg.RequestTimeoutSeconds <= 0 {
+		cfg.RequestTimeoutSeconds = 10
+	}
+	if cfg.CooldownSeconds <= 0 {
+		cfg.CooldownSeconds = 30
+	}
+
+	p := &Pool{cfg: cfg, chains: make(map[string]*Chain)}
+	for _, ctrct := range contracts {
+		chain, ok := p.chains[ctrct.ChainID]
+		if !ok {
+			chain = &Chain{id: ctrct.ChainID, cfg: cfg, now: time.Now}
+			p.chains[ctrct.ChainID] = chain
+		}
+		for _, rawURL := range ctrct.Endpoints() {
+			chain.add(rawURL)
+		}
+	}
+	return p
+}
+
+// Chain is the client of a chain, failing over between its endpoints
+func (p *Pool) Chain(chainID string) (*Chain, error) {
+	chain, ok := p.chains[chainID]
+	if !ok || len(chain.endpoints) == 0 {
+		return nil, fmt.Errorf("%w for chain %s", ErrNoEndpoints, chainID)
+	}
+	return chain, nil
+}
+
+// Start checks the endpoints of every chain until ctx is done
+func (p *Pool) Start(ctx context.Context) {
+	ticker := time.NewTicker(time.Duration(p.cfg.HealthIntervalSeconds) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		for _, chain := range p.chains {
+			chain.checkHealth(ctx)
+		}
+		select {
+		case <-ctx.Done():
+			p.Close()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Statuses lists every endpoint by chain and rank
+func (p *Pool) Statuses() []models.RPCEndpointStatus {
+	chainIDs := make([]string, 0, len(p.chains))
+	for id := range p.chains {
+		chainIDs = append(chainIDs, id)
+	}
+	sort.Strings(chainIDs)
+
+	statuses := make([]models.RPCEndpointStatus, 0)
+	for _, id := range chainIDs {
+		statuses = append(statuses, p.chains[id].statuses()...)
+	}
+	return statuses
+}
+
+func (p *Pool) Close() {
+	for _, chain := range p.chains {
+		for _, e := range chain.endpoints {
+			e.close()
+		}
+	}
+}
+
+type Chain struct {
+	id        string
+	cfg       config.RPCPoolConfig
+	endpoints []*endpoint
+	now       func() time.Time
+}
+
+func (c *Chain) add(rawURL string) {
+	for _, e := range c.endpoints {
+		if e.url == rawURL {
+			return
+		}
+	}
+	c.endpoints = append(c.endpoints, &endpoint{url: rawURL, host: redact(rawURL)})
+}
+
+// ranked orders the endpoints best first, ones that failed recently or
+// lag behind stay in the list as a last resort
+func (c *Chain) ranked() []*endpoint {
+	now := c.now()
+	best := c.bestHead()
+
+	type candidate struct {
+		e       *endpoint
+		cooling bool
+		stale   bool
+		latency float64
+		index   int
+	}
+	candidates := make([]candidate, len(c.endpoints))
+	for i, e := range c.endpoints {
+		e.mu.Lock()
+		candidates[i] = candidate{
+			e:       e,
+			cooling: now.Before(e.cooldownUntil),
+			stale:   best > 0 && e.head+c.cfg.MaxBlockLag < best,
+			latency: e.latency,
+			index:   i,
+		}
+		e.mu.Unlock()
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.cooling != b.cooling {
+			return !a.cooling
+		}
+		if a.stale != b.stale {
+			return !a.stale
+		}
+		return a.latency < b.latency
+	})
+
+	ranked := make([]*endpoint, len(candidates))
+	for i, cand := range candidates {
+		ranked[i] = cand.e
+	}
+	return ranked
+}
+
+func (c *Chain) bestHead() uint64 {
+	var best uint64
+	for _, e := range c.endpoints {
+		e.mu.Lock()
+		if e.head > best {
+			best = e.head
+		}
+		e.mu.Unlock()
+	}
+	return best
+}
+
+// do runs fn on the best endpoint and fails over to the next one while
+// the error is the endpoint's fault
+func (c *Chain) do(ctx context.Context, method string, fn func(ctx context.Context, client *ethclient.Client) error) (*endpoint, error) {
+	timeout := time.Duration(c.cfg.RequestTimeoutSeconds) * time.Second
+	var lastErr error
+	for _, e := range c.ranked() {
+		client, err := e.dial(ctx)
+		if err != nil {
+			e.fail(c.now(), err, c.cooldown())
+			lastErr = err
+			continue
+		}
+
+		reqCtx, cancel := context.WithTimeout(ctx, timeout)
+		start := time.Now()
+		err = fn(reqCtx, client)
+		elapsed := time.Since(start)
+		cancel()
+
+		if err == nil || !endpointFault(err) {
+			e.succeed(elapsed)
+			return e, err
+		}
+		if ctx.Err() != nil {
+			return e, err
+		}
+		e.fail(c.now(), err, c.cooldown())
+		lastErr = err
+		logging.Logger.Warn("RPC endpoint failed, failing over",
+			zap.String("chainID", c.id),
+			zap.String("endpoint", e.host),
+			zap.String("method", method),
+			zap.Error(err),
+		)
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("%w for chain %s", ErrNoEndpoints, c.id)
+	}
+	return nil, fmt.Errorf("all rpc endpoints of chain %s failed: %w", c.id, lastErr)
+}
+
+func (c *Chain) cooldown() time.Duration {
+	return time.Duration(c.cfg.CooldownSeconds) * time.Second
+}
+
+// checkHealth refreshes head and latency of every endpoint, a successful
+// check ends the cooldown of an endpoint that failed before
+func (c *Chain) checkHealth(ctx context.Context) {
+	timeout := time.Duration(c.cfg.RequestTimeoutSeconds) * time.Second
+	var wg sync.WaitGroup
+	for _, e := range c.endpoints {
+		wg.Add(1)
+		go func(e *endpoint) {
+			defer wg.Done()
+			client, err := e.dial(ctx)
+			if err != nil {
+				e.fail(c.now(), err, c.cooldown())
+				return
+			}
+			reqCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			start := time.Now()
+			head, err := client.BlockNumber(reqCtx)
+			if err != nil {
+				if ctx.Err() == nil {
+					e.fail(c.now(), err, c.cooldown())
+				}
+				return
+			}
+			e.succeed(time.Since(start))
+			e.observeHead(head)
+			e.mu.Lock()
+			e.cooldownUntil = time.Time{}
+			e.mu.Unlock()
+		}(e)
+	}
+	wg.Wait()
+}
+
+func (c *Chain) statuses() []models.RPCEndpointStatus {
+	now := c.now()
+	best := c.bestHead()
+	statuses := make([]models.RPCEndpointStatus, 0, len(c.endpoints))
+	for rank, e := range c.ranked() {
+		e.mu.Lock()
+		status := models.RPCEndpointStatus{
+			ChainID:             c.id,
+			Endpoint:            e.host,
+			Rank:                rank,
+			Healthy:             !now.Before(e.cooldownUntil) && (best == 0 || e.head+c.cfg.MaxBlockLag >= best),
+			Head:                e.head,
+			LatencyMs:           e.latency,
+			Requests:            e.requests,
+			Failures:            e.failures,
+			ConsecutiveFailures: e.consecutive,
+			LastError:           e.lastErr,
+		}
+		if best > e.head {
+			status.BlocksBehind = best - e.head
+		}
+		if !e.lastErrAt.IsZero() {
+			at := e.lastErrAt
+			status.LastErrorAt = &at
+		}
+		if now.Before(e.cooldownUntil) {
+			until := e.cooldownUntil
+			status.CooldownUntil = &until
+		}
+		e.mu.Unlock()
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+type endpoint struct {
+	url  string
+	host string // what gets logged and shown
+
+	mu            sync.Mutex
+	client        *ethclient.Client
+	head          uint64
+	latency       float64 // smoothed, in ms
+	requests      uint64
+	failures      uint64
+	consecutive   int
+	lastErr       string
+	lastErrAt     time.Time
+	cooldownUntil time.Time
+}
+
+func (e *endpoint) dial(ctx context.Context) (*ethclient.Client, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.client != nil {
+		return e.client, nil
+	}
+	client, err := ethclient.DialContext(ctx, e.url)
+	if err != nil {
+		return nil, err
+	}
+	e.client = client
+	return client, nil
+}
+
+func (e *endpoint) close() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.client != nil {
+		e.client.Close()
+		e.client = nil
+	}
+}
+
+func (e *endpoint) succeed(elapsed time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	ms := float64(elapsed) / float64(time.Millisecond)
+	if e.requests == 0 || e.latency == 0 {
+		e.latency = ms
+	} else {
+		e.latency += latencyAlpha * (ms - e.latency)
+	}
+	e.requests++
+	e.consecutive = 0
+}
+
+func (e *endpoint) fail(now time.Time, err error, cooldown time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.requests++
+	e.failures++
+	e.consecutive++
+	e.lastErr = err.Error()
+	e.lastErrAt = now
+	e.cooldownUntil = now.Add(cooldown)
+}
+
+func (e *endpoint) observeHead(head uint64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if head > e.head {
+		e.head = head
+	}
+}
+
+// endpointFault reports whether err came from the endpoint rather than
+// from the request, json-rpc errors like reverts are answers
+func endpointFault(err error) bool {
+	if errors.Is(err, ethereum.NotFound) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var httpErr rpc.HTTPError
+	if errors.As(err, &httpErr) {
+		return true
+	}
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		// limit exceeded, the endpoint is rate limiting us
+		return rpcErr.ErrorCode() == -32005
+	}
+	return true
+}
+
+// isKnownTx is the answer of an endpoint that already has the tx, after a
+// failover the first endpoint often got it through
+func isKnownTx(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "already known") || strings.Contains(msg, "known transaction")
+}
+
+// redact keeps scheme and host of an rpc url, paths and queries carry api keys
+func redact(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return "invalid url"
+	}
+	return u.Scheme + "://" + u.Host
+}
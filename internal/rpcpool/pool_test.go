@@ -0,0 +1,110 @@
+package rpcpool
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"oracle_engine/internal/config"
+	"oracle_engine/internal/logging"
+
+	"github.com/ethereum/go-ethereum"
+	"go.uber.org/zap"
+)
+
+// fakeNode answers eth_blockNumber with head and eth_call with a revert,
+// or fails every request with status when set
+type fakeNode struct {
+	head   string
+	status int
+	calls  atomic.Int64
+}
+
+func (f *fakeNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.calls.Add(1)
+	if f.status != 0 {
+		w.WriteHeader(f.status)
+		return
+	}
+	var req struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&req)
+	w.Header().Set("Content-Type", "application/json")
+	if req.Method == "eth_call" {
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":` + string(req.ID) + `,"error":{"code":3,"message":"execution reverted"}}`))
+		return
+	}
+	_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":` + string(req.ID) + `,"result":"` + f.head + `"}`))
+}
+
+func newChain(t *testing.T, nodes ...*fakeNode) *Chain {
+	t.Helper()
+	logging.Logger = zap.NewNop()
+	ctrct := config.ContractConfig{ChainID: "1"}
+	for _, node := range nodes {
+		srv := httptest.NewServer(node)
+		t.Cleanup(srv.Close)
+		ctrct.RPCs = append(ctrct.RPCs, srv.URL)
+	}
+	pool := New(config.RPCPoolConfig{MaxBlockLag: 5}, []config.ContractConfig{ctrct})
+	t.Cleanup(pool.Close)
+	chain, err := pool.Chain("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return chain
+}
+
+func TestFailsOverWhenAnEndpointIsDown(t *testing.T) {
+	down := &fakeNode{status: http.StatusBadGateway}
+	up := &fakeNode{head: "0x64"}
+	chain := newChain(t, down, up)
+
+	head, err := chain.BlockNumber(context.Background())
+	if err != nil {
+		t.Fatalf("request failed although one endpoint is up: %v", err)
+	}
+	if head != 100 {
+		t.Fatalf("got head %d, want 100", head)
+	}
+
+	// the failed endpoint cools down and is no longer asked first
+	chain.BlockNumber(context.Background())
+	if calls := down.calls.Load(); calls != 1 {
+		t.Fatalf("down endpoint asked %d times, want once", calls)
+	}
+}
+
+func TestRanksLaggingEndpointsLast(t *testing.T) {
+	behind := &fakeNode{head: "0x64"}
+	current := &fakeNode{head: "0xc8"}
+	chain := newChain(t, behind, current)
+
+	chain.checkHealth(context.Background())
+	if best := chain.ranked()[0]; best.head != 200 {
+		t.Fatalf("best endpoint is at head %d, want the one at 200", best.head)
+	}
+
+	statuses := chain.statuses()
+	if statuses[1].Healthy || statuses[1].BlocksBehind != 100 {
+		t.Fatalf("lagging endpoint reported as %+v", statuses[1])
+	}
+}
+
+func TestDoesNotFailOverOnRequestErrors(t *testing.T) {
+	first := &fakeNode{head: "0x64"}
+	second := &fakeNode{head: "0x64"}
+	chain := newChain(t, first, second)
+
+	if _, err := chain.CallContract(context.Background(), ethereum.CallMsg{}, nil); err == nil {
+		t.Fatal("revert was swallowed")
+	}
+	if calls := second.calls.Load(); calls != 0 {
+		t.Fatalf("a revert failed over to the next endpoint %d times", calls)
+	}
+}
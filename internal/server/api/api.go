@@ -1,11 +1,17 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"fmt"
 
+	"oracle_engine/internal/balances"
 	"oracle_engine/internal/config"
 	"oracle_engine/internal/logging"
 	"oracle_engine/internal/models"
+	"oracle_engine/internal/reconcile"
+	"oracle_engine/internal/registry"
+	"oracle_engine/internal/rpcpool"
 	"oracle_engine/internal/server/middleware"
 	"oracle_engine/internal/server/services"
 	"oracle_engine/internal/utils"
@@ -49,27 +55,43 @@ type API struct {
 	priceService     services.PriceService
 	issuanceService  services.IssuanceService
 	dashboardService services.DashboardService
+	sourceService    services.SourceService
+	haltService      services.HaltService
+	reviewService    services.ReviewService
+	costService      services.CostService
 	priceCh          chan models.Issuance
 	priceStreamer    *PriceStreamer
 	cfg              *config.Config
+	registry         *registry.Registry
+	rpcPool          *rpcpool.Pool
+	reconciler       *reconcile.Monitor
+	wallets          *balances.Monitor
 	authMiddleware   *middleware.AuthMiddleware
 }
 
-func NewAPI(priceService services.PriceService, issuanceService services.IssuanceService, dashboardService services.DashboardService, priceCh chan models.Issuance, cfg *config.Config) *API {
+func NewAPI(priceService services.PriceService, issuanceService services.IssuanceService, dashboardService services.DashboardService, sourceService services.SourceService, haltService services.HaltService, reviewService services.ReviewService, costService services.CostService, priceCh chan models.Issuance, cfg *config.Config, registry *registry.Registry, rpcPool *rpcpool.Pool, reconciler *reconcile.Monitor, wallets *balances.Monitor) *API {
 
 	priceStreamer := NewPriceStreamer(priceCh, logging.Logger)
 	priceStreamer.Start()
 
 	// Initialize auth middleware
-	authMiddleware := middleware.NewAuthMiddleware(cfg.JWTSecret, dashboardService)
+	authMiddleware := middleware.NewAuthMiddleware(cfg.JWTSecret, cfg.AdminTokens, dashboardService)
 
 	return &API{
 		priceService:     priceService,
 		issuanceService:  issuanceService,
 		dashboardService: dashboardService,
+		sourceService:    sourceService,
+		haltService:      haltService,
+		reviewService:    reviewService,
+		costService:      costService,
 		priceCh:          priceCh,
 		priceStreamer:    priceStreamer,
 		cfg:              cfg,
+		registry:         registry,
+		rpcPool:          rpcPool,
+		reconciler:       reconciler,
+		wallets:          wallets,
 		authMiddleware:   authMiddleware,
 	}
 }
@@ -96,11 +118,31 @@ func (a *API) RegisterRoutes(router *gin.Engine) {
 
 	// Protected asset endpoints
 	router.GET("/api/assets", a.authMiddleware.APIKeyAuth(), a.handleAssets)
+	router.GET("/api/assets/halts", a.authMiddleware.APIKeyAuth(), a.handleAssetHalts)
+	router.GET("/api/assets/registry", a.authMiddleware.APIKeyAuth(), a.handleAssetRegistry)
+
+	// Operator endpoints (require an admin token)
+	router.POST("/api/assets/:id/resume", a.authMiddleware.AdminAuth(), a.handleResumeAsset)
+	admin := router.Group("/api/admin")
+	admin.Use(a.authMiddleware.AdminAuth())
+	{
+		admin.GET("/reviews", a.handlePendingReviews)
+		admin.POST("/reviews/:id/approve", a.handleApproveIssuance)
+		admin.POST("/reviews/:id/reject", a.handleRejectIssuance)
+		admin.GET("/reviews/log", a.handleReviewLog)
+		admin.GET("/rpc", a.handleRPCStatus)
+		admin.GET("/reconcile", a.handleReconcile)
+		admin.GET("/costs", a.handleRelayCosts)
+		admin.GET("/wallets", a.handleWallets)
+	}
 
 	// Protected price audit endpoints
 	router.GET("/api/prices/:id/audit", a.authMiddleware.APIKeyAuth(), a.handleAuditPrice)
 	router.GET("/api/prices/audit", a.authMiddleware.APIKeyAuth(), a.handleAuditPriceRange)
 
+	// Protected source endpoints
+	router.GET("/api/sources/reputation", a.authMiddleware.APIKeyAuth(), a.handleSourceReputation)
+
 	// Protected issuance endpoints
 	router.GET("/api/issuances/:id", a.authMiddleware.APIKeyAuth(), a.handleIssuance)
 
@@ -130,9 +172,7 @@ func (a *API) RegisterRoutes(router *gin.Engine) {
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler, url))
 
 	// Health check endpoint
-	router.GET("/api/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{"status": "ok"})
-	})
+	router.GET("/api/health", a.handleHealth)
 }
 
 // @Summary User Sign Up a company
@@ -465,7 +505,7 @@ func (a *API) handleLastPrice(c *gin.Context) {
 	if asset == "" {
 		// Return all assets' last prices
 		prices := make(map[string]*models.UnifiedPrice)
-		for _, assetConfig := range a.cfg.Assets {
+		for _, assetConfig := range a.registry.Assets() {
 			assetID := utils.GenerateIDForAsset(assetConfig.InternalAssetIdentity)
 			price, err := a.priceService.GetLastPrice(c.Request.Context(), assetID)
 			if err != nil {
@@ -568,7 +608,7 @@ func (a *API) handleIssuances(c *gin.Context) {
 }
 
 // @Summary Get issuance details
-// @Description Returns details of a specific issuance
+// @Description Returns details of a specific issuance, with its lifecycle state (queued, submitted, confirmed, failed, replaced) on every contract
 // @Tags issuances
 // @Accept json
 // @Produce json
@@ -590,22 +630,87 @@ func (a *API) handleIssuance(c *gin.Context) {
 }
 
 // @Summary Get available assets
-// @Description Returns list of all available assets
+// @Description Returns list of all available assets, halted ones are flagged
 // @Tags assets
 // @Produce json
 // @Success 200 {array} models.AssetData
 // @Router /assets [get]
 func (a *API) handleAssets(c *gin.Context) {
-	assetData := make([]models.AssetData, len(a.cfg.Assets))
-	for i, asset := range a.cfg.Assets {
+	assets := a.registry.Assets()
+	assetData := make([]models.AssetData, len(assets))
+	for i, asset := range assets {
 		assetData[i] = models.AssetData{
 			AssetID: utils.GenerateIDForAsset(asset.InternalAssetIdentity),
 			Asset:   asset.Name,
 		}
+		assetData[i].Halted = a.haltService.IsHalted(assetData[i].AssetID)
 	}
 	c.JSON(200, assetData)
 }
 
+// @Summary Get the asset registry version
+// @Description Returns the version of the per asset settings in use, and the last reload that was refused as invalid
+// @Tags assets
+// @Produce json
+// @Success 200 {object} models.RegistryStatus
+// @Router /assets/registry [get]
+func (a *API) handleAssetRegistry(c *gin.Context) {
+	c.JSON(200, a.registry.Status())
+}
+
+// @Summary Get asset halts
+// @Description Returns the circuit breaker state of every asset, halted assets are not published on chain
+// @Tags assets
+// @Produce json
+// @Success 200 {array} models.AssetHalt
+// @Failure 500 {object} map[string]string
+// @Router /assets/halts [get]
+func (a *API) handleAssetHalts(c *gin.Context) {
+	halts, err := a.haltService.GetAssetHalts(c.Request.Context())
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to get asset halts, %v", err)})
+		return
+	}
+	c.JSON(200, halts)
+}
+
+// @Summary Resume a halted asset
+// @Description Acknowledges the halt of an asset and resumes publishing, the operator is taken from the admin token
+// @Tags assets
+// @Produce json
+// @Param id path string true "Asset ID"
+// @Success 200 {object} models.AssetHalt
+// @Failure 401 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Security BearerAuth
+// @Router /assets/{id}/resume [post]
+func (a *API) handleResumeAsset(c *gin.Context) {
+	halt, err := a.haltService.ResumeAsset(c.Request.Context(), c.Param("id"), c.GetString("operator"))
+	if err != nil {
+		c.JSON(409, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, halt)
+}
+
+// @Summary Get source reputation scores
+// @Description Returns the adaptive reputation score of every source, used as its weight during aggregation
+// @Tags sources
+// @Produce json
+// @Param asset query string false "Asset ID to filter by (optional)"
+// @Success 200 {array} models.SourceReputation
+// @Failure 500 {object} map[string]string
+// @Router /sources/reputation [get]
+func (a *API) handleSourceReputation(c *gin.Context) {
+	assetID := c.Query("asset")
+	reputations, err := a.sourceService.GetSourceReputations(c.Request.Context(), assetID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to get source reputations, %v", err)})
+		return
+	}
+	c.JSON(200, reputations)
+}
+
 // @Summary Get price audit
 // @Description Returns audit information for a specific price
 // @Tags prices
@@ -761,3 +866,232 @@ func (a *API) handleGetSubscriptionPlans(c *gin.Context) {
 		"plans": a.cfg.SubscriptionPlans,
 	})
 }
+
+// @Summary List denied issuances awaiting review
+// @Description Returns denied issuances no operator decided on yet, newest first, with the aggregate, its provenance, the band it was judged against and the last approved issuance
+// @Tags admin
+// @Produce json
+// @Param asset query string false "Asset ID to filter by (optional)"
+// @Param limit query int false "Max results (default 50)"
+// @Success 200 {array} models.ReviewItem
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Security BearerAuth
+// @Router /admin/reviews [get]
+func (a *API) handlePendingReviews(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+	items, err := a.reviewService.GetPendingReviews(c.Request.Context(), c.Query("asset"), limit)
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to get pending reviews, %v", err)})
+		return
+	}
+	c.JSON(200, items)
+}
+
+// @Summary Approve and relay a denied issuance
+// @Description Overrides the denial of an issuance and relays it, refused once a newer issuance of the asset was approved or while the asset is halted
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Issuance ID"
+// @Param request body models.ReviewRequest true "Reason for the decision"
+// @Success 200 {object} models.IssuanceReview
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Security BearerAuth
+// @Router /admin/reviews/{id}/approve [post]
+func (a *API) handleApproveIssuance(c *gin.Context) {
+	a.handleReview(c, a.reviewService.ApproveIssuance)
+}
+
+// @Summary Reject a denied issuance
+// @Description Closes the review of a denied issuance, it is never relayed
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Issuance ID"
+// @Param request body models.ReviewRequest true "Reason for the decision"
+// @Success 200 {object} models.IssuanceReview
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Security BearerAuth
+// @Router /admin/reviews/{id}/reject [post]
+func (a *API) handleRejectIssuance(c *gin.Context) {
+	a.handleReview(c, a.reviewService.RejectIssuance)
+}
+
+func (a *API) handleReview(c *gin.Context, decide func(ctx context.Context, issuanceID, operator, reason string) (*models.IssuanceReview, error)) {
+	var req models.ReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Reason) == "" {
+		c.JSON(400, gin.H{"error": "A reason is required"})
+		return
+	}
+
+	review, err := decide(c.Request.Context(), c.Param("id"), c.GetString("operator"), req.Reason)
+	switch {
+	case errors.Is(err, services.ErrReviewNotFound):
+		c.JSON(404, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrReviewConflict):
+		c.JSON(409, gin.H{"error": err.Error()})
+	case err != nil && review != nil:
+		// decided and logged, relaying failed
+		c.JSON(502, gin.H{"error": err.Error(), "review": review})
+	case err != nil:
+		c.JSON(500, gin.H{"error": err.Error()})
+	default:
+		c.JSON(200, review)
+	}
+}
+
+// @Summary Get the review audit log
+// @Description Returns every operator decision on denied issuances, newest first
+// @Tags admin
+// @Produce json
+// @Param asset query string false "Asset ID to filter by (optional)"
+// @Param operator query string false "Operator to filter by (optional)"
+// @Param limit query int false "Max results (default 100)"
+// @Success 200 {array} models.IssuanceReview
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Security BearerAuth
+// @Router /admin/reviews/log [get]
+func (a *API) handleReviewLog(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	reviews, err := a.reviewService.GetReviewLog(c.Request.Context(), c.Query("asset"), c.Query("operator"), limit)
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to get review log, %v", err)})
+		return
+	}
+	c.JSON(200, reviews)
+}
+
+// @Summary Get rpc endpoint health
+// @Description Returns every rpc endpoint of every chain in the order the relayer tries them, with head, latency and failure counts
+// @Tags admin
+// @Produce json
+// @Success 200 {array} models.RPCEndpointStatus
+// @Failure 401 {object} map[string]string
+// @Security BearerAuth
+// @Router /admin/rpc [get]
+func (a *API) handleRPCStatus(c *gin.Context) {
+	c.JSON(200, a.rpcPool.Statuses())
+}
+
+// @Summary Get on chain reconciliation
+// @Description Returns the last check of every asset on every contract carrying it, drifted, stale, missing and unreadable feeds first
+// @Tags admin
+// @Produce json
+// @Param status query string false "Only results with this status (ok, drift, stale, missing, error)"
+// @Success 200 {array} models.ReconcileResult
+// @Failure 401 {object} map[string]string
+// @Security BearerAuth
+// @Router /admin/reconcile [get]
+func (a *API) handleReconcile(c *gin.Context) {
+	results := a.reconciler.Results()
+	if status := c.Query("status"); status != "" {
+		filtered := make([]models.ReconcileResult, 0, len(results))
+		for _, result := range results {
+			if result.Status == status {
+				filtered = append(filtered, result)
+			}
+		}
+		results = filtered
+	}
+	c.JSON(200, results)
+}
+
+// @Summary Get relay costs
+// @Description Returns what publishing cost per period, chain and asset, newest first. Every mined batch tx is split evenly between its assets, usd costs use the engine's own price of the chain's native token when the tx was mined
+// @Tags admin
+// @Produce json
+// @Param from query string true "Start in RFC3339 format (e.g., 2024-01-01T00:00:00Z)"
+// @Param to query string true "End in RFC3339 format (e.g., 2024-02-01T00:00:00Z)"
+// @Param interval query string false "Period of a row: hour, day (default), week or month"
+// @Param chain query string false "Only this chain id"
+// @Param asset query string false "Only this asset, by name or id"
+// @Success 200 {array} models.RelayCostSummary
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Security BearerAuth
+// @Router /admin/costs [get]
+func (a *API) handleRelayCosts(c *gin.Context) {
+	from, err := time.Parse(time.RFC3339, c.Query("from"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid 'from' timestamp format. Use RFC3339 format (e.g., 2024-01-01T00:00:00Z)"})
+		return
+	}
+	to, err := time.Parse(time.RFC3339, c.Query("to"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid 'to' timestamp format. Use RFC3339 format (e.g., 2024-01-01T00:00:00Z)"})
+		return
+	}
+
+	summaries, err := a.costService.GetCostReport(c.Request.Context(), models.RelayCostQuery{
+		From:     from,
+		To:       to,
+		ChainID:  c.Query("chain"),
+		AssetID:  c.Query("asset"),
+		Interval: c.Query("interval"),
+	})
+	if errors.Is(err, services.ErrInvalidCostQuery) {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to get relay costs, %v", err)})
+		return
+	}
+	c.JSON(200, summaries)
+}
+
+// HealthResponse is the engine's health with the runway of its relayer
+// wallets per chain
+type HealthResponse struct {
+	Status string               `json:"status"` // ok, or degraded while a wallet needs funds
+	Chains []models.ChainRunway `json:"chains"`
+}
+
+// @Summary Health check
+// @Description Returns the engine status with the relayer wallet runway of every chain, chains needing funds first
+// @Tags health
+// @Produce json
+// @Success 200 {object} HealthResponse
+// @Router /health [get]
+func (a *API) handleHealth(c *gin.Context) {
+	health := HealthResponse{Status: "ok", Chains: []models.ChainRunway{}}
+	if a.wallets != nil {
+		health.Chains = a.wallets.Runways()
+	}
+	for _, chain := range health.Chains {
+		if chain.NeedingFunds > 0 {
+			health.Status = "degraded"
+			break
+		}
+	}
+	c.JSON(200, health)
+}
+
+// @Summary Get relayer wallets
+// @Description Returns the native balance, spend and projected runway of every relayer wallet, wallets needing funds first
+// @Tags admin
+// @Produce json
+// @Success 200 {array} models.WalletBalance
+// @Failure 401 {object} map[string]string
+// @Security BearerAuth
+// @Router /admin/wallets [get]
+func (a *API) handleWallets(c *gin.Context) {
+	wallets := []models.WalletBalance{}
+	if a.wallets != nil {
+		wallets = a.wallets.Results()
+	}
+	c.JSON(200, wallets)
+}
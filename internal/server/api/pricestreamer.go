@@ -64,7 +64,9 @@ func (ps *PriceStreamer) Stop() {
 }
 
 // @Summary Model Stream price updates
-// @Description Server-Sent Events stream of price updates, have a retry mechanism in place for break
+// @Description Server-Sent Events stream of price updates, have a retry mechanism in place for break.
+// @Description New issuances are sent as "price" events, later lifecycle changes (queued, submitted, confirmed, failed, replaced) as "issuance_state" events.
+// @Description Aggregates of an asset halted by its circuit breaker carry "halted": true and are not published on chain.
 // @Tags prices
 // @Produce text/event-stream
 // @Success 200 {string} models.Issuance "SSE stream"
@@ -109,8 +111,9 @@ func (ps *PriceStreamer) HandleStream(c *gin.Context) {
 				return false
 			}
 
-			// Format and send the message
-			err := ps.writeSSEMessage(w, "price", price)
+			// Format and send the message, lifecycle updates after
+			// the initial approval go out as their own event
+			err := ps.writeSSEMessage(w, streamEvent(price), price)
 			if err != nil {
 				ps.logger.Error("Failed to write SSE message",
 					zap.Error(err),
@@ -199,6 +202,16 @@ func (ps *PriceStreamer) writeSSEMessage(w io.Writer, event string, data interfa
 	return err
 }
 
+// streamEvent names the SSE event of an issuance update
+func streamEvent(issuance models.Issuance) string {
+	switch issuance.State {
+	case models.Approved, models.Denied:
+		return "price"
+	default:
+		return "issuance_state"
+	}
+}
+
 // GetClientCount returns the number of active clients
 func (ps *PriceStreamer) GetClientCount() int {
 	ps.mu.RLock()
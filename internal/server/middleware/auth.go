@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"context"
+	"crypto/subtle"
 	"net/http"
 	"strings"
 
@@ -15,16 +16,42 @@ import (
 
 type AuthMiddleware struct {
 	jwtSecret        string
+	adminTokens      map[string]string // operator name -> token
 	dashboardService services.DashboardService
 }
 
-func NewAuthMiddleware(jwtSecret string, dashboardService services.DashboardService) *AuthMiddleware {
+func NewAuthMiddleware(jwtSecret string, adminTokens map[string]string, dashboardService services.DashboardService) *AuthMiddleware {
 	return &AuthMiddleware{
 		jwtSecret:        jwtSecret,
+		adminTokens:      adminTokens,
 		dashboardService: dashboardService,
 	}
 }
 
+// AdminAuth lets operators with a configured admin token through and
+// sets their name as "operator"
+func (a *AuthMiddleware) AdminAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if token == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Admin token required"})
+			c.Abort()
+			return
+		}
+
+		for operator, adminToken := range a.adminTokens {
+			if adminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) == 1 {
+				c.Set("operator", operator)
+				c.Next()
+				return
+			}
+		}
+
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid admin token"})
+		c.Abort()
+	}
+}
+
 // JWTAuth validates JWT tokens for dashboard endpoints
 func (a *AuthMiddleware) JWTAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+
+	"oracle_engine/internal/database/timescale"
+	"oracle_engine/internal/models"
+)
+
+type CostRepository interface {
+	GetRelayCostReport(ctx context.Context, q models.RelayCostQuery, nativeAssets map[string]string) ([]models.RelayCostSummary, error)
+}
+
+type costRepository struct {
+	db *timescale.TimescaleDB
+}
+
+func NewCostRepository(db *timescale.TimescaleDB) CostRepository {
+	return &costRepository{db: db}
+}
+
+func (r *costRepository) GetRelayCostReport(ctx context.Context, q models.RelayCostQuery, nativeAssets map[string]string) ([]models.RelayCostSummary, error) {
+	return r.db.GetRelayCostReport(ctx, q, nativeAssets)
+}
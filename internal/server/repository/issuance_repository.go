@@ -10,6 +10,7 @@ import (
 type IssuanceRepository interface {
 	SaveIssuance(ctx context.Context, issuance models.Issuance) error
 	GetIssuance(ctx context.Context, id string) (*models.Issuance, error)
+	GetIssuanceContractStates(ctx context.Context, id string) ([]models.IssuanceContractState, error)
 }
 
 type issuanceRepository struct {
@@ -27,3 +28,7 @@ func (r *issuanceRepository) SaveIssuance(ctx context.Context, issuance models.I
 func (r *issuanceRepository) GetIssuance(ctx context.Context, id string) (*models.Issuance, error) {
 	return r.db.GetIssuance(ctx, id)
 }
+
+func (r *issuanceRepository) GetIssuanceContractStates(ctx context.Context, id string) ([]models.IssuanceContractState, error) {
+	return r.db.GetIssuanceContractStates(ctx, id)
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"oracle_engine/internal/database/timescale"
+	"oracle_engine/internal/models"
+)
+
+type ReviewRepository interface {
+	GetPendingReviews(ctx context.Context, assetID string, limit int) ([]models.Issuance, error)
+	GetReviewIssuance(ctx context.Context, issuanceID string) (*models.Issuance, error)
+	GetLastApprovedIssuanceBefore(ctx context.Context, assetID string, before time.Time) (*models.Issuance, error)
+	ReviewIssuance(ctx context.Context, review *models.IssuanceReview, outbox func(*models.Issuance) []models.RelayOutboxEntry) (*models.Issuance, []models.RelayOutboxEntry, error)
+	GetIssuanceReviews(ctx context.Context, assetID, operator string, limit int) ([]models.IssuanceReview, error)
+}
+
+type reviewRepository struct {
+	db *timescale.TimescaleDB
+}
+
+func NewReviewRepository(db *timescale.TimescaleDB) ReviewRepository {
+	return &reviewRepository{db: db}
+}
+
+func (r *reviewRepository) GetPendingReviews(ctx context.Context, assetID string, limit int) ([]models.Issuance, error) {
+	return r.db.GetPendingReviews(ctx, assetID, limit)
+}
+
+func (r *reviewRepository) GetReviewIssuance(ctx context.Context, issuanceID string) (*models.Issuance, error) {
+	return r.db.GetReviewIssuance(ctx, issuanceID)
+}
+
+func (r *reviewRepository) GetLastApprovedIssuanceBefore(ctx context.Context, assetID string, before time.Time) (*models.Issuance, error) {
+	return r.db.GetLastApprovedIssuanceBefore(ctx, assetID, before)
+}
+
+func (r *reviewRepository) ReviewIssuance(ctx context.Context, review *models.IssuanceReview, outbox func(*models.Issuance) []models.RelayOutboxEntry) (*models.Issuance, []models.RelayOutboxEntry, error) {
+	return r.db.ReviewIssuance(ctx, review, outbox)
+}
+
+func (r *reviewRepository) GetIssuanceReviews(ctx context.Context, assetID, operator string, limit int) ([]models.IssuanceReview, error) {
+	return r.db.GetIssuanceReviews(ctx, assetID, operator, limit)
+}
@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+
+	"oracle_engine/internal/database/timescale"
+	"oracle_engine/internal/models"
+)
+
+type SourceRepository interface {
+	GetSourceReputations(ctx context.Context, assetID string) ([]models.SourceReputation, error)
+}
+
+type sourceRepository struct {
+	db *timescale.TimescaleDB
+}
+
+func NewSourceRepository(db *timescale.TimescaleDB) SourceRepository {
+	return &sourceRepository{db: db}
+}
+
+func (r *sourceRepository) GetSourceReputations(ctx context.Context, assetID string) ([]models.SourceReputation, error) {
+	return r.db.GetSourceReputations(ctx, assetID)
+}
@@ -4,10 +4,14 @@ import (
 	"context"
 	"net/http"
 
+	"oracle_engine/internal/balances"
 	"oracle_engine/internal/config"
 	"oracle_engine/internal/database/timescale"
 	"oracle_engine/internal/logging"
 	"oracle_engine/internal/models"
+	"oracle_engine/internal/reconcile"
+	"oracle_engine/internal/registry"
+	"oracle_engine/internal/rpcpool"
 	"oracle_engine/internal/server/api"
 	"oracle_engine/internal/server/middleware"
 	"oracle_engine/internal/server/repository"
@@ -25,7 +29,7 @@ type Server struct {
 	api     *api.API
 }
 
-func New(cfg *config.Config, priceCh chan models.Issuance, db *timescale.TimescaleDB) *Server {
+func New(cfg *config.Config, priceCh chan models.Issuance, db *timescale.TimescaleDB, breaker services.AssetBreaker, relayer services.IssuanceRelayer, history services.PriceHistory, registry *registry.Registry, rpcPool *rpcpool.Pool, reconciler *reconcile.Monitor, wallets *balances.Monitor) *Server {
 	// Initialize GORM DB for dashboard operations
 	gormDB, err := timescale.NewTimescaleGORM(cfg.DB_URL)
 	if err != nil {
@@ -35,15 +39,22 @@ func New(cfg *config.Config, priceCh chan models.Issuance, db *timescale.Timesca
 	// Initialize repositories
 	priceRepo := repository.NewPriceRepository(db)
 	issuanceRepo := repository.NewIssuanceRepository(db)
+	sourceRepo := repository.NewSourceRepository(db)
 	dashboardRepo := repository.NewDashboardRepository(gormDB.GetDB())
+	reviewRepo := repository.NewReviewRepository(db)
+	costRepo := repository.NewCostRepository(db)
 
 	// Initialize services
 	priceService := services.NewPriceService(priceRepo)
 	issuanceService := services.NewIssuanceService(issuanceRepo, priceRepo)
+	sourceService := services.NewSourceService(sourceRepo)
 	dashboardService := services.NewDashboardService(dashboardRepo, cfg.JWTSecret, cfg)
+	haltService := services.NewHaltService(breaker)
+	reviewService := services.NewReviewService(reviewRepo, relayer, breaker, history)
+	costService := services.NewCostService(costRepo, registry, cfg)
 
 	// Initialize API
-	api := api.NewAPI(priceService, issuanceService, dashboardService, priceCh, cfg)
+	api := api.NewAPI(priceService, issuanceService, dashboardService, sourceService, haltService, reviewService, costService, priceCh, cfg, registry, rpcPool, reconciler, wallets)
 
 	return &Server{
 		cfg:     cfg,
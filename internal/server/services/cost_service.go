@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"oracle_engine/internal/config"
+	"oracle_engine/internal/models"
+	"oracle_engine/internal/server/repository"
+	"oracle_engine/internal/utils"
+)
+
+var ErrInvalidCostQuery = errors.New("invalid cost query")
+
+var costIntervals = map[string]struct{}{"hour": {}, "day": {}, "week": {}, "month": {}}
+
+var hexAssetID = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+// AssetCatalog lists the configured assets
+type AssetCatalog interface {
+	Assets() []config.AssetConfig
+}
+
+type CostService interface {
+	GetCostReport(ctx context.Context, q models.RelayCostQuery) ([]models.RelayCostSummary, error)
+}
+
+type costService struct {
+	costRepo  repository.CostRepository
+	assets    AssetCatalog
+	cfg       config.CostAccountingConfig
+	contracts []config.ContractConfig
+}
+
+func NewCostService(costRepo repository.CostRepository, assets AssetCatalog, cfg *config.Config) CostService {
+	return &costService{
+		costRepo:  costRepo,
+		assets:    assets,
+		cfg:       cfg.CostAccounting,
+		contracts: cfg.Contracts,
+	}
+}
+
+// GetCostReport sums what publishing cost per period, chain and asset,
+// in usd where the engine prices the chain's native token
+func (s *costService) GetCostReport(ctx context.Context, q models.RelayCostQuery) ([]models.RelayCostSummary, error) {
+	if q.Interval == "" {
+		q.Interval = "day"
+	}
+	if _, ok := costIntervals[q.Interval]; !ok {
+		return nil, fmt.Errorf("%w: interval %q, use hour, day, week or month", ErrInvalidCostQuery, q.Interval)
+	}
+	if !q.From.Before(q.To) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidCostQuery)
+	}
+
+	names := make(map[string]string)
+	for _, asset := range s.assets.Assets() {
+		names[utils.GenerateIDForAsset(asset.InternalAssetIdentity)] = asset.Name
+	}
+	if q.AssetID != "" {
+		q.AssetID = s.assetID(q.AssetID)
+	}
+
+	native := make(map[string]string)
+	for _, ctrct := range s.contracts {
+		if asset := s.cfg.NativeAssetFor(ctrct.ChainID); asset != "" {
+			native[ctrct.ChainID] = s.assetID(asset)
+		}
+	}
+
+	summaries, err := s.costRepo.GetRelayCostReport(ctx, q, native)
+	if err != nil {
+		return nil, err
+	}
+	for i := range summaries {
+		summaries[i].Asset = names[summaries[i].AssetID]
+	}
+	return summaries, nil
+}
+
+// assetID resolves an asset name, internal identity or id to its id
+func (s *costService) assetID(asset string) string {
+	asset = strings.TrimSpace(asset)
+	if hexAssetID.MatchString(asset) {
+		return strings.ToLower(asset)
+	}
+	for _, configured := range s.assets.Assets() {
+		if strings.EqualFold(configured.Name, asset) {
+			return utils.GenerateIDForAsset(configured.InternalAssetIdentity)
+		}
+	}
+	return utils.GenerateIDForAsset(asset)
+}
@@ -0,0 +1,40 @@
+package services
+
+import (
+	"context"
+
+	"oracle_engine/internal/models"
+)
+
+// AssetBreaker is the circuit breaker of the running engine
+type AssetBreaker interface {
+	Statuses() []models.AssetHalt
+	Halted(assetID string) bool
+	Resume(ctx context.Context, assetID string, operator string) (models.AssetHalt, error)
+}
+
+type HaltService interface {
+	GetAssetHalts(ctx context.Context) ([]models.AssetHalt, error)
+	IsHalted(assetID string) bool
+	ResumeAsset(ctx context.Context, assetID string, operator string) (models.AssetHalt, error)
+}
+
+type haltService struct {
+	breaker AssetBreaker
+}
+
+func NewHaltService(breaker AssetBreaker) HaltService {
+	return &haltService{breaker: breaker}
+}
+
+func (s *haltService) GetAssetHalts(ctx context.Context) ([]models.AssetHalt, error) {
+	return s.breaker.Statuses(), nil
+}
+
+func (s *haltService) IsHalted(assetID string) bool {
+	return s.breaker.Halted(assetID)
+}
+
+func (s *haltService) ResumeAsset(ctx context.Context, assetID string, operator string) (models.AssetHalt, error) {
+	return s.breaker.Resume(ctx, assetID, operator)
+}
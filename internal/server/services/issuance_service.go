@@ -28,6 +28,16 @@ func (s *issuanceService) SaveIssuance(ctx context.Context, issuance models.Issu
 	return s.issuanceRepo.SaveIssuance(ctx, issuance)
 }
 
+// GetIssuance returns an issuance with its current state on every contract
 func (s *issuanceService) GetIssuance(ctx context.Context, id string) (*models.Issuance, error) {
-	return s.issuanceRepo.GetIssuance(ctx, id)
+	issuance, err := s.issuanceRepo.GetIssuance(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	contracts, err := s.issuanceRepo.GetIssuanceContractStates(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	issuance.Contracts = contracts
+	return issuance, nil
 }
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"oracle_engine/internal/database/timescale"
+	"oracle_engine/internal/logging"
+	"oracle_engine/internal/models"
+	"oracle_engine/internal/server/repository"
+
+	"go.uber.org/zap"
+)
+
+var (
+	ErrReviewNotFound = errors.New("denied issuance not found")
+	ErrReviewConflict = errors.New("review not possible")
+)
+
+// IssuanceRelayer hands approved issuances to the running relayer, with
+// the outbox entries stored alongside them
+type IssuanceRelayer interface {
+	Outbox(issuance *models.Issuance) []models.RelayOutboxEntry
+	AcceptQueued(issuance *models.Issuance, entries []models.RelayOutboxEntry) error
+}
+
+// PriceHistory is what consensus judges new aggregates against, an
+// approval makes the approved price the new level
+type PriceHistory interface {
+	Rebase(price models.UnifiedPrice)
+}
+
+type ReviewService interface {
+	GetPendingReviews(ctx context.Context, assetID string, limit int) ([]models.ReviewItem, error)
+	ApproveIssuance(ctx context.Context, issuanceID, operator, reason string) (*models.IssuanceReview, error)
+	RejectIssuance(ctx context.Context, issuanceID, operator, reason string) (*models.IssuanceReview, error)
+	GetReviewLog(ctx context.Context, assetID, operator string, limit int) ([]models.IssuanceReview, error)
+}
+
+type reviewService struct {
+	reviewRepo repository.ReviewRepository
+	relayer    IssuanceRelayer
+	breaker    AssetBreaker
+	history    PriceHistory
+}
+
+func NewReviewService(reviewRepo repository.ReviewRepository, relayer IssuanceRelayer, breaker AssetBreaker, history PriceHistory) ReviewService {
+	return &reviewService{
+		reviewRepo: reviewRepo,
+		relayer:    relayer,
+		breaker:    breaker,
+		history:    history,
+	}
+}
+
+// GetPendingReviews returns the denied issuances waiting for a decision with
+// the approved issuance each was compared to
+func (s *reviewService) GetPendingReviews(ctx context.Context, assetID string, limit int) ([]models.ReviewItem, error) {
+	issuances, err := s.reviewRepo.GetPendingReviews(ctx, assetID, limit)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]models.ReviewItem, 0, len(issuances))
+	for _, issuance := range issuances {
+		last, err := s.reviewRepo.GetLastApprovedIssuanceBefore(ctx, issuance.PriceAssetID, issuance.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, models.ReviewItem{Issuance: issuance, LastApproved: last})
+	}
+	return items, nil
+}
+
+// ApproveIssuance overrides the denial and relays the issuance
+func (s *reviewService) ApproveIssuance(ctx context.Context, issuanceID, operator, reason string) (*models.IssuanceReview, error) {
+	pending, err := s.reviewRepo.GetReviewIssuance(ctx, issuanceID)
+	if err != nil {
+		return nil, reviewError(err)
+	}
+	if s.breaker.Halted(pending.PriceAssetID) {
+		return nil, fmt.Errorf("%w: asset %s is halted, resume it before approving", ErrReviewConflict, pending.PriceAssetID)
+	}
+
+	review := &models.IssuanceReview{
+		IssuanceID: issuanceID,
+		Decision:   models.ReviewApprove,
+		Reason:     reason,
+		Operator:   operator,
+		CreatedAt:  time.Now(),
+	}
+	issuance, entries, err := s.reviewRepo.ReviewIssuance(ctx, review, s.relayer.Outbox)
+	if err != nil {
+		return nil, reviewError(err)
+	}
+
+	// later aggregates at the approved level are not denied against the old one
+	s.history.Rebase(issuance.Price)
+
+	logging.Logger.Info("Operator approved denied issuance",
+		zap.String("issuance", issuanceID),
+		zap.String("asset", review.AssetID),
+		zap.String("operator", operator),
+		zap.String("reason", reason),
+	)
+	if err := s.relayer.AcceptQueued(issuance, entries); err != nil {
+		return review, fmt.Errorf("approved but not relayed: %w", err)
+	}
+	return review, nil
+}
+
+// RejectIssuance closes the review, the issuance stays denied for good
+func (s *reviewService) RejectIssuance(ctx context.Context, issuanceID, operator, reason string) (*models.IssuanceReview, error) {
+	review := &models.IssuanceReview{
+		IssuanceID: issuanceID,
+		Decision:   models.ReviewReject,
+		Reason:     reason,
+		Operator:   operator,
+		CreatedAt:  time.Now(),
+	}
+	if _, _, err := s.reviewRepo.ReviewIssuance(ctx, review, nil); err != nil {
+		return nil, reviewError(err)
+	}
+	logging.Logger.Info("Operator rejected denied issuance",
+		zap.String("issuance", issuanceID),
+		zap.String("asset", review.AssetID),
+		zap.String("operator", operator),
+		zap.String("reason", reason),
+	)
+	return review, nil
+}
+
+func (s *reviewService) GetReviewLog(ctx context.Context, assetID, operator string, limit int) ([]models.IssuanceReview, error) {
+	return s.reviewRepo.GetIssuanceReviews(ctx, assetID, operator, limit)
+}
+
+// reviewError maps storage errors to the ones callers act on
+func reviewError(err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return ErrReviewNotFound
+	case errors.Is(err, timescale.ErrNotReviewable), errors.Is(err, timescale.ErrReviewOutdated):
+		return fmt.Errorf("%w: %v", ErrReviewConflict, err)
+	default:
+		return err
+	}
+}
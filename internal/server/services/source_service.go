@@ -0,0 +1,24 @@
+package services
+
+import (
+	"context"
+
+	"oracle_engine/internal/models"
+	"oracle_engine/internal/server/repository"
+)
+
+type SourceService interface {
+	GetSourceReputations(ctx context.Context, assetID string) ([]models.SourceReputation, error)
+}
+
+type sourceService struct {
+	sourceRepo repository.SourceRepository
+}
+
+func NewSourceService(sourceRepo repository.SourceRepository) SourceService {
+	return &sourceService{sourceRepo: sourceRepo}
+}
+
+func (s *sourceService) GetSourceReputations(ctx context.Context, assetID string) ([]models.SourceReputation, error) {
+	return s.sourceRepo.GetSourceReputations(ctx, assetID)
+}
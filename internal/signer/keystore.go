@@ -0,0 +1,53 @@
+package signer
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"oracle_engine/internal/config"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+)
+
+const defaultPassphraseEnv = "RELAYER_KEYSTORE_PASSPHRASE"
+
+// newKeystore decrypts a keystore file with the passphrase from its file,
+// or else from its env var
+func newKeystore(cfg config.SignerConfig) (*localSigner, error) {
+	if cfg.Keystore == "" {
+		return nil, fmt.Errorf("keystore signer without a keystore file")
+	}
+	encrypted, err := os.ReadFile(cfg.Keystore)
+	if err != nil {
+		return nil, fmt.Errorf("reading keystore: %w", err)
+	}
+	passphrase, err := passphrase(cfg)
+	if err != nil {
+		return nil, err
+	}
+	key, err := keystore.DecryptKey(encrypted, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting keystore %s: %w", cfg.Keystore, err)
+	}
+	return &localSigner{key: key.PrivateKey, address: key.Address}, nil
+}
+
+func passphrase(cfg config.SignerConfig) (string, error) {
+	if cfg.PassphraseFile != "" {
+		raw, err := os.ReadFile(cfg.PassphraseFile)
+		if err != nil {
+			return "", fmt.Errorf("reading keystore passphrase: %w", err)
+		}
+		return strings.TrimRight(string(raw), "\r\n"), nil
+	}
+	env := cfg.PassphraseEnv
+	if env == "" {
+		env = defaultPassphraseEnv
+	}
+	value, ok := os.LookupEnv(env)
+	if !ok {
+		return "", fmt.Errorf("no keystore passphrase, set %s or passphrase_file", env)
+	}
+	return value, nil
+}
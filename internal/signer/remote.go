@@ -0,0 +1,161 @@
+package signer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+
+	"oracle_engine/internal/config"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	defaultSignMethod = "eth_signTransaction"
+	signTimeout       = 30 * time.Second // clef may wait on a human or a rule
+)
+
+// remoteSigner asks an external signer to sign every tx
+type remoteSigner struct {
+	url     string
+	method  string
+	address common.Address
+	client  *rpc.Client
+}
+
+func newRemote(cfg config.SignerConfig) (*remoteSigner, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("remote signer without a url")
+	}
+	if !common.IsHexAddress(cfg.Address) {
+		return nil, fmt.Errorf("remote signer %s without a valid address", cfg.URL)
+	}
+	method := cfg.Method
+	if method == "" {
+		method = defaultSignMethod
+	}
+	// http clients connect on the first call
+	client, err := rpc.Dial(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("remote signer %s: %w", cfg.URL, err)
+	}
+	return &remoteSigner{url: cfg.URL, method: method, address: common.HexToAddress(cfg.Address), client: client}, nil
+}
+
+func (s *remoteSigner) Address() common.Address {
+	return s.address
+}
+
+// signTxArgs is the tx as eth_signTransaction and clef's
+// account_signTransaction take it
+type signTxArgs struct {
+	From                 common.MixedcaseAddress  `json:"from"`
+	To                   *common.MixedcaseAddress `json:"to"`
+	Gas                  hexutil.Uint64           `json:"gas"`
+	GasPrice             *hexutil.Big             `json:"gasPrice,omitempty"`
+	MaxFeePerGas         *hexutil.Big             `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *hexutil.Big             `json:"maxPriorityFeePerGas,omitempty"`
+	Value                hexutil.Big              `json:"value"`
+	Nonce                hexutil.Uint64           `json:"nonce"`
+	Input                hexutil.Bytes            `json:"input"`
+	Data                 hexutil.Bytes            `json:"data"` // older signers only read data
+	ChainID              *hexutil.Big             `json:"chainId,omitempty"`
+}
+
+func (s *remoteSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	args := signTxArgs{
+		From:    common.NewMixedcaseAddress(s.address),
+		Gas:     hexutil.Uint64(tx.Gas()),
+		Value:   hexutil.Big(*tx.Value()),
+		Nonce:   hexutil.Uint64(tx.Nonce()),
+		Input:   tx.Data(),
+		Data:    tx.Data(),
+		ChainID: (*hexutil.Big)(chainID),
+	}
+	if tx.To() != nil {
+		to := common.NewMixedcaseAddress(*tx.To())
+		args.To = &to
+	}
+	switch tx.Type() {
+	case types.LegacyTxType:
+		args.GasPrice = (*hexutil.Big)(tx.GasPrice())
+	case types.DynamicFeeTxType:
+		args.MaxFeePerGas = (*hexutil.Big)(tx.GasFeeCap())
+		args.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GasTipCap())
+	default:
+		return nil, fmt.Errorf("remote signer: unsupported tx type %d", tx.Type())
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, signTimeout)
+	defer cancel()
+	var result json.RawMessage
+	if err := s.client.CallContext(ctx, &result, s.method, args); err != nil {
+		return nil, fmt.Errorf("remote signer %s: %w", s.url, err)
+	}
+	signed, err := decodeSigned(result)
+	if err != nil {
+		return nil, fmt.Errorf("remote signer %s: %w", s.url, err)
+	}
+	if err := s.verify(tx, signed, chainID); err != nil {
+		return nil, fmt.Errorf("remote signer %s: %w", s.url, err)
+	}
+	return signed, nil
+}
+
+// decodeSigned reads the signed tx of either answer, {raw, tx} like geth
+// and clef or the raw tx alone
+func decodeSigned(result json.RawMessage) (*types.Transaction, error) {
+	var raw hexutil.Bytes
+	if len(result) > 0 && result[0] == '"' {
+		if err := json.Unmarshal(result, &raw); err != nil {
+			return nil, fmt.Errorf("decoding signed tx: %w", err)
+		}
+	} else {
+		var res struct {
+			Raw hexutil.Bytes `json:"raw"`
+		}
+		if err := json.Unmarshal(result, &res); err != nil {
+			return nil, fmt.Errorf("decoding signed tx: %w", err)
+		}
+		raw = res.Raw
+	}
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("no signed tx in the answer")
+	}
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		return nil, fmt.Errorf("decoding signed tx: %w", err)
+	}
+	return tx, nil
+}
+
+// verify makes sure the signer signed what was asked, from the address
+// it was asked for
+func (s *remoteSigner) verify(want, got *types.Transaction, chainID *big.Int) error {
+	sender, err := types.Sender(types.LatestSignerForChainID(chainID), got)
+	if err != nil {
+		return fmt.Errorf("recovering sender: %w", err)
+	}
+	if sender != s.address {
+		return fmt.Errorf("signed by %s, want %s", sender.Hex(), s.address.Hex())
+	}
+	same := got.Type() == want.Type() &&
+		got.Nonce() == want.Nonce() &&
+		got.Gas() == want.Gas() &&
+		got.Value().Cmp(want.Value()) == 0 &&
+		bytes.Equal(got.Data(), want.Data()) &&
+		got.GasFeeCap().Cmp(want.GasFeeCap()) == 0 &&
+		got.GasTipCap().Cmp(want.GasTipCap()) == 0 &&
+		(got.To() == nil) == (want.To() == nil) &&
+		(got.To() == nil || *got.To() == *want.To())
+	if !same {
+		return fmt.Errorf("signed tx %s differs from the one asked for", got.Hash().Hex())
+	}
+	return nil
+}
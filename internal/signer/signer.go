@@ -0,0 +1,88 @@
+package signer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"oracle_engine/internal/config"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+/*
+Signer:
+Signs the txs of one relayer address. A keystore signer decrypts a
+go-ethereum keystore file once at startup, the passphrase comes from a
+file or an env var, so no plaintext key sits in the config or the
+environment. A remote signer keeps the key out of the engine entirely
+and asks an external signer like clef for every tx. A raw signer takes
+a plaintext hex key and is meant for development only.
+*/
+
+// Signer signs the txs of one address
+type Signer interface {
+	Address() common.Address
+	SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+// New builds the signer a config describes, a config without a type and
+// with a key is raw
+func New(cfg config.SignerConfig) (Signer, error) {
+	kind := cfg.Type
+	if kind == "" && cfg.Key != "" {
+		kind = config.SignerRaw
+	}
+	switch kind {
+	case config.SignerKeystore:
+		return newKeystore(cfg)
+	case config.SignerRemote:
+		return newRemote(cfg)
+	case config.SignerRaw:
+		return newRaw(cfg.Key)
+	default:
+		return nil, fmt.Errorf("unknown signer type %q", cfg.Type)
+	}
+}
+
+// TransactOpts signs bound contract txs with s
+func TransactOpts(ctx context.Context, s Signer, chainID *big.Int) *bind.TransactOpts {
+	address := s.Address()
+	return &bind.TransactOpts{
+		From:    address,
+		Context: ctx,
+		Signer: func(from common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if from != address {
+				return nil, bind.ErrNotAuthorized
+			}
+			return s.SignTx(ctx, tx, chainID)
+		},
+	}
+}
+
+// localSigner holds its key in memory, decrypted or raw
+type localSigner struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+}
+
+func newRaw(hexKey string) (*localSigner, error) {
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(hexKey, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("raw key: %w", err)
+	}
+	return &localSigner{key: key, address: crypto.PubkeyToAddress(key.PublicKey)}, nil
+}
+
+func (s *localSigner) Address() common.Address {
+	return s.address
+}
+
+func (s *localSigner) SignTx(_ context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return types.SignTx(tx, types.LatestSignerForChainID(chainID), s.key)
+}
@@ -0,0 +1,143 @@
+package signer
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"oracle_engine/internal/config"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/google/uuid"
+)
+
+var testChainID = big.NewInt(8453)
+
+func unsignedTx() *types.Transaction {
+	to := common.HexToAddress("0xfeed")
+	return types.NewTx(&types.DynamicFeeTx{
+		ChainID:   testChainID,
+		Nonce:     3,
+		GasTipCap: big.NewInt(1_000_000),
+		GasFeeCap: big.NewInt(2_000_000_000),
+		Gas:       90_000,
+		To:        &to,
+		Data:      []byte{0xca, 0xfe},
+	})
+}
+
+func TestKeystoreSignerDecryptsWithThePassphraseFile(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	dir := t.TempDir()
+	encrypted, err := keystore.EncryptKey(&keystore.Key{
+		Id:         uuid.New(),
+		Address:    crypto.PubkeyToAddress(key.PublicKey),
+		PrivateKey: key,
+	}, "correct horse", keystore.LightScryptN, keystore.LightScryptP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(dir, "relayer.json")
+	passFile := filepath.Join(dir, "passphrase")
+	os.WriteFile(keyFile, encrypted, 0o600)
+	os.WriteFile(passFile, []byte("correct horse\n"), 0o600)
+
+	s, err := New(config.SignerConfig{Type: config.SignerKeystore, Keystore: keyFile, PassphraseFile: passFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := s.SignTx(context.Background(), unsignedTx(), testChainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender, err := types.Sender(types.LatestSignerForChainID(testChainID), signed)
+	if err != nil || sender != s.Address() || sender != crypto.PubkeyToAddress(key.PublicKey) {
+		t.Fatalf("signed by %s, %v, want %s", sender.Hex(), err, s.Address().Hex())
+	}
+
+	os.WriteFile(passFile, []byte("wrong"), 0o600)
+	if _, err := New(config.SignerConfig{Type: config.SignerKeystore, Keystore: keyFile, PassphraseFile: passFile}); err == nil {
+		t.Fatal("decrypted the keystore with a wrong passphrase")
+	}
+}
+
+// fakeSigner answers eth_signTransaction like an external signer, tamper
+// changes the tx before signing it
+func fakeSigner(t *testing.T, key []byte, tamper bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Params []signTxArgs    `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Method != defaultSignMethod || len(req.Params) != 1 {
+			t.Errorf("unexpected request %s %v", req.Method, err)
+			return
+		}
+		args := req.Params[0]
+		nonce := uint64(args.Nonce)
+		if tamper {
+			nonce++
+		}
+		to := args.To.Address()
+		tx := types.NewTx(&types.DynamicFeeTx{
+			ChainID:   args.ChainID.ToInt(),
+			Nonce:     nonce,
+			GasTipCap: args.MaxPriorityFeePerGas.ToInt(),
+			GasFeeCap: args.MaxFeePerGas.ToInt(),
+			Gas:       uint64(args.Gas),
+			To:        &to,
+			Value:     args.Value.ToInt(),
+			Data:      args.Input,
+		})
+		privateKey, _ := crypto.ToECDSA(key)
+		signed, _ := types.SignTx(tx, types.LatestSignerForChainID(args.ChainID.ToInt()), privateKey)
+		raw, _ := signed.MarshalBinary()
+		json.NewEncoder(w).Encode(map[string]any{
+			"jsonrpc": "2.0",
+			"id":      req.ID,
+			"result":  map[string]any{"raw": hexutil.Bytes(raw), "tx": signed},
+		})
+	}))
+}
+
+func TestRemoteSignerRefusesTxsOtherThanAskedFor(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	address := crypto.PubkeyToAddress(key.PublicKey)
+
+	honest := fakeSigner(t, crypto.FromECDSA(key), false)
+	defer honest.Close()
+	s, err := New(config.SignerConfig{Type: config.SignerRemote, URL: honest.URL, Address: address.Hex()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := s.SignTx(context.Background(), unsignedTx(), testChainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signed.Nonce() != 3 || signed.Hash() == unsignedTx().Hash() {
+		t.Fatalf("got tx with nonce %d", signed.Nonce())
+	}
+
+	tampering := fakeSigner(t, crypto.FromECDSA(key), true)
+	defer tampering.Close()
+	s, _ = New(config.SignerConfig{Type: config.SignerRemote, URL: tampering.URL, Address: address.Hex()})
+	if _, err := s.SignTx(context.Background(), unsignedTx(), testChainID); err == nil {
+		t.Fatal("accepted a tx with another nonce than asked for")
+	}
+
+	other, _ := crypto.GenerateKey()
+	s, _ = New(config.SignerConfig{Type: config.SignerRemote, URL: honest.URL, Address: crypto.PubkeyToAddress(other.PublicKey).Hex()})
+	if _, err := s.SignTx(context.Background(), unsignedTx(), testChainID); err == nil {
+		t.Fatal("accepted a tx signed by another address")
+	}
+}
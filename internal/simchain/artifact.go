@@ -0,0 +1,60 @@
+package simchain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Artifact is a compiled contract, its abi and creation bytecode
+type Artifact struct {
+	ABI      abi.ABI
+	Bytecode []byte
+}
+
+// LoadArtifact reads a forge or hardhat artifact, forge nests the
+// bytecode in an object, hardhat keeps it as a string
+func LoadArtifact(path string) (*Artifact, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		ABI      json.RawMessage `json:"abi"`
+		Bytecode json.RawMessage `json:"bytecode"`
+	}
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	parsed, err := abi.JSON(strings.NewReader(string(file.ABI)))
+	if err != nil {
+		return nil, fmt.Errorf("parsing the abi of %s: %w", path, err)
+	}
+
+	var bytecode string
+	if err := json.Unmarshal(file.Bytecode, &bytecode); err != nil {
+		var forge struct {
+			Object string `json:"object"`
+		}
+		if err := json.Unmarshal(file.Bytecode, &forge); err != nil {
+			return nil, fmt.Errorf("parsing the bytecode of %s: %w", path, err)
+		}
+		bytecode = forge.Object
+	}
+	if !strings.HasPrefix(bytecode, "0x") {
+		bytecode = "0x" + bytecode
+	}
+	code, err := hexutil.Decode(bytecode)
+	if err != nil {
+		return nil, fmt.Errorf("decoding the bytecode of %s: %w", path, err)
+	}
+	if len(code) == 0 {
+		return nil, errors.New(path + " holds no bytecode, an interface or abstract contract")
+	}
+	return &Artifact{ABI: parsed, Bytecode: code}, nil
+}
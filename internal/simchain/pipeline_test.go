@@ -0,0 +1,194 @@
+package simchain
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"oracle_engine/internal/aggregator"
+	"oracle_engine/internal/config"
+	"oracle_engine/internal/consensus"
+	"oracle_engine/internal/consensus/breaker"
+	"oracle_engine/internal/models"
+	"oracle_engine/internal/reconcile"
+	"oracle_engine/internal/registry"
+	"oracle_engine/internal/relayer"
+	"oracle_engine/internal/utils"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/google/uuid"
+)
+
+// memoryStore keeps what consensus saves in memory
+type memoryStore struct {
+	mu        sync.Mutex
+	issuances []models.Issuance
+	relays    []models.RelayOutboxEntry
+}
+
+func (m *memoryStore) GetRecentPrices(ctx context.Context, assetID string, limit int) ([]models.UnifiedPrice, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var prices []models.UnifiedPrice
+	for i := len(m.issuances) - 1; i >= 0 && len(prices) < limit; i-- {
+		if issuance := m.issuances[i]; issuance.PriceAssetID == assetID && issuance.State == models.Approved {
+			prices = append(prices, issuance.Price)
+		}
+	}
+	return prices, nil
+}
+
+func (m *memoryStore) last(assetID string, approved bool) (*models.Issuance, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := len(m.issuances) - 1; i >= 0; i-- {
+		issuance := m.issuances[i]
+		if issuance.PriceAssetID == assetID && (!approved || issuance.State == models.Approved) {
+			return &issuance, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *memoryStore) GetLastIssuance(ctx context.Context, assetID string) (*models.Issuance, error) {
+	return m.last(assetID, false)
+}
+
+func (m *memoryStore) GetLastApprovedIssuance(ctx context.Context, assetID string) (*models.Issuance, error) {
+	return m.last(assetID, true)
+}
+
+func (m *memoryStore) SaveIssuance(ctx context.Context, issuance models.Issuance) error {
+	return m.SaveIssuanceWithOutbox(ctx, issuance, nil)
+}
+
+func (m *memoryStore) SaveIssuanceWithOutbox(ctx context.Context, issuance models.Issuance, entries []models.RelayOutboxEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.issuances = append(m.issuances, issuance)
+	m.relays = append(m.relays, entries...)
+	return nil
+}
+
+func (m *memoryStore) SaveIssuanceSkip(ctx context.Context, skip models.IssuanceSkip) error {
+	return nil
+}
+
+func (m *memoryStore) SaveReviewInputs(ctx context.Context, issuance models.Issuance) error {
+	return nil
+}
+
+func (m *memoryStore) SaveConsensusReport(ctx context.Context, report models.ConsensusReport, issuanceID string) error {
+	return nil
+}
+
+func (m *memoryStore) SaveReportedIssuance(ctx context.Context, issuance models.Issuance, isNew bool, entries []models.RelayOutboxEntry) error {
+	return m.SaveIssuanceWithOutbox(ctx, issuance, entries)
+}
+
+func (m *memoryStore) SaveAggregationProvenance(ctx context.Context, priceID string, timestamp time.Time, prov *models.AggregationProvenance) error {
+	return nil
+}
+
+func (m *memoryStore) LinkRawPricesToAggregatedPrice(ctx context.Context, aggregatedPriceID string, timestamp time.Time, rawPriceIDs []string) error {
+	return nil
+}
+
+// TestFeedPricesReachTheChain runs feed prices through the aggregator,
+// consensus and the relayer onto a simulated chain
+func TestFeedPricesReachTheChain(t *testing.T) {
+	asset := config.AssetConfig{
+		Name:                  "USDT/USD",
+		InternalAssetIdentity: "0xUSDT",
+		Settings:              config.DefaultAssetSetting,
+	}
+	assetID := utils.GenerateIDForAsset(asset.InternalAssetIdentity)
+	cfg := &config.Config{
+		RelayerMode:    config.RelayerModeSimulated,
+		SimulatedChain: testArtifacts(t),
+		Assets:         []config.AssetConfig{asset},
+		Contracts: []config.ContractConfig{{
+			ChainID: "8453", Address: verifierAddress, Decimals: 8, Assets: []string{asset.Name},
+		}},
+		RelayerBatch: config.RelayerBatchConfig{MaxIssuances: 1, FlushIntervalSeconds: 1},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sims, err := New(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(sims.Close)
+
+	reg, err := registry.New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := relayer.New(cfg, nil, func(chainID string) (relayer.ChainClient, error) {
+		return sims.Client(chainID)
+	})
+	r.UseRegistry(reg)
+	store := &memoryStore{}
+	c := consensus.New(reg, r, store, breaker.New(reg.Setting, nil))
+	aggr := aggregator.New(ctx, cfg, reg, nil)
+	runUntilCleanup(t, cancel, func() { c.Ambassador(ctx, aggr.AggrOutCh) })
+	waitReady(t, r)
+	// the aggregator spins up the unit of the registry asset in the background
+	deadline := time.Now().Add(10 * time.Second)
+	for aggr.UnitCount() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("aggregator unit never started")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	prices := make(aggregator.AggrUnitCh, aggregator.BUFFER_MAX_SIZE)
+	runUntilCleanup(t, cancel, func() { aggr.Run(ctx, prices) })
+	sources := []string{"pyth", "fixer", "moralis"}
+	for i := 0; i < aggregator.BUFFER_MAX_SIZE; i++ {
+		prices <- models.UnifiedPrice{
+			ID:        uuid.NewString(),
+			AssetID:   assetID,
+			Source:    sources[i%len(sources)],
+			Value:     float64(100_010 + i%3*2),
+			Expo:      -5,
+			Timestamp: time.Now(),
+		}
+	}
+
+	var issuance models.Issuance
+	for issuance.State != models.Submitted {
+		select {
+		case update := <-c.IssuanceChan():
+			if update.PriceAssetID != assetID {
+				continue
+			}
+			if update.State == models.Denied || update.State == models.Failed || update.Halted {
+				t.Fatalf("issuance ended %s, halted %v", update.State, update.Halted)
+			}
+			issuance = update
+		case <-time.After(10 * time.Second):
+			t.Fatalf("no issuance was submitted, last %+v", issuance)
+		}
+	}
+	if saved, err := store.GetLastApprovedIssuance(ctx, assetID); err != nil || saved.ID != issuance.ID {
+		t.Fatalf("consensus saved %+v, %v", saved, err)
+	}
+
+	reader := reconcile.NewChainReader(func(chainID string) (bind.ContractCaller, error) {
+		return sims.Client(chainID)
+	})
+	feed, err := reader.ReadFeed(ctx, cfg.Contracts[0], assetID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !feed.Exists || math.Abs(feed.Value-issuance.PriceValue) > 1e-8 {
+		t.Fatalf("contract holds %v, want %v", feed.Value, issuance.PriceValue)
+	}
+	if math.Abs(feed.Value-1.00012) > 0.0001 {
+		t.Fatalf("contract holds %v, not the aggregate of the feeds", feed.Value)
+	}
+}
@@ -0,0 +1,278 @@
+package simchain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strconv"
+	"sync"
+	"time"
+
+	"oracle_engine/internal/config"
+	"oracle_engine/internal/logging"
+	"oracle_engine/internal/signer"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/ethconfig"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/params"
+	"go.uber.org/zap"
+)
+
+/*
+Simulated chains:
+One in process chain per configured chain id, on go-ethereum's simulated
+backend. Every configured contract gets the compiled IfaPriceFeed and
+IfaPriceFeedVerifier of simulated_chain deployed, with the chain's first
+relayer key as the relayer node, and its address in the config moves to
+the deployed verifier. Every relayer key starts with a funded balance,
+so the relayer runs unchanged against them. A sent tx is mined into a
+block at once, Run adds empty blocks so confirmations and heartbeats
+move on.
+
+The price feed's address constructor inputs get the deployer, and a
+price feed with setVerifier(address) is pointed at its verifier.
+*/
+
+// fundedBalance is what every relayer key starts with, 1000 ether
+var fundedBalance = new(big.Int).Mul(big.NewInt(1000), big.NewInt(params.Ether))
+
+type Chains struct {
+	chains map[string]*Chain
+}
+
+// New starts a simulated chain for every chain of the contracts and
+// deploys the contracts to it. A chain without relayer signers gets a
+// generated raw key, set in cfg like the deployed addresses so the
+// relayer picks them up.
+func New(ctx context.Context, cfg *config.Config) (*Chains, error) {
+	verifier, err := LoadArtifact(cfg.SimulatedChain.VerifierArtifact)
+	if err != nil {
+		return nil, fmt.Errorf("loading the verifier artifact: %w", err)
+	}
+	feed, err := LoadArtifact(cfg.SimulatedChain.PriceFeedArtifact)
+	if err != nil {
+		return nil, fmt.Errorf("loading the price feed artifact: %w", err)
+	}
+
+	contracts := make(map[string][]int)
+	order := make([]string, 0)
+	for i, ctrct := range cfg.Contracts {
+		if _, ok := contracts[ctrct.ChainID]; !ok {
+			order = append(order, ctrct.ChainID)
+		}
+		contracts[ctrct.ChainID] = append(contracts[ctrct.ChainID], i)
+	}
+
+	c := &Chains{chains: make(map[string]*Chain, len(order))}
+	for _, chainID := range order {
+		if len(cfg.SignersFor(chainID)) == 0 {
+			key, err := crypto.GenerateKey()
+			if err != nil {
+				c.Close()
+				return nil, err
+			}
+			if cfg.RelayerKeys == nil {
+				cfg.RelayerKeys = make(map[string][]string)
+			}
+			cfg.RelayerKeys[chainID] = []string{common.Bytes2Hex(crypto.FromECDSA(key))}
+		}
+		relayers, err := addresses(cfg.SignersFor(chainID))
+		if err != nil {
+			c.Close()
+			return nil, fmt.Errorf("chain %s: %w", chainID, err)
+		}
+		chain, err := NewChain(chainID, relayers)
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+		c.chains[chainID] = chain
+		for _, i := range contracts[chainID] {
+			address, err := chain.Deploy(ctx, verifier, feed, relayers[0])
+			if err != nil {
+				c.Close()
+				return nil, fmt.Errorf("chain %s: deploying for %s: %w", chainID, cfg.Contracts[i].Address, err)
+			}
+			logging.Logger.Info("Deployed simulated verifier",
+				zap.String("chainID", chainID),
+				zap.String("configured", cfg.Contracts[i].Address),
+				zap.String("deployed", address.Hex()),
+			)
+			cfg.Contracts[i].Address = address.Hex()
+		}
+		logging.Logger.Info("Started simulated chain",
+			zap.String("chainID", chainID),
+			zap.Int("contracts", len(contracts[chainID])),
+			zap.String("relayerNode", relayers[0].Hex()),
+		)
+	}
+	return c, nil
+}
+
+func addresses(signers []config.SignerConfig) ([]common.Address, error) {
+	addrs := make([]common.Address, 0, len(signers))
+	for i, cfg := range signers {
+		s, err := signer.New(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("relayer signer %d: %w", i, err)
+		}
+		addrs = append(addrs, s.Address())
+	}
+	return addrs, nil
+}
+
+// Client is the client of a simulated chain
+func (c *Chains) Client(chainID string) (*Client, error) {
+	chain, ok := c.chains[chainID]
+	if !ok {
+		return nil, fmt.Errorf("no simulated chain %s", chainID)
+	}
+	return chain.Client(), nil
+}
+
+// Run mines a block on every chain each period until ctx is done
+func (c *Chains) Run(ctx context.Context, period time.Duration) {
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, chain := range c.chains {
+				chain.Commit()
+			}
+		}
+	}
+}
+
+func (c *Chains) Close() {
+	for _, chain := range c.chains {
+		chain.Close()
+	}
+}
+
+// Chain is one simulated chain
+type Chain struct {
+	id       string
+	backend  *simulated.Backend
+	deployer *bind.TransactOpts // funded key that deploys and owns the contracts
+
+	mu sync.Mutex // serializes mining
+}
+
+// NewChain starts a chain with the relayers funded
+func NewChain(chainID string, relayers []common.Address) (*Chain, error) {
+	id, err := strconv.ParseUint(chainID, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("parsing chain id %q: %w", chainID, err)
+	}
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	deployer, err := bind.NewKeyedTransactorWithChainID(key, new(big.Int).SetUint64(id))
+	if err != nil {
+		return nil, err
+	}
+
+	alloc := make(types.GenesisAlloc, len(relayers)+1)
+	alloc[deployer.From] = types.Account{Balance: fundedBalance}
+	for _, relayer := range relayers {
+		alloc[relayer] = types.Account{Balance: fundedBalance}
+	}
+
+	backend := simulated.NewBackend(alloc, func(_ *node.Config, ethConf *ethconfig.Config) {
+		chainConfig := *params.AllDevChainProtocolChanges
+		chainConfig.ChainID = new(big.Int).SetUint64(id)
+		ethConf.Genesis.Config = &chainConfig
+		ethConf.NetworkId = id
+	})
+	return &Chain{id: chainID, backend: backend, deployer: deployer}, nil
+}
+
+// Deploy deploys a price feed and a verifier submitting to it with
+// relayerNode as its relayer node, and returns the verifier's address
+func (c *Chain) Deploy(ctx context.Context, verifier, feed *Artifact, relayerNode common.Address) (common.Address, error) {
+	client := c.Client()
+	owner := c.deployer
+
+	var args []interface{}
+	for _, input := range feed.ABI.Constructor.Inputs {
+		if input.Type.T != abi.AddressTy {
+			return common.Address{}, fmt.Errorf("price feed constructor input %s is not an address", input.Name)
+		}
+		args = append(args, owner.From)
+	}
+	_, tx, _, err := bind.DeployContract(owner, feed.ABI, feed.Bytecode, client, args...)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("deploying the price feed: %w", err)
+	}
+	feedAddress, err := bind.WaitDeployed(ctx, client, tx)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("deploying the price feed: %w", err)
+	}
+
+	_, tx, _, err = bind.DeployContract(owner, verifier.ABI, verifier.Bytecode, client, relayerNode, feedAddress, owner.From)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("deploying the verifier: %w", err)
+	}
+	verifierAddress, err := bind.WaitDeployed(ctx, client, tx)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("deploying the verifier: %w", err)
+	}
+
+	if _, ok := feed.ABI.Methods["setVerifier"]; ok {
+		contract := bind.NewBoundContract(feedAddress, feed.ABI, client, client, client)
+		tx, err := contract.Transact(owner, "setVerifier", verifierAddress)
+		if err != nil {
+			return common.Address{}, fmt.Errorf("setting the verifier of the price feed: %w", err)
+		}
+		receipt, err := bind.WaitMined(ctx, client, tx)
+		if err != nil {
+			return common.Address{}, fmt.Errorf("setting the verifier of the price feed: %w", err)
+		}
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			return common.Address{}, fmt.Errorf("setting the verifier of the price feed reverted in %s", tx.Hash().Hex())
+		}
+	}
+	return verifierAddress, nil
+}
+
+func (c *Chain) Client() *Client {
+	return &Client{Client: c.backend.Client(), chain: c}
+}
+
+// Commit mines the pending txs into a new block
+func (c *Chain) Commit() common.Hash {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.backend.Commit()
+}
+
+func (c *Chain) Close() {
+	if err := c.backend.Close(); err != nil {
+		logging.Logger.Warn("Failed to close simulated chain", zap.String("chainID", c.id), zap.Error(err))
+	}
+}
+
+// Client mines every tx it sends right away, like a chain with instant
+// blocks
+type Client struct {
+	simulated.Client
+	chain *Chain
+}
+
+func (c *Client) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	if err := c.Client.SendTransaction(ctx, tx); err != nil {
+		return err
+	}
+	c.chain.Commit()
+	return nil
+}
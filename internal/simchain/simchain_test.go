@@ -0,0 +1,278 @@
+package simchain
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/big"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"oracle_engine/internal/config"
+	"oracle_engine/internal/logging"
+	"oracle_engine/internal/models"
+	"oracle_engine/internal/reconcile"
+	"oracle_engine/internal/relayer"
+	"oracle_engine/internal/utils"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"go.uber.org/zap"
+
+	importVerifier "oracle_engine/pkg/abi"
+)
+
+const verifierAddress = "0x00000000000000000000000000000000000F33D1"
+
+var verifierABI, _ = importVerifier.VerifierMetaData.GetAbi()
+
+// TestMain sets the logger once, the goroutines of a test read it until
+// its cleanups have run
+func TestMain(m *testing.M) {
+	logging.Logger = zap.NewNop()
+	os.Exit(m.Run())
+}
+
+// runUntilCleanup runs fn in the background, the test cleanup cancels it
+// and waits for it to return before the next test starts
+func runUntilCleanup(t *testing.T, cancel context.CancelFunc, fn func()) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn()
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+// waitReady waits for the contract routines of the relayer to be up
+func waitReady(t *testing.T, r *relayer.Relayer) {
+	t.Helper()
+	select {
+	case <-r.Ready():
+	case <-time.After(10 * time.Second):
+		t.Fatal("relayer never started")
+	}
+}
+
+// newTestChain deploys the test artifacts to a new chain and returns the
+// verifier and its relayer node
+func newTestChain(t *testing.T) (*Chain, common.Address, *bind.TransactOpts) {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth, err := bind.NewKeyedTransactorWithChainID(key, big.NewInt(8453))
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain, err := NewChain("8453", []common.Address{auth.From})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(chain.Close)
+
+	artifacts := testArtifacts(t)
+	verifier, err := LoadArtifact(artifacts.VerifierArtifact)
+	if err != nil {
+		t.Fatal(err)
+	}
+	feed, err := LoadArtifact(artifacts.PriceFeedArtifact)
+	if err != nil {
+		t.Fatal(err)
+	}
+	address, err := chain.Deploy(context.Background(), verifier, feed, auth.From)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return chain, address, auth
+}
+
+func TestVerifierStoresFeedsOfTheRelayerNode(t *testing.T) {
+	chain, address, auth := newTestChain(t)
+	client := chain.Client()
+
+	verifier, err := importVerifier.NewVerifier(address, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := [][32]byte{utils.HexToBytes32(utils.GenerateIDForAsset("0xUSDT")), utils.HexToBytes32(utils.GenerateIDForAsset("0xETH"))}
+	feeds := []importVerifier.IIfaPriceFeedPriceFeed{
+		{Price: big.NewInt(100_012), Decimal: -5, LastUpdateTime: 1_750_000_000},
+		{Price: big.NewInt(3), Decimal: -2, LastUpdateTime: 1_750_000_001},
+	}
+	if _, err := verifier.SubmitPriceFeed(auth, ids, feeds); err != nil {
+		t.Fatal(err)
+	}
+
+	node, err := verifier.RelayerNode(&bind.CallOpts{})
+	if err != nil || node != auth.From {
+		t.Fatalf("relayer node %s, %v, want %s", node.Hex(), err, auth.From.Hex())
+	}
+	feedAddress, err := verifier.IfaPriceFeed(&bind.CallOpts{})
+	if err != nil || feedAddress == (common.Address{}) {
+		t.Fatalf("price feed %s, %v", feedAddress.Hex(), err)
+	}
+	reader, err := importVerifier.NewPriceFeedReader(feedAddress, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, id := range ids {
+		got, exists, err := reader.GetAssetInfo(&bind.CallOpts{}, id)
+		if err != nil || !exists {
+			t.Fatalf("asset %d: exists %v, %v", i, exists, err)
+		}
+		if got.Price.Cmp(feeds[i].Price) != 0 || got.Decimal != feeds[i].Decimal || got.LastUpdateTime != feeds[i].LastUpdateTime {
+			t.Fatalf("asset %d: stored %+v, want %+v", i, got, feeds[i])
+		}
+	}
+	if _, exists, err := reader.GetAssetInfo(&bind.CallOpts{}, [32]byte{1}); err != nil || exists {
+		t.Fatalf("unknown asset exists %v, %v", exists, err)
+	}
+
+	// another key is refused like by the real verifier
+	calldata, err := verifierABI.Pack("submitPriceFeed", ids[:1], feeds[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.CallContract(context.Background(), ethereum.CallMsg{From: common.HexToAddress("0x1"), To: &address, Data: calldata}, nil)
+	if revert := revertData(err); !strings.HasPrefix(revert, errorSelector("OnlyRelayerNode")) {
+		t.Fatalf("submission of a key that is not the relayer node: %v, want OnlyRelayerNode", err)
+	}
+	if _, err := verifier.SubmitPriceFeed(auth, ids, feeds[:1]); err == nil || !strings.Contains(err.Error(), "revert") {
+		t.Fatalf("mismatched lengths: %v, want a revert", err)
+	}
+}
+
+func errorSelector(name string) string {
+	id := verifierABI.Errors[name].ID
+	return hexutil.Encode(id[:4])
+}
+
+func revertData(err error) string {
+	var dataErr rpc.DataError
+	if !errors.As(err, &dataErr) {
+		return ""
+	}
+	data, _ := dataErr.ErrorData().(string)
+	return data
+}
+
+type lastIssuance struct{ issuance *models.Issuance }
+
+func (l lastIssuance) GetLastRelayedIssuanceBefore(ctx context.Context, assetID string, before time.Time) (*models.Issuance, error) {
+	return l.issuance, nil
+}
+
+type assets []config.AssetConfig
+
+func (a assets) Assets() []config.AssetConfig { return a }
+
+func (a assets) Setting(assetID string) config.AssetSetting { return config.AssetSetting{} }
+
+// TestRelayerPublishesToSimulatedChain runs approved issuances through the
+// relayer onto a simulated chain and reconciles the contract against them,
+// the asset the contract refuses is dropped from the batch
+func TestRelayerPublishesToSimulatedChain(t *testing.T) {
+	asset := config.AssetConfig{Name: "USDT/USD", InternalAssetIdentity: "0xUSDT"}
+	broken := config.AssetConfig{Name: "BROKEN/USD", InternalAssetIdentity: "0xBROKEN"}
+	ctrct := config.ContractConfig{ChainID: "8453", Address: verifierAddress, Decimals: 8}
+	cfg := &config.Config{
+		RelayerMode:    config.RelayerModeSimulated,
+		SimulatedChain: testArtifacts(t),
+		Assets:         []config.AssetConfig{asset, broken},
+		Contracts:      []config.ContractConfig{ctrct},
+		RelayerBatch:   config.RelayerBatchConfig{MaxIssuances: 2, FlushIntervalSeconds: 1},
+		Reconcile:      config.ReconcileConfig{Enabled: true, MaxDrift: 0.001},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sims, err := New(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(sims.Close)
+	if len(cfg.KeysFor("8453")) != 1 {
+		t.Fatal("no relayer key was generated for the chain")
+	}
+	if ctrct = cfg.Contracts[0]; ctrct.Address == verifierAddress {
+		t.Fatal("the contract was not moved to the deployed verifier")
+	}
+	r := relayer.New(cfg, nil, func(chainID string) (relayer.ChainClient, error) {
+		return sims.Client(chainID)
+	})
+	events := make(chan models.Issuance, 32)
+	r.StreamTransitions(events)
+	runUntilCleanup(t, cancel, func() { r.Start(ctx) })
+
+	issuanceOf := func(id string, asset config.AssetConfig, value float64) *models.Issuance {
+		assetID := utils.GenerateIDForAsset(asset.InternalAssetIdentity)
+		return &models.Issuance{
+			ID:    id,
+			State: models.Approved,
+			Price: models.UnifiedPrice{
+				AssetID:   assetID,
+				Value:     value,
+				Expo:      -5,
+				Timestamp: time.Unix(1_750_000_000, 0),
+			},
+			PriceValue:   value / 100_000,
+			PriceAssetID: assetID,
+			CreatedAt:    time.Now(),
+		}
+	}
+	issuance := issuanceOf("iss-1", asset, 100_012)
+	refused := issuanceOf("iss-2", broken, 0)
+
+	waitReady(t, r)
+	if err := r.AcceptIssuance(issuance); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.AcceptIssuance(refused); err != nil {
+		t.Fatal(err)
+	}
+
+	states := make(map[string]models.Issuance)
+	for states["iss-1"].State != models.Submitted || states["iss-2"].State != models.Failed {
+		select {
+		case update := <-events:
+			states[update.ID] = update
+		case <-time.After(10 * time.Second):
+			t.Fatalf("batch never went out, states %+v", states)
+		}
+	}
+	if errText := states["iss-2"].Contracts[0].Error; !strings.Contains(errText, "InvalidAssePrice") {
+		t.Fatalf("refused asset failed with %q, want InvalidAssePrice", errText)
+	}
+
+	reader := reconcile.NewChainReader(func(chainID string) (bind.ContractCaller, error) {
+		return sims.Client(chainID)
+	})
+	feed, err := reader.ReadFeed(ctx, ctrct, issuance.PriceAssetID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(feed.Value-issuance.PriceValue) > 1e-9 || !feed.UpdatedAt.Equal(issuance.Price.Timestamp) {
+		t.Fatalf("contract holds %v at %s, want %v at %s", feed.Value, feed.UpdatedAt, issuance.PriceValue, issuance.Price.Timestamp)
+	}
+	if feed, err := reader.ReadFeed(ctx, ctrct, refused.PriceAssetID); err != nil || feed.Exists {
+		t.Fatalf("refused asset on chain: %+v, %v", feed, err)
+	}
+
+	monitor := reconcile.New(cfg, assets{asset}, r.Carries, lastIssuance{issuance}, reader)
+	monitor.Check(ctx)
+	results := monitor.Results()
+	if len(results) != 1 || results[0].Status != models.ReconcileOK {
+		t.Fatalf("reconcile results %+v, want one in sync", results)
+	}
+}
@@ -0,0 +1,254 @@
+package simchain
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"oracle_engine/internal/config"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/program"
+
+	importVerifier "oracle_engine/pkg/abi"
+)
+
+/*
+The solidity sources of IfaPriceFeedVerifier and IfaPriceFeed are not
+part of this repo, so without SIMCHAIN_VERIFIER_ARTIFACT and
+SIMCHAIN_PRICE_FEED_ARTIFACT pointing at their compiled artifacts the
+tests deploy a stand-in assembled here, as both contracts. It answers
+the same abi as both at once:
+
+	submitPriceFeed(bytes32[],(int256,int8,uint64)[])
+	IfaPriceFeed() returns its own address
+	relayerNode() returns storage slot 0
+	getAssetInfo(bytes32) returns the stored feed and whether it exists
+
+A submission from anyone but the relayer node reverts with
+OnlyRelayerNode(address), index and price arrays of different length
+revert with InvalidAssetIndexorPriceLength() and a price below one with
+InvalidAssePrice(), the errors of the real verifier.
+An asset's price, decimal and update time live in the storage slots at
+its id and the two after it. The constructor stores its first argument
+as the relayer node, the price feed deployed without one accepts anyone.
+*/
+
+// relayerSlot holds the address allowed to submit, zero allows anyone
+const relayerSlot = 0
+
+// testArtifacts are the compiled contracts the tests deploy
+func testArtifacts(t *testing.T) config.SimulatedChainConfig {
+	t.Helper()
+	artifacts := config.SimulatedChainConfig{
+		VerifierArtifact:  os.Getenv("SIMCHAIN_VERIFIER_ARTIFACT"),
+		PriceFeedArtifact: os.Getenv("SIMCHAIN_PRICE_FEED_ARTIFACT"),
+	}
+	if artifacts.VerifierArtifact != "" && artifacts.PriceFeedArtifact != "" {
+		return artifacts
+	}
+
+	bytecode := hexutil.Encode(deployable(assembleVerifier(t)))
+	write := func(name, abiJSON string) string {
+		path := filepath.Join(t.TempDir(), name+".json")
+		raw, err := json.Marshal(map[string]interface{}{
+			"abi":      json.RawMessage(abiJSON),
+			"bytecode": map[string]string{"object": bytecode},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, raw, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	return config.SimulatedChainConfig{
+		VerifierArtifact:  write("IfaPriceFeedVerifier", importVerifier.VerifierMetaData.ABI),
+		PriceFeedArtifact: write("IfaPriceFeed", importVerifier.PriceFeedReaderABI),
+	}
+}
+
+// deployable prefixes runtime code with a constructor storing the first
+// constructor argument in the relayer slot and returning the code
+func deployable(runtime []byte) []byte {
+	const constructorSize = 29
+	size := func(n int) []byte { return []byte{byte(n >> 8), byte(n)} }
+
+	code := []byte{byte(vm.PUSH1), 0x20, byte(vm.PUSH2)}
+	code = append(code, size(constructorSize+len(runtime))...)
+	code = append(code, byte(vm.PUSH1), 0, byte(vm.CODECOPY))
+	code = append(code, byte(vm.PUSH1), 0, byte(vm.MLOAD), byte(vm.PUSH1), relayerSlot, byte(vm.SSTORE))
+	code = append(code, byte(vm.PUSH2))
+	code = append(code, size(len(runtime))...)
+	code = append(code, byte(vm.PUSH2))
+	code = append(code, size(constructorSize)...)
+	code = append(code, byte(vm.PUSH1), 0, byte(vm.CODECOPY))
+	code = append(code, byte(vm.PUSH2))
+	code = append(code, size(len(runtime))...)
+	code = append(code, byte(vm.PUSH1), 0, byte(vm.RETURN))
+	return append(code, runtime...)
+}
+
+// assembleVerifier is the runtime code of the stand-in
+func assembleVerifier(t *testing.T) []byte {
+	t.Helper()
+	verifierABI, err := importVerifier.VerifierMetaData.GetAbi()
+	if err != nil {
+		t.Fatal(err)
+	}
+	readerABI, err := abi.JSON(strings.NewReader(importVerifier.PriceFeedReaderABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sel := func(parsed *abi.ABI, method string) []byte {
+		return parsed.Methods[method].ID
+	}
+	errorsABI, err := abi.JSON(strings.NewReader(importVerifier.OracleErrorsABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	errSel := func(name string) []byte {
+		id := errorsABI.Errors[name].ID
+		return id[:4]
+	}
+
+	a := newAssembler()
+	p := a.p
+
+	// dispatch on the selector
+	p.Push(0).Op(vm.CALLDATALOAD).Push(0xe0).Op(vm.SHR)
+	for _, route := range []struct {
+		selector []byte
+		label    string
+	}{
+		{sel(verifierABI, "submitPriceFeed"), "submit"},
+		{sel(verifierABI, "IfaPriceFeed"), "feed"},
+		{sel(verifierABI, "relayerNode"), "relayer"},
+		{sel(&readerABI, "getAssetInfo"), "info"},
+	} {
+		p.Op(vm.DUP1).Push(route.selector).Op(vm.EQ)
+		a.jumpIf(route.label)
+	}
+	p.Push(0).Op(vm.DUP1).Op(vm.REVERT)
+
+	// IfaPriceFeed()
+	a.label("feed")
+	p.Op(vm.ADDRESS).Push(0).Op(vm.MSTORE)
+	p.Push(0x20).Push(0).Op(vm.RETURN)
+
+	// relayerNode()
+	a.label("relayer")
+	p.Push(relayerSlot).Op(vm.SLOAD).Push(0).Op(vm.MSTORE)
+	p.Push(0x20).Push(0).Op(vm.RETURN)
+
+	// getAssetInfo(bytes32)
+	a.label("info")
+	p.Push(4).Op(vm.CALLDATALOAD)
+	p.Op(vm.DUP1).Op(vm.SLOAD).Push(0).Op(vm.MSTORE)
+	p.Op(vm.DUP1).Push(1).Op(vm.ADD).Op(vm.SLOAD).Push(0x20).Op(vm.MSTORE)
+	p.Push(2).Op(vm.ADD).Op(vm.SLOAD).Op(vm.DUP1).Push(0x40).Op(vm.MSTORE)
+	p.Op(vm.ISZERO).Op(vm.ISZERO).Push(0x60).Op(vm.MSTORE)
+	p.Push(0x80).Push(0).Op(vm.RETURN)
+
+	// submitPriceFeed(bytes32[],(int256,int8,uint64)[])
+	a.label("submit")
+	p.Push(relayerSlot).Op(vm.SLOAD)
+	p.Op(vm.DUP1).Op(vm.ISZERO).Op(vm.SWAP1).Op(vm.CALLER).Op(vm.EQ).Op(vm.OR)
+	a.jumpIf("authorized")
+	p.Push(errSel("OnlyRelayerNode")).Push(0xe0).Op(vm.SHL).Push(0).Op(vm.MSTORE)
+	p.Op(vm.CALLER).Push(4).Op(vm.MSTORE)
+	p.Push(0x24).Push(0).Op(vm.REVERT)
+
+	// stack: index array, price array, their length, position
+	a.label("authorized")
+	p.Push(4).Op(vm.CALLDATALOAD).Push(4).Op(vm.ADD)
+	p.Push(0x24).Op(vm.CALLDATALOAD).Push(4).Op(vm.ADD)
+	p.Op(vm.DUP2).Op(vm.CALLDATALOAD).Op(vm.DUP2).Op(vm.CALLDATALOAD)
+	p.Op(vm.DUP2).Op(vm.EQ)
+	a.jumpIf("lengths")
+	p.Push(errSel("InvalidAssetIndexorPriceLength")).Push(0xe0).Op(vm.SHL).Push(0).Op(vm.MSTORE)
+	p.Push(4).Push(0).Op(vm.REVERT)
+
+	a.label("lengths")
+	p.Push(0)
+	a.label("loop")
+	p.Op(vm.DUP2).Op(vm.DUP2).Op(vm.LT).Op(vm.ISZERO)
+	a.jumpIf("done")
+	// asset id, the slot of its price
+	p.Op(vm.DUP1).Push(5).Op(vm.SHL).Op(vm.DUP5).Op(vm.ADD).Push(0x20).Op(vm.ADD).Op(vm.CALLDATALOAD)
+	// its feed, three words inline
+	p.Op(vm.DUP2).Push(0x60).Op(vm.MUL).Op(vm.DUP5).Op(vm.ADD).Push(0x20).Op(vm.ADD)
+	p.Push(1).Op(vm.DUP2).Op(vm.CALLDATALOAD).Op(vm.SLT)
+	a.jumpIf("price")
+	p.Op(vm.DUP1).Op(vm.CALLDATALOAD).Op(vm.DUP3).Op(vm.SSTORE)
+	p.Op(vm.DUP1).Push(0x20).Op(vm.ADD).Op(vm.CALLDATALOAD).Op(vm.DUP3).Push(1).Op(vm.ADD).Op(vm.SSTORE)
+	p.Push(0x40).Op(vm.ADD).Op(vm.CALLDATALOAD).Op(vm.SWAP1).Push(2).Op(vm.ADD).Op(vm.SSTORE)
+	p.Push(1).Op(vm.ADD)
+	a.jump("loop")
+
+	a.label("done")
+	p.Op(vm.STOP)
+
+	a.label("price")
+	p.Push(errSel("InvalidAssePrice")).Push(0xe0).Op(vm.SHL).Push(0).Op(vm.MSTORE)
+	p.Push(4).Push(0).Op(vm.REVERT)
+
+	code, err := a.bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+// assembler resolves jump labels, every jump pushes its target with a
+// PUSH2 patched once all labels are known
+type assembler struct {
+	p      *program.Program
+	labels map[string]uint16
+	fixups map[int]string // offset of a PUSH2 argument -> label
+}
+
+func newAssembler() *assembler {
+	return &assembler{p: program.New(), labels: make(map[string]uint16), fixups: make(map[int]string)}
+}
+
+func (a *assembler) label(name string) {
+	_, pc := a.p.Jumpdest()
+	a.labels[name] = uint16(pc)
+}
+
+func (a *assembler) pushLabel(name string) {
+	a.p.Op(vm.PUSH2)
+	a.fixups[a.p.Size()] = name
+	a.p.Append([]byte{0, 0})
+}
+
+// jumpIf jumps to the label when the top of the stack is not zero
+func (a *assembler) jumpIf(name string) {
+	a.pushLabel(name)
+	a.p.Op(vm.JUMPI)
+}
+
+func (a *assembler) jump(name string) {
+	a.pushLabel(name)
+	a.p.Op(vm.JUMP)
+}
+
+func (a *assembler) bytes() ([]byte, error) {
+	code := a.p.Bytes()
+	for offset, name := range a.fixups {
+		pc, ok := a.labels[name]
+		if !ok {
+			return nil, errors.New("undefined label " + name)
+		}
+		binary.BigEndian.PutUint16(code[offset:], pc)
+	}
+	return code, nil
+}
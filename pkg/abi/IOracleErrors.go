@@ -0,0 +1,5 @@
+package verifier
+
+// OracleErrorsABI holds the custom errors of the IOracle verifier and the
+// price feed behind it, to decode what a reverted submission says
+const OracleErrorsABI = `[{"type":"error","name":"AlreadyInitialized","inputs":[]},{"type":"error","name":"InvalidAssePrice","inputs":[]},{"type":"error","name":"InvalidAssetIndexorPriceLength","inputs":[]},{"type":"error","name":"InvalidAuthor","inputs":[{"name":"received","type":"address","internalType":"address"},{"name":"expected","type":"address","internalType":"address"}]},{"type":"error","name":"InvalidForwarderAddress","inputs":[]},{"type":"error","name":"InvalidRelayerNode","inputs":[{"name":"_address","type":"address","internalType":"address"}]},{"type":"error","name":"InvalidSender","inputs":[{"name":"sender","type":"address","internalType":"address"},{"name":"expected","type":"address","internalType":"address"}]},{"type":"error","name":"InvalidWorkflowId","inputs":[{"name":"received","type":"bytes32","internalType":"bytes32"},{"name":"expected","type":"bytes32","internalType":"bytes32"}]},{"type":"error","name":"InvalidWorkflowName","inputs":[{"name":"received","type":"bytes10","internalType":"bytes10"},{"name":"expected","type":"bytes10","internalType":"bytes10"}]},{"type":"error","name":"NewOwnerIsZeroAddress","inputs":[]},{"type":"error","name":"NoHandoverRequest","inputs":[]},{"type":"error","name":"OnlyRelayerNode","inputs":[{"name":"_caller","type":"address","internalType":"address"}]},{"type":"error","name":"Unauthorized","inputs":[]},{"type":"error","name":"WorkflowNameRequiresAuthorValidation","inputs":[]}]`
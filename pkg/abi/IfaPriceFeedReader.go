@@ -0,0 +1,38 @@
+package verifier
+
+import (
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// PriceFeedReaderABI is the read side of the IIfaPriceFeed contract the
+// verifier submits to, enough to read back what was published
+const PriceFeedReaderABI = `[{"type":"function","name":"getAssetInfo","stateMutability":"view","inputs":[{"name":"_assetIndex","type":"bytes32","internalType":"bytes32"}],"outputs":[{"name":"assetInfo","type":"tuple","internalType":"struct IIfaPriceFeed.PriceFeed","components":[{"name":"price","type":"int256","internalType":"int256"},{"name":"decimal","type":"int8","internalType":"int8"},{"name":"lastUpdateTime","type":"uint64","internalType":"uint64"}]},{"name":"exist","type":"bool","internalType":"bool"}]}]`
+
+// PriceFeedReader reads stored prices from an IIfaPriceFeed contract
+type PriceFeedReader struct {
+	contract *bind.BoundContract
+}
+
+func NewPriceFeedReader(address common.Address, caller bind.ContractCaller) (*PriceFeedReader, error) {
+	parsed, err := abi.JSON(strings.NewReader(PriceFeedReaderABI))
+	if err != nil {
+		return nil, err
+	}
+	return &PriceFeedReader{contract: bind.NewBoundContract(address, parsed, caller, nil, nil)}, nil
+}
+
+// GetAssetInfo is the stored feed of an asset index, exist is false for
+// an asset the contract never received
+func (r *PriceFeedReader) GetAssetInfo(opts *bind.CallOpts, assetIndex [32]byte) (IIfaPriceFeedPriceFeed, bool, error) {
+	var out []interface{}
+	if err := r.contract.Call(opts, &out, "getAssetInfo", assetIndex); err != nil {
+		return IIfaPriceFeedPriceFeed{}, false, err
+	}
+	feed := *abi.ConvertType(out[0], new(IIfaPriceFeedPriceFeed)).(*IIfaPriceFeedPriceFeed)
+	exist := *abi.ConvertType(out[1], new(bool)).(*bool)
+	return feed, exist, nil
+}
@@ -0,0 +1,46 @@
+package verifier
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+// TestOracleAdminMatchesIOracle checks the copied admin surface against the
+// ABI the ioracle bindings are generated from
+func TestOracleAdminMatchesIOracle(t *testing.T) {
+	raw, err := os.ReadFile("../../oracle/contracts/evm/src/abi/IOracle.abi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ioracle, err := abi.JSON(strings.NewReader(string(raw)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin, err := abi.JSON(strings.NewReader(OracleAdminABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, method := range admin.Methods {
+		generated, ok := ioracle.Methods[name]
+		if !ok {
+			t.Errorf("%s is not in IOracle", name)
+			continue
+		}
+		if method.Sig != generated.Sig || method.StateMutability != generated.StateMutability {
+			t.Errorf("%s is %s %s, IOracle has %s %s", name, method.Sig, method.StateMutability, generated.Sig, generated.StateMutability)
+		}
+		if len(method.Outputs) != len(generated.Outputs) {
+			t.Errorf("%s returns %d values, IOracle %d", name, len(method.Outputs), len(generated.Outputs))
+			continue
+		}
+		for i, output := range method.Outputs {
+			if output.Type.String() != generated.Outputs[i].Type.String() {
+				t.Errorf("%s returns %s, IOracle %s", name, output.Type, generated.Outputs[i].Type)
+			}
+		}
+	}
+}
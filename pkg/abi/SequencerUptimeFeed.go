@@ -0,0 +1,5 @@
+package verifier
+
+// SequencerUptimeFeedABI reads an L2 sequencer uptime feed, an answer of
+// 0 is up and 1 is down, startedAt is when the status last changed
+const SequencerUptimeFeedABI = `[{"inputs":[],"name":"latestRoundData","outputs":[{"internalType":"uint80","name":"roundId","type":"uint80"},{"internalType":"int256","name":"answer","type":"int256"},{"internalType":"uint256","name":"startedAt","type":"uint256"},{"internalType":"uint256","name":"updatedAt","type":"uint256"},{"internalType":"uint80","name":"answeredInRound","type":"uint80"}],"stateMutability":"view","type":"function"}]`